@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/config"
 	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/internal/jobs"
+	"github.com/AhmedHossam777/go-mongo/internal/mailer"
 	"github.com/AhmedHossam777/go-mongo/internal/repository"
 	"github.com/AhmedHossam777/go-mongo/internal/services"
 	"github.com/AhmedHossam777/go-mongo/routes"
@@ -43,23 +47,152 @@ func main() {
 	}
 
 	courseRepo := repository.NewCourseRepo(db)
-	courseService := services.NewCourseService(courseRepo)
+	curriculumRepo := repository.NewCurriculumRepo(db)
+	materialRepo := repository.NewCourseMaterialRepo(db)
+	materialFileRepo := repository.NewFileRepo(db, "materials")
+	enrollmentRepo := repository.NewEnrollmentRepo(db)
+	progressRepo := repository.NewCourseProgressRepo(db)
+	certificateRepo := repository.NewCertificateRepo(db)
+	reviewRepo := repository.NewReviewRepo(db)
+	reviewVoteRepo := repository.NewReviewVoteRepo(db)
+	revisionRepo := repository.NewCourseRevisionRepo(db)
+	userRepo := repository.NewUserRepo(db)
+	courseService := services.NewCourseService(
+		courseRepo, curriculumRepo, materialRepo, materialFileRepo, enrollmentRepo,
+		progressRepo, certificateRepo, reviewRepo, reviewVoteRepo, revisionRepo,
+	)
 	courseHandler := handlers.NewCourseHandler(courseService)
+	curriculumService := services.NewCurriculumService(curriculumRepo, courseRepo)
+	curriculumHandler := handlers.NewCurriculumHandler(curriculumService)
+	materialService := services.NewCourseMaterialService(
+		materialRepo, materialFileRepo, courseRepo, enrollmentRepo,
+	)
+	materialHandler := handlers.NewCourseMaterialHandler(materialService)
+	certificateService := services.NewCertificateService(
+		certificateRepo, enrollmentRepo, courseRepo, userRepo,
+	)
+	certificateHandler := handlers.NewCertificateHandler(certificateService)
+	enrollmentService := services.NewEnrollmentService(
+		enrollmentRepo, courseRepo, certificateService,
+	)
+	enrollmentHandler := handlers.NewEnrollmentHandler(enrollmentService)
+	progressService := services.NewCourseProgressService(
+		progressRepo, enrollmentRepo, curriculumRepo, courseRepo,
+		certificateService,
+	)
+	progressHandler := handlers.NewCourseProgressHandler(progressService)
+	reviewService := services.NewReviewService(
+		reviewRepo, reviewVoteRepo, courseRepo, enrollmentRepo,
+	)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	prerequisiteService := services.NewPrerequisiteService(
+		courseRepo, enrollmentRepo,
+	)
+	prerequisiteHandler := handlers.NewPrerequisiteHandler(prerequisiteService)
+	revisionService := services.NewCourseRevisionService(revisionRepo, courseRepo)
+	revisionHandler := handlers.NewCourseRevisionHandler(revisionService)
+	cloneService := services.NewCourseCloneService(
+		courseRepo, curriculumRepo, materialRepo, materialFileRepo, userRepo,
+	)
+	cloneHandler := handlers.NewCourseCloneHandler(cloneService)
 
-	userRepo := repository.NewUserRepo(db)
-	userService := services.NewUserService(userRepo)
+	refreshTokenRepo := repository.NewRefreshTokenRepo(db)
+
+	avatarRepo := repository.NewFileRepo(db, "avatars")
+	membershipRepo := repository.NewMembershipRepo(db)
+	mail := mailer.NewMailer()
+
+	err = services.CheckPurgePolicy()
+	if err != nil {
+		log.Fatal("Invalid user purge settings:", err)
+	}
+
+	invitationRepo := repository.NewInvitationRepo(db)
+	applicationRepo := repository.NewInstructorApplicationRepo(db)
+	exportRepo := repository.NewDataExportRepo(db)
+	exportFileRepo := repository.NewFileRepo(db, "exports")
+
+	userService := services.NewUserService(
+		userRepo, avatarRepo, refreshTokenRepo, courseService, membershipRepo,
+		exportRepo, exportFileRepo, applicationRepo, invitationRepo, mail,
+	)
 	userHandler := handlers.NewUserHandler(userService)
 
-	refreshTokenRepo := repository.NewRefreshTokenRepo(db)
-	authService := services.NewAuthService(userService, refreshTokenRepo)
+	userImportService := services.NewUserImportService(
+		userRepo, membershipRepo, mail,
+	)
+	userImportHandler := handlers.NewUserImportHandler(userImportService)
+
+	invitationService := services.NewInvitationService(
+		invitationRepo, userRepo, mail,
+	)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+
+	organizationRepo := repository.NewOrganizationRepo(db)
+	organizationService := services.NewOrganizationService(
+		organizationRepo, membershipRepo, userRepo, refreshTokenRepo,
+	)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+
+	applicationService := services.NewInstructorApplicationService(
+		applicationRepo, userRepo, membershipRepo, refreshTokenRepo, mail,
+	)
+	applicationHandler := handlers.NewInstructorApplicationHandler(
+		applicationService,
+	)
+
+	authService := services.NewAuthService(
+		userService, refreshTokenRepo, invitationRepo,
+	)
 	authHandler := handlers.NewAuthHandler(authService)
 
+	exportService := services.NewExportService(
+		exportRepo, exportFileRepo, userRepo, avatarRepo, refreshTokenRepo,
+		courseRepo, enrollmentRepo, progressRepo, certificateRepo, reviewRepo,
+		reviewVoteRepo, applicationRepo, invitationRepo, membershipRepo,
+	)
+	exportHandler := handlers.NewExportHandler(exportService)
+
+	jobs.Schedule(
+		"purge deleted users", time.Hour, func(ctx context.Context) error {
+			purged, err := userService.PurgeDeletedUsers(ctx)
+			if purged > 0 {
+				log.Printf("purged %d deleted users", purged)
+			}
+			return err
+		},
+	)
+
+	jobs.Schedule(
+		"run scheduled account deletions", time.Hour,
+		func(ctx context.Context) error {
+			deleted, err := userService.RunScheduledDeletions(ctx)
+			if deleted > 0 {
+				log.Printf("deleted %d accounts after their grace period", deleted)
+			}
+			return err
+		},
+	)
+	jobs.Schedule("process data exports", time.Minute, exportService.ProcessPendingExports)
+	jobs.Schedule(
+		"delete expired data exports", time.Hour, func(ctx context.Context) error {
+			_, err := exportService.DeleteExpiredExports(ctx)
+			return err
+		},
+	)
+
 	port := cnfg.Port
 	if port == "" {
 		port = "8080"
 	}
 
-	router := routes.SetupRoutes(userHandler, courseHandler, authHandler)
+	router := routes.SetupRoutes(
+		userHandler, courseHandler, authHandler, exportHandler,
+		userImportHandler, invitationHandler, organizationHandler,
+		applicationHandler, curriculumHandler, materialHandler, enrollmentHandler,
+		progressHandler, certificateHandler, reviewHandler, prerequisiteHandler,
+		revisionHandler, cloneHandler,
+	)
 
 	fmt.Println("╔════════════════════════════════════════════════════╗")
 	fmt.Println("║       Go-MongoDB Course API Server                ║")
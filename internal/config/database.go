@@ -91,5 +91,10 @@ func ConnectDB(cfg *Config) (*mongo.Database, error) {
 		return nil, fmt.Errorf("failed to initialize indexes: %w", err)
 	}
 
+	err = repository.RunMigrations(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
 	return db, nil
 }
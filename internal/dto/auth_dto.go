@@ -6,6 +6,9 @@ type RegisterDto struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6,max=100"`
+	// InviteToken is required when registration is invite only, the new
+	// account gets the role the invitation was created with
+	InviteToken string `json:"inviteToken,omitempty"`
 }
 
 type TokenPair struct {
@@ -21,6 +24,9 @@ type LoginDto struct {
 type AuthResponse struct {
 	Token *TokenPair   `json:"tokens"`
 	User  UserResponse `json:"user"`
+	// DeletionCancelled reports that logging in cancelled a pending account
+	// deletion
+	DeletionCancelled bool `json:"deletionCancelled,omitempty"`
 }
 
 type UserResponse struct {
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type RevokeCertificateDto struct {
+	Reason string `json:"reason" validate:"required,min=1,max=500"`
+}
+
+// CertificateVerification is what anybody holding a verification code may
+// learn about the certificate
+type CertificateVerification struct {
+	Code             string     `json:"code"`
+	Valid            bool       `json:"valid"`
+	LearnerName      string     `json:"learner_name"`
+	CourseName       string     `json:"course_name"`
+	InstructorName   string     `json:"instructor_name"`
+	CompletedAt      time.Time  `json:"completed_at"`
+	IssuedAt         time.Time  `json:"issued_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+}
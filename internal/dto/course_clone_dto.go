@@ -0,0 +1,14 @@
+package dto
+
+// CloneCourseDto picks what a course copy takes over from the original.
+// Materials, curriculum and pricing are copied unless turned off
+type CloneCourseDto struct {
+	// CourseName defaults to the original's name marked as a copy
+	CourseName string `json:"course_name" validate:"omitempty,min=2,max=100"`
+	// OwnerId defaults to the caller, only admins hand the copy to someone
+	// else
+	OwnerId    string `json:"owner_id" validate:"omitempty,mongodb"`
+	Materials  *bool  `json:"materials"`
+	Curriculum *bool  `json:"curriculum"`
+	Pricing    *bool  `json:"pricing"`
+}
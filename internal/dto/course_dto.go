@@ -1,13 +1,85 @@
 package dto
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MoneyDto is a price in the minor units of its currency, e.g. 1250 with
+// USD for $12.50. An amount of 0 makes the course free
+type MoneyDto struct {
+	Amount   int64  `json:"amount" validate:"gte=0,lte=1000000000"`
+	Currency string `json:"currency" validate:"required,currency"`
+}
 
 type CreateCourseDto struct {
 	CourseName   string             `json:"course_name" validate:"required,min=2,max=100"`
-	Price        int                `json:"price" validate:"required,gt=0"`
+	Description  string             `json:"description" validate:"omitempty,max=5000"`
+	Tags         []string           `json:"tags" validate:"omitempty,max=10,dive,min=1,max=30"`
+	Category     string             `json:"category" validate:"omitempty,max=50,slug"`
+	Level        string             `json:"level" validate:"omitempty,oneof=beginner intermediate advanced all"`
+	Language     string             `json:"language" validate:"omitempty,oneof=da nl en fi fr de hu it nb pt ro ru es sv tr none"`
+	Price        MoneyDto           `json:"price"`
+	PriceList    []MoneyDto         `json:"price_list" validate:"omitempty,max=10,dive"`
 	InstructorId primitive.ObjectID `json:"instructor_id" validate:"required"`
 }
 type UpdateCourseDto struct {
-	CourseName *string `json:"course_name" validate:"omitempty,min=2,max=100"`
-	Price      *int    `json:"price" validate:"omitempty,gte=0"`
+	CourseName  *string     `json:"course_name" validate:"omitempty,min=2,max=100"`
+	Description *string     `json:"description" validate:"omitempty,max=5000"`
+	Tags        *[]string   `json:"tags" validate:"omitempty,max=10,dive,min=1,max=30"`
+	Category    *string     `json:"category" validate:"omitempty,max=50,slug"`
+	Level       *string     `json:"level" validate:"omitempty,oneof=beginner intermediate advanced all"`
+	Language    *string     `json:"language" validate:"omitempty,oneof=da nl en fi fr de hu it nb pt ro ru es sv tr none"`
+	Price       *MoneyDto   `json:"price"`
+	PriceList   *[]MoneyDto `json:"price_list" validate:"omitempty,max=10,dive"`
+}
+
+// CourseListQuery holds the catalog filters accepted by GET /courses, every
+// filter given must match
+type CourseListQuery struct {
+	// MinPrice and MaxPrice are in the minor units of Currency, which
+	// defaults to DEFAULT_CURRENCY
+	MinPrice     *int   `json:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice     *int   `json:"max_price" validate:"omitempty,gte=0"`
+	Currency     string `json:"currency" validate:"omitempty,currency"`
+	InstructorId string `json:"instructor_id" validate:"omitempty,mongodb"`
+	Category     string `json:"category" validate:"omitempty,max=50,slug"`
+	Level        string `json:"level" validate:"omitempty,oneof=beginner intermediate advanced all"`
+	Tag          string `json:"tag" validate:"omitempty,max=30"`
+	// Cursor continues from a next_cursor or prev_cursor instead of a page
+	Cursor string `json:"cursor" validate:"omitempty,max=1024"`
+}
+
+// FacetCount is how many listed courses share a value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeCount is how many listed courses cost between Min and Max, Max
+// is left out for the open-ended top range
+type PriceRangeCount struct {
+	// Currency is what Min and Max are in, in minor units
+	Currency string `json:"currency"`
+	Min      int    `json:"min"`
+	Max      *int   `json:"max,omitempty"`
+	Count    int64  `json:"count"`
+}
+
+// CourseFacets counts the courses matching the current filters by each
+// value they could be narrowed down further by
+type CourseFacets struct {
+	PriceRanges []PriceRangeCount `json:"price_ranges"`
+	Instructors []FacetCount      `json:"instructors"`
+	Categories  []FacetCount      `json:"categories"`
+	Levels      []FacetCount      `json:"levels"`
+	Tags        []FacetCount      `json:"tags"`
+}
+
+// CourseSearchResult is a course matching a search, with its relevance
+// score and the parts of it that matched
+type CourseSearchResult struct {
+	Course   models.Course `json:"course"`
+	Score    float64       `json:"score"`
+	Snippets []string      `json:"snippets,omitempty"`
 }
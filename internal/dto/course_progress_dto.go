@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+)
+
+// RecordProgressDto is what a learner did in a lesson since they last
+// reported on it
+type RecordProgressDto struct {
+	// Completed marks the lesson complete or not, leave it out to only
+	// record the position and time
+	Completed       *bool `json:"completed"`
+	PositionSeconds *int  `json:"position_seconds" validate:"omitempty,gte=0,lte=86400"`
+	// TimeSpentSeconds is added to the time spent in the course
+	TimeSpentSeconds int `json:"time_spent_seconds" validate:"gte=0,lte=3600"`
+}
+
+// ResumePoint is the lesson to continue a course with
+type ResumePoint struct {
+	CourseId          string    `json:"course_id"`
+	CourseName        string    `json:"course_name"`
+	SectionId         string    `json:"section_id"`
+	SectionTitle      string    `json:"section_title"`
+	LessonId          string    `json:"lesson_id"`
+	LessonTitle       string    `json:"lesson_title"`
+	PositionSeconds   int       `json:"position_seconds"`
+	CompletionPercent int       `json:"completion_percent"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+}
+
+type CourseProgressDetails struct {
+	Progress models.CourseProgress `json:"progress"`
+	// Next is where to continue, empty once every lesson is completed
+	Next *ResumePoint `json:"next,omitempty"`
+}
+
+// LearnerProgress is one row of an instructor's progress report
+type LearnerProgress struct {
+	UserId            string     `json:"user_id"`
+	EnrollmentStatus  string     `json:"enrollment_status"`
+	EnrolledAt        time.Time  `json:"enrolled_at"`
+	CompletedLessons  int        `json:"completed_lessons"`
+	LessonCount       int        `json:"lesson_count"`
+	CompletionPercent int        `json:"completion_percent"`
+	TimeSpentSeconds  int64      `json:"time_spent_seconds"`
+	LastActivityAt    *time.Time `json:"last_activity_at,omitempty"`
+}
+
+type ProgressReportSummary struct {
+	// Learners counts active and completed enrollments, Started those of
+	// any status with recorded progress
+	Learners  int64 `json:"learners"`
+	Completed int64 `json:"completed"`
+	Started   int64 `json:"started"`
+	// AverageCompletionPercent is over the learners that started, as of
+	// their last activity
+	AverageCompletionPercent float64 `json:"average_completion_percent"`
+	TotalTimeSpentSeconds    int64   `json:"total_time_spent_seconds"`
+}
+
+type CourseProgressReport struct {
+	Summary    ProgressReportSummary `json:"summary"`
+	Learners   []LearnerProgress     `json:"learners"`
+	Page       int                   `json:"page"`
+	TotalCount int64                 `json:"total_count"`
+	HasMore    bool                  `json:"has_more"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+// FieldChange is a course field that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RevisionDiff lists what changed from one revision to another, fields
+// that didn't change are left out
+type RevisionDiff struct {
+	From    int64         `json:"from"`
+	To      int64         `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+type CreateSectionDto struct {
+	Title string `json:"title" validate:"required,min=1,max=200"`
+	// Position places the section, counting from 1. It goes last when empty
+	Position *int `json:"position" validate:"omitempty,min=1"`
+}
+
+type UpdateSectionDto struct {
+	Title *string `json:"title" validate:"omitempty,min=1,max=200"`
+}
+
+type CreateLessonDto struct {
+	Title           string `json:"title" validate:"required,min=1,max=200"`
+	ContentType     string `json:"content_type" validate:"required,oneof=video article quiz assignment"`
+	DurationSeconds int    `json:"duration_seconds" validate:"gte=0,lte=86400"`
+	// Position places the lesson in its section, counting from 1. It goes
+	// last when empty
+	Position *int `json:"position" validate:"omitempty,min=1"`
+}
+
+type UpdateLessonDto struct {
+	Title           *string `json:"title" validate:"omitempty,min=1,max=200"`
+	ContentType     *string `json:"content_type" validate:"omitempty,oneof=video article quiz assignment"`
+	DurationSeconds *int    `json:"duration_seconds" validate:"omitempty,gte=0,lte=86400"`
+}
+
+// ReorderCurriculumDto is the complete new order of a curriculum. Every
+// section and lesson has to be listed exactly once, lessons may move to
+// another section
+type ReorderCurriculumDto struct {
+	Sections []SectionOrderDto `json:"sections" validate:"required,dive"`
+}
+
+type SectionOrderDto struct {
+	Id      string   `json:"id" validate:"required,mongodb"`
+	Lessons []string `json:"lessons" validate:"dive,mongodb"`
+}
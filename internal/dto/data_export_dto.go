@@ -0,0 +1,8 @@
+package dto
+
+import "github.com/AhmedHossam777/go-mongo/internal/models"
+
+type DataExportResponse struct {
+	models.DataExport
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
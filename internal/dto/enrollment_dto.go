@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/AhmedHossam777/go-mongo/internal/models"
+
+type UpdateEnrollmentDto struct {
+	Status string `json:"status" validate:"required,oneof=active completed cancelled"`
+}
+
+// EnrolledCourse is one of a learner's enrollments with the course it is for
+type EnrolledCourse struct {
+	Enrollment models.Enrollment `json:"enrollment"`
+	Course     models.Course     `json:"course"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+type CreateInstructorApplicationDto struct {
+	Bio string `json:"bio" validate:"required,min=50,max=2000"`
+	// SampleMaterial links to teaching samples such as videos or slides
+	SampleMaterial []string `json:"sampleMaterial" validate:"required,min=1,max=5,dive,url"`
+}
+
+type RejectInstructorApplicationDto struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500"`
+}
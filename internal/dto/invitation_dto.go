@@ -0,0 +1,6 @@
+package dto
+
+type CreateInvitationDto struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=user instructor admin"`
+}
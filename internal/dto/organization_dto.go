@@ -0,0 +1,15 @@
+package dto
+
+type CreateOrganizationDto struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+	Slug string `json:"slug" validate:"required,min=2,max=50,slug"`
+}
+
+type AddMemberDto struct {
+	UserId string `json:"userId" validate:"required,len=24,hexadecimal"`
+	Role   string `json:"role" validate:"required,oneof=user instructor admin"`
+}
+
+type UpdateMemberRoleDto struct {
+	Role string `json:"role" validate:"required,oneof=user instructor admin"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// PageCursors continue a list from the page they were returned with, each is
+// empty when the list doesn't go on in that direction
+type PageCursors struct {
+	Next string `json:"next_cursor,omitempty"`
+	Prev string `json:"prev_cursor,omitempty"`
+}
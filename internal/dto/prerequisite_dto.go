@@ -0,0 +1,24 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type AddPrerequisiteDto struct {
+	CourseId string `json:"course_id" validate:"required,mongodb"`
+}
+
+// PrerequisiteNode is a course with everything it requires, directly or
+// through its own prerequisites
+type PrerequisiteNode struct {
+	CourseId   primitive.ObjectID `json:"course_id"`
+	CourseName string             `json:"course_name"`
+	Status     string             `json:"status"`
+	// Completed tells a signed in learner whether they completed the course
+	Completed     *bool              `json:"completed,omitempty"`
+	Prerequisites []PrerequisiteNode `json:"prerequisites"`
+}
+
+// MissingPrerequisite is a prerequisite the learner hasn't completed yet
+type MissingPrerequisite struct {
+	CourseId   primitive.ObjectID `json:"course_id"`
+	CourseName string             `json:"course_name"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type CreateReviewDto struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Text   string `json:"text" validate:"max=5000"`
+}
+
+type UpdateReviewDto struct {
+	Rating *int    `json:"rating" validate:"omitempty,min=1,max=5"`
+	Text   *string `json:"text" validate:"omitempty,max=5000"`
+}
+
+type ReviewReplyDto struct {
+	Text string `json:"text" validate:"required,min=1,max=5000"`
+}
@@ -1,14 +1,60 @@
 package dto
 
+import "time"
+
 type CreateUserDto struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6,max=100"`
-	Role     string `json:"role" validate:"omitempty"`
+	Role     string `json:"role" validate:"omitempty,oneof=user instructor admin"`
 }
 
 type UpdateUserDto struct {
 	Name     *string `json:"name" validate:"omitempty,min=2,max=100"`
-	Email    *string `json:"email" validate:"omitempty,email"`
 	Password *string `json:"password" validate:"omitempty,min=6,max=100"`
+	Bio      *string `json:"bio" validate:"omitempty,max=500"`
+	Headline *string `json:"headline" validate:"omitempty,max=120"`
+	Timezone *string `json:"timezone" validate:"omitempty,timezone"`
+	Language *string `json:"language" validate:"omitempty,bcp47_language_tag"`
+}
+
+type DeleteAccountDto struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// UserListQuery holds the filters and sort order accepted by GET /users
+type UserListQuery struct {
+	Role        string     `json:"role" validate:"omitempty,oneof=user instructor admin"`
+	Search      string     `json:"q" validate:"omitempty,max=100"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	Sort        string     `json:"sort" validate:"omitempty,oneof=created_at updated_at name email"`
+	Order       string     `json:"order" validate:"omitempty,oneof=asc desc"`
+	// Cursor continues from a next_cursor or prev_cursor instead of a page
+	Cursor string `json:"cursor" validate:"omitempty,max=1024"`
+	// AdminView lets the search match email addresses, other callers can
+	// only search by name so hidden emails can't be probed
+	AdminView bool `json:"-"`
+}
+
+type UpdatePrivacyDto struct {
+	ShowEmail    *bool `json:"showEmail"`
+	ShowAvatar   *bool `json:"showAvatar"`
+	ShowBio      *bool `json:"showBio"`
+	ShowHeadline *bool `json:"showHeadline"`
+	ShowJoinDate *bool `json:"showJoinDate"`
+}
+
+type RequestEmailChangeDto struct {
+	NewEmail string `json:"newEmail" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ConfirmEmailChangeDto struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type CompletePasswordSetupDto struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=100"`
 }
@@ -0,0 +1,41 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	ImportRowCreated = "created"
+	ImportRowSkipped = "skipped"
+	ImportRowFailed  = "failed"
+)
+
+// ImportUserRow is one CSV row, validated with the same rules as
+// CreateUserDto minus the password
+type ImportUserRow struct {
+	Name  string `json:"name" validate:"required,min=2,max=100"`
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"omitempty,oneof=user instructor admin"`
+}
+
+type UserImportOptions struct {
+	DryRun      bool
+	SendInvites bool
+}
+
+type UserImportRowResult struct {
+	// Row is the 1-based record number in the file, the header is record 1
+	Row    int                 `json:"row"`
+	Email  string              `json:"email,omitempty"`
+	Status string              `json:"status"`
+	Reason string              `json:"reason,omitempty"`
+	UserId *primitive.ObjectID `json:"userId,omitempty"`
+}
+
+// UserImportReport describes what happened to every row. In dry run mode
+// nothing is written and "created" means the row would have been created
+type UserImportReport struct {
+	DryRun  bool                  `json:"dryRun"`
+	Created int                   `json:"created"`
+	Skipped int                   `json:"skipped"`
+	Failed  int                   `json:"failed"`
+	Rows    []UserImportRowResult `json:"rows"`
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -24,16 +25,17 @@ func NewAuthHandler(
 }
 
 // @Summary Register a new user
-// @Description Register a new user with name, email and password
+// @Description Register a new user with name, email and password. An invitation token gives the account the invited role and is required when registration is invite only
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body dto.RegisterDto true "User registration details"
 // @Success 201 {object} dto.AuthResponse "User registered successfully"
-// @Failure 400 {object} map[string]string "Bad request - validation error or user already exists"
+// @Failure 400 {object} map[string]string "Bad request - validation error, user already exists or invalid invitation"
+// @Failure 403 {object} map[string]string "Forbidden - registration requires an invitation"
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var registerDto dto.RegisterDto
@@ -57,6 +59,10 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	authResponse, err := h.authService.Register(ctx, registerDto, r)
 	if err != nil {
+		if errors.Is(err, services.ErrInvitationRequired) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if mongo.IsDuplicateKeyError(err) {
 			RespondWithError(
 				w, http.StatusBadRequest,
@@ -84,7 +90,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} map[string]string "Bad request - invalid credentials"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var loginDto dto.LoginDto
@@ -125,7 +131,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 // @Failure 401 {object} map[string]string "Unauthorized - invalid refresh token"
 // @Router /auth/refresh-tokens [post]
 func (h *AuthHandler) RefreshTokens(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var refreshTokenInput *dto.RefreshTokenInput
@@ -168,7 +174,7 @@ func (h *AuthHandler) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} map[string]string "Bad request"
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var refreshTokenInput *dto.RefreshTokenInput
@@ -213,7 +219,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) GetActiveSessions(
 	w http.ResponseWriter, r *http.Request,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	userId, ok := r.Context().Value("userId").(string)
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type CertificateHandler struct {
+	service services.CertificateService
+}
+
+func NewCertificateHandler(
+	service services.CertificateService,
+) *CertificateHandler {
+	return &CertificateHandler{service: service}
+}
+
+// respondWithCertificateError maps certificate service errors to a status
+func respondWithCertificateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidUserID):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrCourseNotCompleted):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCertificateNotFound),
+		errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrNotEnrolled):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCertificateAlreadyRevoked):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrCertificateRevoked):
+		RespondWithError(w, http.StatusGone, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Get my certificate for a course
+// @Description Get the signed in learner's certificate for a completed course, issuing it on first request
+// @Tags certificates
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Certificate "Certificate"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Course not completed yet"
+// @Failure 404 {object} map[string]string "Course not found or not enrolled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/certificate [get]
+func (h *CertificateHandler) GetCertificate(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	certificate, err := h.service.GetCertificate(ctx, r.PathValue("id"), userId)
+	if err != nil {
+		respondWithCertificateError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, certificate)
+}
+
+// @Summary Download my certificate as a PDF
+// @Description Download the signed in learner's certificate for a completed course. It shows the learner, course, instructor, completion date and the code to verify it with
+// @Tags certificates
+// @Security BearerAuth
+// @Produce application/pdf
+// @Param id path string true "Course ID"
+// @Success 200 {file} file "Certificate PDF"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Course not completed yet"
+// @Failure 404 {object} map[string]string "Course not found or not enrolled"
+// @Failure 410 {object} map[string]string "Certificate revoked"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/certificate/pdf [get]
+func (h *CertificateHandler) DownloadCertificate(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	certificate, pdf, err := h.service.RenderCertificate(
+		ctx, r.PathValue("id"), userId,
+	)
+	if err != nil {
+		respondWithCertificateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(
+		"attachment",
+		map[string]string{"filename": "certificate-" + certificate.Code + ".pdf"},
+	))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
+
+// @Summary List my certificates
+// @Description List the signed in learner's certificates, most recently issued first
+// @Tags certificates
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} github_com_AhmedHossam777_go-mongo_internal_models.Certificate "Certificates"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/certificates [get]
+func (h *CertificateHandler) GetMyCertificates(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	certificates, err := h.service.GetUserCertificates(ctx, userId)
+	if err != nil {
+		respondWithCertificateError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, certificates)
+}
+
+// @Summary Verify a certificate
+// @Description Check that a certificate code belongs to a genuine certificate and see what it certifies. Revoked certificates are reported as not valid
+// @Tags certificates
+// @Produce json
+// @Param code path string true "Verification code"
+// @Success 200 {object} dto.CertificateVerification "Certificate details"
+// @Failure 404 {object} map[string]string "No certificate has this code"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /certificates/{code} [get]
+func (h *CertificateHandler) VerifyCertificate(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	verification, err := h.service.VerifyCertificate(ctx, r.PathValue("code"))
+	if err != nil {
+		respondWithCertificateError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, verification)
+}
+
+// @Summary Revoke a certificate
+// @Description Revoke a certificate, for instance one obtained by cheating. Verifying it afterwards reports it as not valid (Admin only)
+// @Tags certificates
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "Verification code"
+// @Param revocation body dto.RevokeCertificateDto true "Why the certificate is revoked"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Certificate "Revoked certificate"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - admin only"
+// @Failure 404 {object} map[string]string "Certificate not found"
+// @Failure 409 {object} map[string]string "Certificate already revoked"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /certificates/{code}/revoke [post]
+func (h *CertificateHandler) RevokeCertificate(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	adminId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var revokeDto dto.RevokeCertificateDto
+	if !decodeAndValidate(w, r, &revokeDto) {
+		return
+	}
+
+	certificate, err := h.service.RevokeCertificate(
+		ctx, r.PathValue("code"), adminId, revokeDto.Reason,
+	)
+	if err != nil {
+		respondWithCertificateError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, certificate)
+}
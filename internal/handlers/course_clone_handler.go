@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type CourseCloneHandler struct {
+	service services.CourseCloneService
+}
+
+func NewCourseCloneHandler(service services.CourseCloneService) *CourseCloneHandler {
+	return &CourseCloneHandler{service: service}
+}
+
+// @Summary Clone a course
+// @Description Copy a course into a new draft, with its curriculum, materials and price unless turned off. Without a course_name the copy is named after the original. Only admins choose another owner (Course instructor or Admin only)
+// @Tags courses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param clone body dto.CloneCourseDto false "What to copy"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course copy"
+// @Failure 400 {object} map[string]string "Bad request - validation error or invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor, or not allowed to choose the owner"
+// @Failure 404 {object} map[string]string "Course or owner not found"
+// @Failure 409 {object} map[string]string "Course name already taken"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/clone [post]
+func (h *CourseCloneHandler) CloneCourse(w http.ResponseWriter, r *http.Request) {
+	// Copying materials streams every file, which gets as long as uploading
+	// them
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	var cloneDto dto.CloneCourseDto
+	if r.ContentLength != 0 && !decodeAndValidate(w, r, &cloneDto) {
+		return
+	}
+
+	course, err := h.service.CloneCourse(
+		ctx, r.PathValue("id"), userId, userRole, &cloneDto,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCourseID),
+			errors.Is(err, services.ErrInvalidUserID),
+			errors.Is(err, services.ErrOwnerNotInstructor):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrNotCourseOwner),
+			errors.Is(err, services.ErrForbidden):
+			RespondWithError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, services.ErrCourseNotFound),
+			errors.Is(err, services.ErrUserNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrCourseNameTaken):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, course)
+}
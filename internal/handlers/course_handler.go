@@ -6,10 +6,12 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/dto"
 	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
 	"github.com/AhmedHossam777/go-mongo/internal/services"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -26,7 +28,7 @@ func NewCourseHandler(service services.CourseService) *CourseHandler {
 }
 
 // @Summary Create a new course
-// @Description Create a new course (requires authentication)
+// @Description Create a new course owned by the caller (Instructor or admin only)
 // @Tags courses
 // @Security BearerAuth
 // @Accept json
@@ -35,10 +37,11 @@ func NewCourseHandler(service services.CourseService) *CourseHandler {
 // @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course created successfully"
 // @Failure 400 {object} map[string]string "Bad request - validation error or duplicate course"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - instructor or admin role required"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /courses [post]
 func (h *CourseHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	userId, ok := r.Context().Value("userId").(string)
@@ -80,6 +83,10 @@ func (h *CourseHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 	createdCourse, err := h.service.CreateCourse(ctx, &courseDto)
 
 	if err != nil {
+		if errors.Is(err, services.ErrDuplicatePriceCurrency) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if mongo.IsDuplicateKeyError(err) {
 			RespondWithError(
 				w, http.StatusBadRequest,
@@ -95,16 +102,26 @@ func (h *CourseHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get all courses
-// @Description Get a paginated list of all courses
+// @Description Get a paginated list of published courses, newest first. Instructors also see their own drafts and archived courses, admins see every course. The response's facets count the matching courses per price range, instructor, category, level and tag
 // @Tags courses
+// @Security BearerAuth
 // @Produce json
-// @Param page query int false "Page number (default: 1)"
+// @Param page query int false "Page number (default: 1), ignored with a cursor"
 // @Param page_size query int false "Page size (default: 10, max: 100)"
-// @Success 200 {object} map[string]interface{} "Paginated list of courses"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page"
+// @Param min_price query int false "Lowest price in minor units of currency, inclusive"
+// @Param max_price query int false "Highest price in minor units of currency, inclusive"
+// @Param currency query string false "Currency of the price filters and price facet (default: DEFAULT_CURRENCY)" Enums(EGP, EUR, USD)
+// @Param instructor_id query string false "Only courses by this instructor"
+// @Param category query string false "Only courses in this category"
+// @Param level query string false "Only courses at this level" Enums(beginner, intermediate, advanced, all)
+// @Param tag query string false "Only courses with this tag"
+// @Success 200 {object} handlers.PaginatedResponse "Paginated list of courses with dto.CourseFacets, the Link header points to the next, previous and first pages"
+// @Failure 400 {object} map[string]string "Bad request - invalid filters or cursor"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /courses [get]
 func (h *CourseHandler) GetAllCourses(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
@@ -117,11 +134,34 @@ func (h *CourseHandler) GetAllCourses(w http.ResponseWriter, r *http.Request) {
 		pageSize = 10 // Default to 10
 	}
 
-	courses, totalCount, err := h.service.GetAllCourses(
-		ctx, int64(page),
+	listQuery, err := parseCourseListQuery(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(listQuery)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	courses, totalCount, facets, cursors, err := h.service.GetAllCourses(
+		ctx, viewerId, viewerRole, listQuery, int64(page),
 		int64(pageSize),
 	)
 
+	if errors.Is(err, services.ErrInvalidUserID) {
+		RespondWithError(w, http.StatusBadRequest, "Invalid instructor_id")
+		return
+	}
+	if errors.Is(err, helpers.ErrInvalidCursor) {
+		RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		RespondWithError(
 			w, http.StatusInternalServerError,
@@ -130,23 +170,130 @@ func (h *CourseHandler) GetAllCourses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hasMore := false
-	count := pageSize
-	if totalCount > (page * pageSize) {
-		hasMore = true
-	} else {
-		count = pageSize - (page*pageSize - totalCount)
+	// A page number means nothing once paging by cursor
+	if listQuery.Cursor != "" {
+		page = 0
+	}
+
+	CursorPaginationResponse(w, r, http.StatusOK, PaginatedResponse{
+		Data:       courses,
+		Page:       page,
+		Count:      len(courses),
+		TotalCount: int64(totalCount),
+		NextCursor: cursors.Next,
+		PrevCursor: cursors.Prev,
+		HasMore:    cursors.Next != "",
+		Facets:     facets,
+	})
+}
+
+// parseCourseListQuery reads the catalog filters, a price that isn't a
+// whole number is rejected rather than ignored
+func parseCourseListQuery(r *http.Request) (dto.CourseListQuery, error) {
+	query := r.URL.Query()
+	listQuery := dto.CourseListQuery{
+		InstructorId: query.Get("instructor_id"),
+		Category:     query.Get("category"),
+		Level:        query.Get("level"),
+		Tag:          strings.TrimSpace(query.Get("tag")),
+		Currency:     strings.ToUpper(query.Get("currency")),
+		Cursor:       query.Get("cursor"),
+	}
+
+	var err error
+	listQuery.MinPrice, err = parseIntParam(query.Get("min_price"))
+	if err != nil {
+		return listQuery, errors.New("invalid min_price, expected a whole number")
+	}
+
+	listQuery.MaxPrice, err = parseIntParam(query.Get("max_price"))
+	if err != nil {
+		return listQuery, errors.New("invalid max_price, expected a whole number")
+	}
+
+	if listQuery.MinPrice != nil && listQuery.MaxPrice != nil &&
+		*listQuery.MaxPrice < *listQuery.MinPrice {
+		return listQuery, errors.New("max_price must not be below min_price")
+	}
+
+	return listQuery, nil
+}
+
+func parseIntParam(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+// @Summary Search courses
+// @Description Full-text search over published courses, best matches first. Name matches rank above tag matches, which rank above description matches, and words are matched by their stem
+// @Tags courses
+// @Produce json
+// @Param q query string true "Search text, quote phrases and prefix words with - to exclude them"
+// @Param lang query string false "Language used to stem the search text (da, nl, en, fi, fr, de, hu, it, nb, pt, ro, ru, es, sv, tr or none)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Paginated list of dto.CourseSearchResult"
+// @Failure 400 {object} map[string]string "Bad request - missing search text or invalid language"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/search [get]
+func (h *CourseHandler) SearchCourses(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	page, pageSize := pageParams(r)
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		RespondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len(query) > 200 {
+		RespondWithError(w, http.StatusBadRequest, "q must be at most 200 characters")
+		return
+	}
+
+	language := r.URL.Query().Get("lang")
+	if language != "" && !searchLanguages[language] {
+		RespondWithError(w, http.StatusBadRequest, "unsupported lang")
+		return
+	}
+
+	results, totalCount, err := h.service.SearchCourses(
+		ctx, query, language, int64(page), int64(pageSize),
+	)
+	if err != nil {
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"Error searching courses",
+		)
+		return
 	}
 
 	PaginationResponse(
-		w, http.StatusOK, courses, page, count, int64(totalCount),
-		hasMore,
+		w, http.StatusOK, results, page, len(results), totalCount,
+		int(totalCount) > page*pageSize,
 	)
 }
 
+// searchLanguages are the languages MongoDB text search can stem
+var searchLanguages = map[string]bool{
+	"da": true, "nl": true, "en": true, "fi": true, "fr": true, "de": true,
+	"hu": true, "it": true, "nb": true, "pt": true, "ro": true, "ru": true,
+	"es": true, "sv": true, "tr": true, "none": true,
+}
+
 // @Summary Get course by ID
-// @Description Get a specific course by its ID
+// @Description Get a specific course by its ID. Unpublished courses are only visible to their instructor and admins
 // @Tags courses
+// @Security BearerAuth
 // @Produce json
 // @Param id path string true "Course ID"
 // @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course details"
@@ -155,12 +302,15 @@ func (h *CourseHandler) GetAllCourses(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /courses/{id} [get]
 func (h *CourseHandler) GetOneCourse(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	courseId := r.PathValue("id")
 
-	course, err := h.service.GetCourseByID(ctx, courseId)
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	course, err := h.service.GetCourseByID(ctx, courseId, viewerId, viewerRole)
 
 	if err != nil {
 		if errors.Is(err, services.ErrInvalidCourseID) {
@@ -185,7 +335,7 @@ func (h *CourseHandler) GetOneCourse(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Update course
-// @Description Update course details by ID (requires authentication)
+// @Description Update course details by ID (Course instructor or admin)
 // @Tags courses
 // @Security BearerAuth
 // @Accept json
@@ -195,12 +345,22 @@ func (h *CourseHandler) GetOneCourse(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course updated successfully"
 // @Failure 400 {object} map[string]string "Bad request - validation error"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - not the course instructor"
+// @Failure 404 {object} map[string]string "Course not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /courses/{id} [patch]
 func (h *CourseHandler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	userRole, _ := r.Context().Value("userRole").(string)
+
 	courseId := r.PathValue("id")
 
 	var updatedCourseDto dto.UpdateCourseDto
@@ -221,14 +381,143 @@ func (h *CourseHandler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedCourse, err := h.service.UpdateCourse(ctx, courseId, &updatedCourseDto)
+	updatedCourse, err := h.service.UpdateCourse(
+		ctx, courseId, userId, userRole, &updatedCourseDto,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCourseID) {
+			RespondWithError(w, http.StatusBadRequest, "Invalid course ID")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidUserID) {
+			RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		if errors.Is(err, services.ErrDuplicatePriceCurrency) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrNotCourseOwner) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrCourseNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Course not found")
+			return
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			RespondWithError(w, http.StatusBadRequest, "duplicated course name")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Error updating course")
+		return
+	}
 
 	RespondWithJSON(w, http.StatusOK, updatedCourse)
 
 }
 
+// @Summary Publish course
+// @Description Publish a draft course so it is publicly listed. All required fields must be filled in (Course instructor or admin)
+// @Tags courses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course published"
+// @Failure 400 {object} map[string]string "Bad request - invalid course ID or missing required fields"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - not the course instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Course can't be published from its current status"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/publish [post]
+func (h *CourseHandler) PublishCourse(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, models.CourseStatusPublished)
+}
+
+// @Summary Unpublish course
+// @Description Move a published course back to draft, hiding it from public listings (Course instructor or admin)
+// @Tags courses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course unpublished"
+// @Failure 400 {object} map[string]string "Bad request - invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - not the course instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Course isn't published"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/unpublish [post]
+func (h *CourseHandler) UnpublishCourse(
+	w http.ResponseWriter, r *http.Request,
+) {
+	h.changeStatus(w, r, models.CourseStatusDraft)
+}
+
+// @Summary Archive course
+// @Description Archive a published course. Archived courses are no longer listed and can't be published again (Course instructor or admin)
+// @Tags courses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Course archived"
+// @Failure 400 {object} map[string]string "Bad request - invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - not the course instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Course isn't published"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/archive [post]
+func (h *CourseHandler) ArchiveCourse(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, models.CourseStatusArchived)
+}
+
+func (h *CourseHandler) changeStatus(
+	w http.ResponseWriter, r *http.Request, status string,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	course, err := h.service.ChangeStatus(
+		ctx, r.PathValue("id"), status, userId, userRole,
+	)
+	if err != nil {
+		var incompleteErr *services.CourseIncompleteError
+		switch {
+		case errors.As(err, &incompleteErr):
+			RespondWithValidationErrors(w, incompleteErr.Fields)
+		case errors.Is(err, services.ErrInvalidCourseID):
+			RespondWithError(w, http.StatusBadRequest, "Invalid course ID")
+		case errors.Is(err, services.ErrCourseNotFound):
+			RespondWithError(w, http.StatusNotFound, "Course not found")
+		case errors.Is(err, services.ErrForbidden):
+			RespondWithError(
+				w, http.StatusForbidden,
+				"only the course instructor can change its status",
+			)
+		case errors.Is(err, services.ErrInvalidCourseTransition):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError, "Error changing course status",
+			)
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, course)
+}
+
 // @Summary Delete course
-// @Description Delete a course by ID (requires authentication)
+// @Description Delete a course by ID with its curriculum, materials, enrollments and reviews (Course instructor or admin)
 // @Tags courses
 // @Security BearerAuth
 // @Produce json
@@ -236,24 +525,36 @@ func (h *CourseHandler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
 // @Success 200 "Course deleted successfully"
 // @Failure 400 {object} map[string]string "Bad request - invalid course ID"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - not the course instructor"
 // @Failure 404 {object} map[string]string "Course not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /courses/{id} [delete]
 func (h *CourseHandler) DeleteOneCourse(
 	w http.ResponseWriter, r *http.Request,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
 	courseId := r.PathValue("id")
 
-	err := h.service.DeleteCourse(ctx, courseId)
+	err := h.service.DeleteCourse(ctx, courseId, userId, userRole)
 
 	if err != nil {
 		if errors.Is(err, services.ErrInvalidCourseID) {
 			RespondWithError(w, http.StatusBadRequest, "Invalid course ID")
 			return
 		}
+		if errors.Is(err, services.ErrNotCourseOwner) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if errors.Is(err, services.ErrCourseNotFound) {
 			RespondWithError(w, http.StatusNotFound, "Course not found")
 			return
@@ -278,7 +579,7 @@ func (h *CourseHandler) DeleteOneCourse(
 func (h *CourseHandler) Drop(
 	w http.ResponseWriter, r *http.Request,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	err := h.service.Drop(ctx)
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type CourseMaterialHandler struct {
+	service services.CourseMaterialService
+}
+
+func NewCourseMaterialHandler(
+	service services.CourseMaterialService,
+) *CourseMaterialHandler {
+	return &CourseMaterialHandler{service: service}
+}
+
+// respondWithMaterialError maps course material service errors to a status
+func respondWithMaterialError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		RespondWithError(
+			w, http.StatusRequestEntityTooLarge,
+			services.ErrMaterialTooLarge.Error(),
+		)
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidMaterialID),
+		errors.Is(err, services.ErrEmptyMaterial):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotCourseOwner),
+		errors.Is(err, services.ErrNotEnrolled):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrMaterialNotFound):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrMaterialTooLarge),
+		errors.Is(err, services.ErrMaterialQuotaExceeded):
+		RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, services.ErrInvalidMaterialType):
+		RespondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary List a course's materials
+// @Description List the files attached to a course, oldest first. Unpublished courses' materials are only visible to their instructor and admins
+// @Tags materials
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {array} github_com_AhmedHossam777_go-mongo_internal_models.CourseMaterial "Materials"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/materials [get]
+func (h *CourseMaterialHandler) ListMaterials(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	materials, err := h.service.ListMaterials(
+		ctx, r.PathValue("id"), viewerId, viewerRole,
+	)
+	if err != nil {
+		respondWithMaterialError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, materials)
+}
+
+// @Summary Upload a course material
+// @Description Attach a file to a course (Course instructor only). The file is streamed as the "file" field of a multipart form; its type is detected from its content. Accepted are PDF, office documents, plain text, images, MP4/WebM video and MP3/WAV audio, up to MATERIAL_MAX_SIZE_MB each and COURSE_MATERIAL_QUOTA_MB per course
+// @Tags materials
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param file formData file true "Material file"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.CourseMaterial "Material uploaded"
+// @Failure 400 {object} map[string]string "Bad request - missing or empty file"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 413 {object} map[string]string "File too large or course quota exceeded"
+// @Failure 415 {object} map[string]string "Unsupported file type"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/materials [post]
+func (h *CourseMaterialHandler) UploadMaterial(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxMaterialSize()+1<<20)
+	defer r.Body.Close()
+
+	part, err := materialPart(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithMaterialError(w, err)
+			return
+		}
+		RespondWithError(
+			w, http.StatusBadRequest, "material file is required, "+err.Error(),
+		)
+		return
+	}
+
+	material, err := h.service.UploadMaterial(
+		ctx, r.PathValue("id"), userId, part.FileName(), part,
+	)
+	if err != nil {
+		respondWithMaterialError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, material)
+}
+
+// @Summary Download a course material
+// @Description Download a course material. Supports Range requests to resume downloads and seek through media, and If-None-Match revalidation. Only learners with an active or completed enrollment, the course's instructor and admins can download
+// @Tags materials
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param id path string true "Course ID"
+// @Param materialId path string true "Material ID"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag from an earlier download"
+// @Success 200 {file} file "Material content"
+// @Success 206 {file} file "Requested range"
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 403 {object} map[string]string "Not enrolled in the course"
+// @Failure 404 {object} map[string]string "Course or material not found"
+// @Failure 416 {string} string "Range not satisfiable"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/materials/{materialId} [get]
+func (h *CourseMaterialHandler) DownloadMaterial(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	material, content, err := h.service.OpenMaterial(
+		ctx, r.PathValue("id"), r.PathValue("materialId"), viewerId, viewerRole,
+	)
+	if err != nil {
+		respondWithMaterialError(w, err)
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if inlineMaterial(material.ContentType) {
+		disposition = "inline"
+	}
+
+	// Stored files never change, so their id is a strong validator
+	w.Header().Set("ETag", `"`+material.FileId.Hex()+`"`)
+	w.Header().Set("Content-Type", material.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(
+		disposition, map[string]string{"filename": material.Filename},
+	))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, material.Filename, material.CreatedAt, content)
+}
+
+// @Summary Delete a course material
+// @Description Remove a file from a course and free its storage (Course instructor only)
+// @Tags materials
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param materialId path string true "Material ID"
+// @Success 200 {object} map[string]string "Material deleted"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or material not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/materials/{materialId} [delete]
+func (h *CourseMaterialHandler) DeleteMaterial(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	err := h.service.DeleteMaterial(
+		ctx, r.PathValue("id"), r.PathValue("materialId"), userId,
+	)
+	if err != nil {
+		respondWithMaterialError(w, err)
+		return
+	}
+
+	RespondWithJSON(
+		w, http.StatusOK, map[string]string{"message": "material deleted"},
+	)
+}
+
+// materialPart finds the "file" part of a multipart upload, leaving its
+// content unread so it can be streamed into storage
+func materialPart(r *http.Request) (*multipart.Part, error) {
+	multipartReader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := multipartReader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("multipart form has no \"file\" field")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// inlineMaterial tells whether browsers should show a material in place
+// rather than save it
+func inlineMaterial(contentType string) bool {
+	return contentType == "application/pdf" ||
+		strings.HasPrefix(contentType, "image/") ||
+		strings.HasPrefix(contentType, "video/") ||
+		strings.HasPrefix(contentType, "audio/")
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type CourseProgressHandler struct {
+	service services.CourseProgressService
+}
+
+func NewCourseProgressHandler(
+	service services.CourseProgressService,
+) *CourseProgressHandler {
+	return &CourseProgressHandler{service: service}
+}
+
+// respondWithProgressError maps course progress service errors to a status
+func respondWithProgressError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidLessonID),
+		errors.Is(err, services.ErrInvalidUserID),
+		errors.Is(err, services.ErrInvalidEnrollmentStatus):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotCourseOwner):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrLessonNotFound),
+		errors.Is(err, services.ErrNotEnrolled):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Record lesson progress
+// @Description Record what the signed in learner did in a lesson: where they stopped, how long they spent and whether they completed it. Completing the course's last lesson completes the enrollment
+// @Tags progress
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param lessonId path string true "Lesson ID"
+// @Param progress body dto.RecordProgressDto true "Lesson activity"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.CourseProgress "Updated progress"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Course or lesson not found, or not enrolled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/lessons/{lessonId}/progress [post]
+func (h *CourseProgressHandler) RecordProgress(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var progressDto dto.RecordProgressDto
+	if !decodeAndValidate(w, r, &progressDto) {
+		return
+	}
+
+	progress, err := h.service.RecordProgress(
+		ctx, r.PathValue("id"), r.PathValue("lessonId"), userId, &progressDto,
+	)
+	if err != nil {
+		respondWithProgressError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, progress)
+}
+
+// @Summary Get my progress in a course
+// @Description Get the signed in learner's completed lessons, completion percentage, time spent and the lesson to continue with
+// @Tags progress
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} dto.CourseProgressDetails "Progress"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Course not found or not enrolled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/progress [get]
+func (h *CourseProgressHandler) GetProgress(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	progress, err := h.service.GetProgress(ctx, r.PathValue("id"), userId)
+	if err != nil {
+		respondWithProgressError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, progress)
+}
+
+// @Summary Continue where you left off
+// @Description List the lesson to pick up in each of the signed in learner's unfinished courses, most recently active first
+// @Tags progress
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Number of courses, at most 20" default(5)
+// @Success 200 {array} dto.ResumePoint "Lessons to continue with"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/continue [get]
+func (h *CourseProgressHandler) ContinueLearning(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	resumePoints, err := h.service.ContinueLearning(ctx, userId, limit)
+	if err != nil {
+		respondWithProgressError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, resumePoints)
+}
+
+// @Summary Get a course's progress report
+// @Description Get every learner's progress through a course, most recently enrolled first, with totals for the course (Course instructor or Admin only)
+// @Tags progress
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param status query string false "Only learners whose enrollment is in this status" Enums(active, completed, cancelled)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(10)
+// @Success 200 {object} dto.CourseProgressReport "Progress report"
+// @Failure 400 {object} map[string]string "Invalid course ID or status"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/progress/report [get]
+func (h *CourseProgressHandler) GetCourseReport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	page, pageSize := pageParams(r)
+
+	report, err := h.service.GetCourseReport(
+		ctx, r.PathValue("id"), userId, userRole, r.URL.Query().Get("status"),
+		int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithProgressError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, report)
+}
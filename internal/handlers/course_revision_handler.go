@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type CourseRevisionHandler struct {
+	service services.CourseRevisionService
+}
+
+func NewCourseRevisionHandler(
+	service services.CourseRevisionService,
+) *CourseRevisionHandler {
+	return &CourseRevisionHandler{service: service}
+}
+
+// respondWithRevisionError maps revision service errors to a status
+func respondWithRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidUserID),
+		errors.Is(err, services.ErrInvalidRevision):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotCourseOwner):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrRevisionNotFound):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCourseNameTaken),
+		errors.Is(err, services.ErrRevisionConflict):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// revisionParam reads a revision number, anything that isn't a number comes
+// back as 0 which the service rejects
+func revisionParam(value string) int64 {
+	revision, _ := strconv.ParseInt(value, 10, 64)
+	return revision
+}
+
+// @Summary List a course's revisions
+// @Description List the snapshots stored each time the course was changed, newest first (Course instructor or Admin only)
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} map[string]interface{} "Paginated list of revisions"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/revisions [get]
+func (h *CourseRevisionHandler) GetRevisions(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	page, pageSize := pageParams(r)
+
+	revisions, totalCount, err := h.service.GetRevisions(
+		ctx, r.PathValue("id"), userId, userRole, int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, revisions, page, len(revisions), totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
+
+// @Summary Get a course revision
+// @Description Get the course as a revision stored it (Course instructor or Admin only)
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.CourseRevision "Revision"
+// @Failure 400 {object} map[string]string "Invalid course ID or revision"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or revision not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/revisions/{rev} [get]
+func (h *CourseRevisionHandler) GetRevision(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	revision, err := h.service.GetRevision(
+		ctx, r.PathValue("id"), revisionParam(r.PathValue("rev")), userId,
+		userRole,
+	)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, revision)
+}
+
+// @Summary Compare two course revisions
+// @Description List the fields that differ between two revisions of a course (Course instructor or Admin only)
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param from query int true "Revision to compare from"
+// @Param to query int true "Revision to compare to"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_dto.RevisionDiff "Changed fields"
+// @Failure 400 {object} map[string]string "Invalid course ID or revision"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or revision not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/revisions/diff [get]
+func (h *CourseRevisionHandler) DiffRevisions(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	diff, err := h.service.DiffRevisions(
+		ctx, r.PathValue("id"), revisionParam(r.URL.Query().Get("from")),
+		revisionParam(r.URL.Query().Get("to")), userId, userRole,
+	)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, diff)
+}
+
+// @Summary Restore a course revision
+// @Description Put the course's details back the way a revision stored them. The restore is recorded as a new revision (Course instructor or Admin only)
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Restored course"
+// @Failure 400 {object} map[string]string "Invalid course ID or revision"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or revision not found"
+// @Failure 409 {object} map[string]string "Another course took the revision's name"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/revisions/{rev}/restore [post]
+func (h *CourseRevisionHandler) RestoreRevision(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	course, err := h.service.RestoreRevision(
+		ctx, r.PathValue("id"), revisionParam(r.PathValue("rev")), userId,
+		userRole,
+	)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, course)
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type CurriculumHandler struct {
+	service services.CurriculumService
+}
+
+func NewCurriculumHandler(service services.CurriculumService) *CurriculumHandler {
+	return &CurriculumHandler{service: service}
+}
+
+// respondWithCurriculumError maps curriculum service errors to a status
+func respondWithCurriculumError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidSectionID),
+		errors.Is(err, services.ErrInvalidLessonID),
+		errors.Is(err, services.ErrInvalidCurriculumOrder):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotCourseOwner):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrSectionNotFound),
+		errors.Is(err, services.ErrLessonNotFound):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCurriculumConflict):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrCurriculumTooLarge):
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Get a course's curriculum
+// @Description Get the course's sections with their lessons, both in order, and the lesson count and total duration. Unpublished courses' curricula are only visible to their instructor and admins
+// @Tags curriculum
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Curriculum "Curriculum"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/curriculum [get]
+func (h *CurriculumHandler) GetCurriculum(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	curriculum, err := h.service.GetCurriculum(
+		ctx, r.PathValue("id"), viewerId, viewerRole,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, curriculum)
+}
+
+// @Summary Add a section
+// @Description Add a section to the course's curriculum, last unless a position is given (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param section body dto.CreateSectionDto true "Section details"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Section "Section added"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 422 {object} map[string]string "Curriculum has too many sections"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/sections [post]
+func (h *CurriculumHandler) AddSection(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var sectionDto dto.CreateSectionDto
+	if !decodeAndValidate(w, r, &sectionDto) {
+		return
+	}
+
+	section, err := h.service.AddSection(
+		ctx, r.PathValue("id"), userId, &sectionDto,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, section)
+}
+
+// @Summary Update a section
+// @Description Rename a section (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param sectionId path string true "Section ID"
+// @Param section body dto.UpdateSectionDto true "Fields to update"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Section "Section updated"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or section not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/sections/{sectionId} [patch]
+func (h *CurriculumHandler) UpdateSection(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var sectionDto dto.UpdateSectionDto
+	if !decodeAndValidate(w, r, &sectionDto) {
+		return
+	}
+
+	section, err := h.service.UpdateSection(
+		ctx, r.PathValue("id"), r.PathValue("sectionId"), userId, &sectionDto,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, section)
+}
+
+// @Summary Delete a section
+// @Description Delete a section together with its lessons (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param sectionId path string true "Section ID"
+// @Success 204 "Section deleted"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or section not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/sections/{sectionId} [delete]
+func (h *CurriculumHandler) DeleteSection(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	err := h.service.DeleteSection(
+		ctx, r.PathValue("id"), r.PathValue("sectionId"), userId,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add a lesson
+// @Description Add a lesson to a section, last unless a position is given (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param sectionId path string true "Section ID"
+// @Param lesson body dto.CreateLessonDto true "Lesson details"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Lesson "Lesson added"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or section not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 422 {object} map[string]string "Section has too many lessons"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/sections/{sectionId}/lessons [post]
+func (h *CurriculumHandler) AddLesson(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var lessonDto dto.CreateLessonDto
+	if !decodeAndValidate(w, r, &lessonDto) {
+		return
+	}
+
+	lesson, err := h.service.AddLesson(
+		ctx, r.PathValue("id"), r.PathValue("sectionId"), userId, &lessonDto,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, lesson)
+}
+
+// @Summary Update a lesson
+// @Description Update a lesson's title, content type or duration (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param sectionId path string true "Section ID"
+// @Param lessonId path string true "Lesson ID"
+// @Param lesson body dto.UpdateLessonDto true "Fields to update"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Lesson "Lesson updated"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course, section or lesson not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/sections/{sectionId}/lessons/{lessonId} [patch]
+func (h *CurriculumHandler) UpdateLesson(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var lessonDto dto.UpdateLessonDto
+	if !decodeAndValidate(w, r, &lessonDto) {
+		return
+	}
+
+	lesson, err := h.service.UpdateLesson(
+		ctx, r.PathValue("id"), r.PathValue("sectionId"),
+		r.PathValue("lessonId"), userId, &lessonDto,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, lesson)
+}
+
+// @Summary Delete a lesson
+// @Description Delete a lesson from its section (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param sectionId path string true "Section ID"
+// @Param lessonId path string true "Lesson ID"
+// @Success 204 "Lesson deleted"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course, section or lesson not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/sections/{sectionId}/lessons/{lessonId} [delete]
+func (h *CurriculumHandler) DeleteLesson(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	err := h.service.DeleteLesson(
+		ctx, r.PathValue("id"), r.PathValue("sectionId"),
+		r.PathValue("lessonId"), userId,
+	)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Reorder the curriculum
+// @Description Set the order of every section and lesson in one atomic write. Lessons can move between sections. The order must list each current section and lesson exactly once (Course instructor only)
+// @Tags curriculum
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param order body dto.ReorderCurriculumDto true "New order"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Curriculum "Reordered curriculum"
+// @Failure 400 {object} map[string]string "Bad request - invalid or incomplete order"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Curriculum changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/curriculum/order [put]
+func (h *CurriculumHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var orderDto dto.ReorderCurriculumDto
+	if !decodeAndValidate(w, r, &orderDto) {
+		return
+	}
+
+	curriculum, err := h.service.Reorder(ctx, r.PathValue("id"), userId, &orderDto)
+	if err != nil {
+		respondWithCurriculumError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, curriculum)
+}
+
+// decodeAndValidate reads the JSON body into target and validates it,
+// writing the error response and returning false when either fails
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, target interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(target)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return false
+	}
+
+	validationErr := helpers.ValidateStruct(target)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type EnrollmentHandler struct {
+	service services.EnrollmentService
+}
+
+func NewEnrollmentHandler(service services.EnrollmentService) *EnrollmentHandler {
+	return &EnrollmentHandler{service: service}
+}
+
+// respondWithEnrollmentError maps enrollment service errors to a status
+func respondWithEnrollmentError(w http.ResponseWriter, err error) {
+	var prerequisitesErr *services.PrerequisitesNotMetError
+	switch {
+	case errors.As(err, &prerequisitesErr):
+		// The missing prerequisites go in errors, for the client to list
+		RespondWithErrorDetails(
+			w, http.StatusForbidden, services.ErrPrerequisitesNotMet.Error(),
+			prerequisitesErr.Missing,
+		)
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidUserID),
+		errors.Is(err, services.ErrInvalidEnrollmentStatus):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotCourseOwner),
+		errors.Is(err, services.ErrForbidden):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrNotEnrolled):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCourseNotEnrollable),
+		errors.Is(err, services.ErrEnrollmentConflict):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Enroll in a course
+// @Description Enroll the signed in user in a published course, or reactivate a cancelled enrollment. Enrolling again returns the existing enrollment with 200. Courses with prerequisites need them completed first, the ones missing are listed in errors
+// @Tags enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Enrollment "Already enrolled"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Enrollment "Enrolled"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Prerequisites not completed, with the missing ones"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Course is not published"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/enroll [post]
+func (h *EnrollmentHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	enrollment, changed, err := h.service.Enroll(ctx, r.PathValue("id"), userId)
+	if err != nil {
+		respondWithEnrollmentError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if changed {
+		status = http.StatusCreated
+	}
+
+	RespondWithJSON(w, status, enrollment)
+}
+
+// @Summary Enroll a learner
+// @Description Enroll a learner in a published course whether or not they completed its prerequisites (Admin only)
+// @Tags enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param userId path string true "Learner's user ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Enrollment "Already enrolled"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Enrollment "Enrolled"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - admin only"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Course is not published"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/enrollments/{userId} [post]
+func (h *EnrollmentHandler) EnrollLearner(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	enrollment, changed, err := h.service.EnrollLearner(
+		ctx, r.PathValue("id"), r.PathValue("userId"),
+	)
+	if err != nil {
+		respondWithEnrollmentError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if changed {
+		status = http.StatusCreated
+	}
+
+	RespondWithJSON(w, status, enrollment)
+}
+
+// @Summary Unenroll from a course
+// @Description Cancel the signed in user's enrollment in a course. Unenrolling again is harmless
+// @Tags enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Enrollment "Cancelled enrollment"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Course not found or not enrolled"
+// @Failure 409 {object} map[string]string "Enrollment changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/enroll [delete]
+func (h *EnrollmentHandler) Unenroll(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	enrollment, err := h.service.Unenroll(ctx, r.PathValue("id"), userId)
+	if err != nil {
+		respondWithEnrollmentError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, enrollment)
+}
+
+// @Summary List a course's enrollments
+// @Description List the learners enrolled in a course, most recent first (Course instructor or Admin only)
+// @Tags enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param status query string false "Only enrollments in this status" Enums(active, completed, cancelled)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{} "Paginated list of enrollments"
+// @Failure 400 {object} map[string]string "Invalid course ID or status"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/enrollments [get]
+func (h *EnrollmentHandler) GetCourseEnrollments(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	page, pageSize := pageParams(r)
+
+	enrollments, totalCount, err := h.service.GetCourseEnrollments(
+		ctx, r.PathValue("id"), userId, userRole, r.URL.Query().Get("status"),
+		int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithEnrollmentError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, enrollments, page, len(enrollments), totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
+
+// @Summary Update a learner's enrollment
+// @Description Complete, cancel or reactivate a learner's enrollment (Course instructor or Admin only)
+// @Tags enrollments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param userId path string true "Learner's user ID"
+// @Param enrollment body dto.UpdateEnrollmentDto true "New status"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Enrollment "Updated enrollment"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found or learner not enrolled"
+// @Failure 409 {object} map[string]string "Enrollment changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/enrollments/{userId} [patch]
+func (h *EnrollmentHandler) UpdateEnrollment(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	var enrollmentDto dto.UpdateEnrollmentDto
+	if !decodeAndValidate(w, r, &enrollmentDto) {
+		return
+	}
+
+	enrollment, err := h.service.UpdateEnrollmentStatus(
+		ctx, r.PathValue("id"), r.PathValue("userId"), userId, userRole,
+		enrollmentDto.Status,
+	)
+	if err != nil {
+		respondWithEnrollmentError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, enrollment)
+}
+
+// @Summary List a user's enrolled courses
+// @Description List the courses a user is enrolled in with their enrollment, most recent first. Users see their own, admins anybody's
+// @Tags enrollments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Param status query string false "Only enrollments in this status" Enums(active, completed, cancelled)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{} "Paginated list of dto.EnrolledCourse"
+// @Failure 400 {object} map[string]string "Invalid user ID or status"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/{id}/enrolled-courses [get]
+func (h *EnrollmentHandler) GetUserEnrollments(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	page, pageSize := pageParams(r)
+
+	enrolled, totalCount, err := h.service.GetUserEnrollments(
+		ctx, r.PathValue("id"), userId, userRole, r.URL.Query().Get("status"),
+		int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithEnrollmentError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, enrolled, page, len(enrolled), totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
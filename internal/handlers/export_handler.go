@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type ExportHandler struct {
+	service services.ExportService
+}
+
+func NewExportHandler(service services.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// @Summary Export my data
+// @Description Start an export of the authenticated user's personal data. The archive is built in the background, poll the export to get a download link
+// @Tags exports
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} github_com_AhmedHossam777_go-mongo_internal_dto.DataExportResponse "Export queued"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/export [post]
+func (h *ExportHandler) RequestMyExport(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	h.requestExport(w, r, userId, userId)
+}
+
+// @Summary Export a user's data
+// @Description Start an export of any user's personal data (Admin only)
+// @Tags exports
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 202 {object} github_com_AhmedHossam777_go-mongo_internal_dto.DataExportResponse "Export queued"
+// @Failure 400 {object} map[string]string "Bad request - invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/{id}/export [post]
+func (h *ExportHandler) RequestUserExport(
+	w http.ResponseWriter, r *http.Request,
+) {
+	adminId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	h.requestExport(w, r, r.PathValue("id"), adminId)
+}
+
+func (h *ExportHandler) requestExport(
+	w http.ResponseWriter, r *http.Request, userId string, requestedBy string,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	export, err := h.service.RequestExport(ctx, userId, requestedBy)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserID) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while requesting data export",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusAccepted, export)
+}
+
+// @Summary Get export status
+// @Description Get the status of a data export. Completed exports include a time-limited download link
+// @Tags exports
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_dto.DataExportResponse "Export status"
+// @Failure 400 {object} map[string]string "Bad request - invalid export ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Export not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /exports/{id} [get]
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	export, err := h.service.GetExport(ctx, r.PathValue("id"), userId, userRole)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidExportID):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrForbidden):
+			RespondWithError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, services.ErrExportNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while getting data export",
+			)
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, export)
+}
+
+// @Summary Download export
+// @Description Download a completed data export as a zip archive using the signed link from the export status
+// @Tags exports
+// @Produce application/zip
+// @Param id path string true "Export ID"
+// @Param expires query string true "Link expiry (unix seconds)"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file "Zip archive"
+// @Failure 403 {object} map[string]string "Invalid or expired link"
+// @Failure 404 {object} map[string]string "Export not found"
+// @Failure 409 {object} map[string]string "Export not ready"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /exports/{id}/download [get]
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	query := r.URL.Query()
+	file, stream, err := h.service.OpenDownload(
+		ctx, r.PathValue("id"), query.Get("expires"), query.Get("signature"),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDownloadLink):
+			RespondWithError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, services.ErrInvalidExportID),
+			errors.Is(err, services.ErrExportNotFound):
+			RespondWithError(w, http.StatusNotFound, "export not found")
+		case errors.Is(err, services.ErrExportNotReady):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while downloading data export",
+			)
+		}
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", file.Metadata.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Length, 10))
+	w.Header().Set(
+		"Content-Disposition", `attachment; filename="`+file.Filename+`"`,
+	)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, stream)
+}
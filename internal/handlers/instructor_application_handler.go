@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type InstructorApplicationHandler struct {
+	service services.InstructorApplicationService
+}
+
+func NewInstructorApplicationHandler(
+	service services.InstructorApplicationService,
+) *InstructorApplicationHandler {
+	return &InstructorApplicationHandler{service: service}
+}
+
+// respondWithApplicationError maps instructor application errors to a status
+func respondWithApplicationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidApplicationID),
+		errors.Is(err, services.ErrInvalidUserID):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrApplicationNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrApplicationPending),
+		errors.Is(err, services.ErrAlreadyInstructor):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Apply to become an instructor
+// @Description Request the instructor role with a bio and links to sample material. Only one application can be pending at a time
+// @Tags instructor-applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param application body dto.CreateInstructorApplicationDto true "Application"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.InstructorApplication "Application submitted"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Already an instructor or an application is pending"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /instructor-applications [post]
+func (h *InstructorApplicationHandler) Apply(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var applicationDto dto.CreateInstructorApplicationDto
+	err := json.NewDecoder(r.Body).Decode(&applicationDto)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(applicationDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	application, err := h.service.Apply(ctx, userId, &applicationDto)
+	if err != nil {
+		respondWithApplicationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, application)
+}
+
+// @Summary List my instructor applications
+// @Description List the authenticated user's instructor applications, newest first
+// @Tags instructor-applications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} github_com_AhmedHossam777_go-mongo_internal_models.InstructorApplication "Applications"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /instructor-applications/me [get]
+func (h *InstructorApplicationHandler) GetMyApplications(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	applications, err := h.service.GetMyApplications(ctx, userId)
+	if err != nil {
+		respondWithApplicationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, applications)
+}
+
+// @Summary Review queue of instructor applications
+// @Description List instructor applications, oldest first. Defaults to pending applications (Admin only)
+// @Tags instructor-applications
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, approved, rejected or all (default: pending)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Paginated list of applications"
+// @Failure 400 {object} map[string]string "Bad request - invalid status"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /instructor-applications [get]
+func (h *InstructorApplicationHandler) GetAllApplications(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	page, pageSize := pageParams(r)
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.ApplicationStatusPending
+	case "all":
+		status = ""
+	case models.ApplicationStatusPending, models.ApplicationStatusApproved,
+		models.ApplicationStatusRejected:
+	default:
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"status must be one of pending, approved, rejected or all",
+		)
+		return
+	}
+
+	applications, totalCount, err := h.service.GetAllApplications(
+		ctx, status, int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithApplicationError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, applications, page, len(applications),
+		totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
+
+// @Summary Approve an instructor application
+// @Description Approve a pending application, granting the applicant the instructor role (Admin only)
+// @Tags instructor-applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.InstructorApplication "Application approved"
+// @Failure 400 {object} map[string]string "Bad request - invalid application ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "No pending application with this ID"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /instructor-applications/{id}/approve [post]
+func (h *InstructorApplicationHandler) Approve(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	adminId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	application, err := h.service.Approve(ctx, r.PathValue("id"), adminId)
+	if err != nil {
+		respondWithApplicationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, application)
+}
+
+// @Summary Reject an instructor application
+// @Description Reject a pending application with a reason that is sent to the applicant (Admin only)
+// @Tags instructor-applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param rejection body dto.RejectInstructorApplicationDto true "Reason"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.InstructorApplication "Application rejected"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "No pending application with this ID"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /instructor-applications/{id}/reject [post]
+func (h *InstructorApplicationHandler) Reject(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	adminId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var rejectDto dto.RejectInstructorApplicationDto
+	err := json.NewDecoder(r.Body).Decode(&rejectDto)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(rejectDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	application, err := h.service.Reject(
+		ctx, r.PathValue("id"), adminId, rejectDto.Reason,
+	)
+	if err != nil {
+		respondWithApplicationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, application)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type InvitationHandler struct {
+	service services.InvitationService
+}
+
+func NewInvitationHandler(
+	service services.InvitationService,
+) *InvitationHandler {
+	return &InvitationHandler{service: service}
+}
+
+// @Summary Invite someone
+// @Description Invite a person by email with a predefined role. They get a single-use registration link that expires. Instructors can only invite users (Admin or instructor)
+// @Tags invitations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param invitation body dto.CreateInvitationDto true "Invitation details"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Invitation "Invitation sent"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - role can't be invited by you"
+// @Failure 409 {object} map[string]string "Email already registered or already invited"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /invitations [post]
+func (h *InvitationHandler) CreateInvitation(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	var invitationDto dto.CreateInvitationDto
+	err := json.NewDecoder(r.Body).Decode(&invitationDto)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(invitationDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	invitation, err := h.service.CreateInvitation(
+		ctx, userId, userRole, &invitationDto,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidUserID):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrForbidden):
+			RespondWithError(
+				w, http.StatusForbidden,
+				"you can't invite people with the "+invitationDto.Role+" role",
+			)
+		case errors.Is(err, services.ErrEmailAlreadyExists),
+			errors.Is(err, services.ErrInvitationExists):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, invitation)
+}
+
+// @Summary List invitations
+// @Description List invitations, newest first. Defaults to pending invitations (Admin only)
+// @Tags invitations
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "pending, accepted, revoked or all (default: pending)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Paginated list of invitations"
+// @Failure 400 {object} map[string]string "Bad request - invalid status"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /invitations [get]
+func (h *InvitationHandler) GetAllInvitations(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10 // Default to 10
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.InvitationStatusPending
+	case "all":
+		status = ""
+	case models.InvitationStatusPending, models.InvitationStatusAccepted,
+		models.InvitationStatusRevoked:
+	default:
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"status must be one of pending, accepted, revoked or all",
+		)
+		return
+	}
+
+	invitations, totalCount, err := h.service.GetAllInvitations(
+		ctx, status, int64(page), int64(pageSize),
+	)
+	if err != nil {
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while fetching invitations, "+err.Error(),
+		)
+		return
+	}
+
+	hasMore := int(totalCount) > page*pageSize
+
+	PaginationResponse(
+		w, http.StatusOK, invitations, page, len(invitations),
+		totalCount,
+		hasMore,
+	)
+}
+
+// @Summary Revoke an invitation
+// @Description Revoke a pending invitation so its link can no longer be used (Admin only)
+// @Tags invitations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Invitation "Invitation revoked"
+// @Failure 400 {object} map[string]string "Bad request - invalid invitation ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "No pending invitation with this ID"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /invitations/{id} [delete]
+func (h *InvitationHandler) RevokeInvitation(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	invitation, err := h.service.RevokeInvitation(ctx, r.PathValue("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInvitationID):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrInvitationNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, invitation)
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type OrganizationHandler struct {
+	service services.OrganizationService
+}
+
+func NewOrganizationHandler(
+	service services.OrganizationService,
+) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+// respondWithOrganizationError maps organization service errors to a status
+func respondWithOrganizationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidOrganizationID),
+		errors.Is(err, services.ErrInvalidUserID):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		RespondWithError(
+			w, http.StatusForbidden,
+			"only platform admins can manage organizations",
+		)
+	case errors.Is(err, services.ErrOrganizationNotFound),
+		errors.Is(err, services.ErrMembershipNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrOrganizationExists),
+		errors.Is(err, services.ErrAlreadyMember):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Create an organization
+// @Description Create a tenant such as a school. Its users and courses are only visible inside it (Platform admin only)
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param organization body dto.CreateOrganizationDto true "Organization details"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Organization "Organization created"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - platform admin access required"
+// @Failure 409 {object} map[string]string "Slug already taken"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var organizationDto dto.CreateOrganizationDto
+	err := json.NewDecoder(r.Body).Decode(&organizationDto)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(organizationDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	organization, err := h.service.CreateOrganization(
+		ctx, userId, &organizationDto,
+	)
+	if err != nil {
+		respondWithOrganizationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, organization)
+}
+
+// @Summary List organizations
+// @Description List organizations. Organization admins only see their own (Admin only)
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Paginated list of organizations"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /organizations [get]
+func (h *OrganizationHandler) GetAllOrganizations(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	page, pageSize := pageParams(r)
+
+	organizations, totalCount, err := h.service.GetAllOrganizations(
+		ctx, int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithOrganizationError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, organizations, page, len(organizations),
+		totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
+
+// @Summary Get an organization
+// @Description Get an organization by ID. Organization admins can only get their own (Admin only)
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Organization "Organization"
+// @Failure 400 {object} map[string]string "Bad request - invalid organization ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	organization, err := h.service.GetOrganization(ctx, r.PathValue("id"))
+	if err != nil {
+		respondWithOrganizationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, organization)
+}
+
+// @Summary List organization members
+// @Description List the members of an organization with their roles (Admin only)
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Paginated list of memberships"
+// @Failure 400 {object} map[string]string "Bad request - invalid organization ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /organizations/{id}/members [get]
+func (h *OrganizationHandler) GetMembers(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	page, pageSize := pageParams(r)
+
+	members, totalCount, err := h.service.GetMembers(
+		ctx, r.PathValue("id"), int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithOrganizationError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, members, page, len(members),
+		totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
+
+// @Summary Add an organization member
+// @Description Move a user that doesn't belong to any organization into this one with a role. Their sessions are ended (Platform admin only)
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param member body dto.AddMemberDto true "User and role"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Membership "Member added"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - platform admin access required"
+// @Failure 404 {object} map[string]string "Organization or user not found"
+// @Failure 409 {object} map[string]string "User already belongs to an organization"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /organizations/{id}/members [post]
+func (h *OrganizationHandler) AddMember(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var memberDto dto.AddMemberDto
+	err := json.NewDecoder(r.Body).Decode(&memberDto)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(memberDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	membership, err := h.service.AddMember(ctx, r.PathValue("id"), &memberDto)
+	if err != nil {
+		respondWithOrganizationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, membership)
+}
+
+// @Summary Change a member's role
+// @Description Change a member's role inside the organization. Their sessions are ended so the new role applies (Admin only)
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Param role body dto.UpdateMemberRoleDto true "New role"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Membership "Role updated"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "Organization or membership not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /organizations/{id}/members/{userId} [patch]
+func (h *OrganizationHandler) UpdateMemberRole(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var roleDto dto.UpdateMemberRoleDto
+	err := json.NewDecoder(r.Body).Decode(&roleDto)
+	defer r.Body.Close()
+
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(roleDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	membership, err := h.service.UpdateMemberRole(
+		ctx, r.PathValue("id"), r.PathValue("userId"), roleDto.Role,
+	)
+	if err != nil {
+		respondWithOrganizationError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, membership)
+}
+
+// pageParams reads the page and page_size query parameters
+func pageParams(r *http.Request) (int, int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10 // Default to 10
+	}
+
+	return page, pageSize
+}
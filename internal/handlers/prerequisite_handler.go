@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type PrerequisiteHandler struct {
+	service services.PrerequisiteService
+}
+
+func NewPrerequisiteHandler(
+	service services.PrerequisiteService,
+) *PrerequisiteHandler {
+	return &PrerequisiteHandler{service: service}
+}
+
+// respondWithPrerequisiteError maps prerequisite service errors to a status
+func respondWithPrerequisiteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseID):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotCourseOwner):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrPrerequisiteNotFound):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrPrerequisiteCycle),
+		errors.Is(err, services.ErrTooManyPrerequisites):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Get a course's prerequisites
+// @Description Get everything a course requires, directly or through its prerequisites, as a tree. Signed in learners see which courses they completed
+// @Tags prerequisites
+// @Produce json
+// @Param id path string true "Course ID"
+// @Success 200 {object} dto.PrerequisiteNode "The course with its prerequisites"
+// @Failure 400 {object} map[string]string "Invalid course ID"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/prerequisites [get]
+func (h *PrerequisiteHandler) GetPrerequisites(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	tree, err := h.service.GetPrerequisites(
+		ctx, r.PathValue("id"), viewerId, viewerRole,
+	)
+	if err != nil {
+		respondWithPrerequisiteError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, tree)
+}
+
+// @Summary Add a prerequisite
+// @Description Require learners to complete another course before enrolling. A prerequisite that would make the course require itself is rejected (Course instructor or Admin only)
+// @Tags prerequisites
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param prerequisite body dto.AddPrerequisiteDto true "Course to require"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Updated course"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or published prerequisite not found"
+// @Failure 409 {object} map[string]string "Prerequisite would create a cycle, or too many prerequisites"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/prerequisites [post]
+func (h *PrerequisiteHandler) AddPrerequisite(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	var prerequisiteDto dto.AddPrerequisiteDto
+	if !decodeAndValidate(w, r, &prerequisiteDto) {
+		return
+	}
+
+	course, err := h.service.AddPrerequisite(
+		ctx, r.PathValue("id"), prerequisiteDto.CourseId, userId, userRole,
+	)
+	if err != nil {
+		respondWithPrerequisiteError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, course)
+}
+
+// @Summary Remove a prerequisite
+// @Description Stop requiring a course before enrolling (Course instructor or Admin only)
+// @Tags prerequisites
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param prerequisiteId path string true "Prerequisite course ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Course "Updated course"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/prerequisites/{prerequisiteId} [delete]
+func (h *PrerequisiteHandler) RemovePrerequisite(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	course, err := h.service.RemovePrerequisite(
+		ctx, r.PathValue("id"), r.PathValue("prerequisiteId"), userId, userRole,
+	)
+	if err != nil {
+		respondWithPrerequisiteError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, course)
+}
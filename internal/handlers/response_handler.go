@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/AhmedHossam777/go-mongo/internal/helpers"
 )
@@ -20,7 +23,9 @@ type PaginatedResponse struct {
 	Count      int         `json:"count"`
 	TotalCount int64       `json:"total_count,omitempty"`
 	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
 	HasMore    bool        `json:"has_more"`
+	Facets     interface{} `json:"facets,omitempty"`
 }
 
 func PaginationResponse(
@@ -39,6 +44,44 @@ func PaginationResponse(
 	})
 }
 
+// CursorPaginationResponse writes a page that carries cursors, and links
+// to the next, previous and first pages in an RFC 8288 Link header
+func CursorPaginationResponse(
+	w http.ResponseWriter, r *http.Request, statusCode int,
+	response PaginatedResponse,
+) {
+	links := make([]string, 0, 3)
+	if response.NextCursor != "" {
+		links = append(links, pageLink(r, response.NextCursor, "next"))
+	}
+	if response.PrevCursor != "" {
+		links = append(links, pageLink(r, response.PrevCursor, "prev"))
+		links = append(links, pageLink(r, "", "first"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// pageLink is the request's own URL with its cursor swapped for cursor, so
+// the filters and page size carry over
+func pageLink(r *http.Request, cursor string, rel string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("cursor")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", link.String(), rel)
+}
+
 func RespondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -57,6 +100,20 @@ func RespondWithError(w http.ResponseWriter, statusCode int, message string) {
 	})
 }
 
+// RespondWithErrorDetails writes an error with details, such as a list of
+// what is missing, in the response's errors
+func RespondWithErrorDetails(
+	w http.ResponseWriter, statusCode int, message string, details interface{},
+) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(APIResponse{
+		Success: false,
+		Message: message,
+		Errors:  details,
+	})
+}
+
 func RespondWithValidationErrors(
 	w http.ResponseWriter, errors []helpers.ValidationError,
 ) {
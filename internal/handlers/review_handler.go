@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type ReviewHandler struct {
+	service services.ReviewService
+}
+
+func NewReviewHandler(service services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: service}
+}
+
+// respondWithReviewError maps review service errors to a status
+func respondWithReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseID),
+		errors.Is(err, services.ErrInvalidReviewID),
+		errors.Is(err, services.ErrInvalidUserID),
+		errors.Is(err, services.ErrInvalidReviewSort):
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotReviewAuthor),
+		errors.Is(err, services.ErrNotCourseOwner),
+		errors.Is(err, services.ErrOwnCourseReview),
+		errors.Is(err, services.ErrOwnReviewVote),
+		errors.Is(err, services.ErrReviewNotEnrolled):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrCourseNotFound),
+		errors.Is(err, services.ErrReviewNotFound),
+		errors.Is(err, services.ErrReviewReplyMissing):
+		RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrAlreadyReviewed),
+		errors.Is(err, services.ErrReviewConflict):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary Review a course
+// @Description Rate a course from 1 to 5 with an optional text. Learners enrolled in the course review it once, the course's average rating is updated right away
+// @Tags reviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param review body dto.CreateReviewDto true "Rating and text"
+// @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.Review "Created review"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not enrolled, or the course's own instructor"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 409 {object} map[string]string "Course already reviewed"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews [post]
+func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var reviewDto dto.CreateReviewDto
+	if !decodeAndValidate(w, r, &reviewDto) {
+		return
+	}
+
+	review, err := h.service.CreateReview(ctx, r.PathValue("id"), userId, &reviewDto)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, review)
+}
+
+// @Summary List a course's reviews
+// @Description List a course's reviews, newest or most helpful first. Signed in instructors and admins also see the reviews of their unpublished courses
+// @Tags reviews
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param sort query string false "Order of the reviews" Enums(newest, helpful) default(newest)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{} "Paginated list of reviews"
+// @Failure 400 {object} map[string]string "Invalid course ID or sort"
+// @Failure 404 {object} map[string]string "Course not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews [get]
+func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	page, pageSize := pageParams(r)
+
+	reviews, totalCount, err := h.service.GetReviews(
+		ctx, r.PathValue("id"), viewerId, viewerRole, r.URL.Query().Get("sort"),
+		int64(page), int64(pageSize),
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	PaginationResponse(
+		w, http.StatusOK, reviews, page, len(reviews), totalCount,
+		int(totalCount) > page*pageSize,
+	)
+}
+
+// @Summary Edit a review
+// @Description Change the rating or text of your review, the course's average rating follows
+// @Tags reviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param reviewId path string true "Review ID"
+// @Param review body dto.UpdateReviewDto true "Fields to change"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Review "Updated review"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the review's author"
+// @Failure 404 {object} map[string]string "Review not found"
+// @Failure 409 {object} map[string]string "Review changed concurrently"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews/{reviewId} [patch]
+func (h *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var reviewDto dto.UpdateReviewDto
+	if !decodeAndValidate(w, r, &reviewDto) {
+		return
+	}
+
+	review, err := h.service.UpdateReview(
+		ctx, r.PathValue("id"), r.PathValue("reviewId"), userId, &reviewDto,
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, review)
+}
+
+// @Summary Delete a review
+// @Description Delete your review, its rating is taken off the course's average (Author or Admin only)
+// @Tags reviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param reviewId path string true "Review ID"
+// @Success 200 {object} map[string]string "Review deleted"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the review's author"
+// @Failure 404 {object} map[string]string "Review not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews/{reviewId} [delete]
+func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	err := h.service.DeleteReview(
+		ctx, r.PathValue("id"), r.PathValue("reviewId"), userId, userRole,
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(
+		w, http.StatusOK, map[string]string{"message": "review deleted"},
+	)
+}
+
+// @Summary Reply to a review
+// @Description Reply to a review of your course, replying again replaces the reply (Course instructor or Admin only)
+// @Tags reviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param reviewId path string true "Review ID"
+// @Param reply body dto.ReviewReplyDto true "Reply"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Review "Review with the reply"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course or review not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews/{reviewId}/reply [put]
+func (h *ReviewHandler) ReplyToReview(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	var replyDto dto.ReviewReplyDto
+	if !decodeAndValidate(w, r, &replyDto) {
+		return
+	}
+
+	review, err := h.service.ReplyToReview(
+		ctx, r.PathValue("id"), r.PathValue("reviewId"), userId, userRole,
+		&replyDto,
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, review)
+}
+
+// @Summary Delete a reply
+// @Description Remove the reply to a review of your course (Course instructor or Admin only)
+// @Tags reviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param reviewId path string true "Review ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Review "Review without the reply"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not the course's instructor"
+// @Failure 404 {object} map[string]string "Course, review or reply not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews/{reviewId}/reply [delete]
+func (h *ReviewHandler) DeleteReply(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	userRole, _ := r.Context().Value("userRole").(string)
+
+	review, err := h.service.DeleteReply(
+		ctx, r.PathValue("id"), r.PathValue("reviewId"), userId, userRole,
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, review)
+}
+
+// @Summary Mark a review helpful
+// @Description Count a review as helpful, which ranks it higher when sorting by most helpful. Marking it again changes nothing
+// @Tags reviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param reviewId path string true "Review ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Review "Review"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Your own review"
+// @Failure 404 {object} map[string]string "Review not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews/{reviewId}/helpful [post]
+func (h *ReviewHandler) MarkHelpful(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	review, err := h.service.MarkHelpful(
+		ctx, r.PathValue("id"), r.PathValue("reviewId"), userId,
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, review)
+}
+
+// @Summary Take back a helpful vote
+// @Description Stop counting a review you marked as helpful
+// @Tags reviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param reviewId path string true "Review ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.Review "Review"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Review not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /courses/{id}/reviews/{reviewId}/helpful [delete]
+func (h *ReviewHandler) UnmarkHelpful(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	review, err := h.service.UnmarkHelpful(
+		ctx, r.PathValue("id"), r.PathValue("reviewId"), userId,
+	)
+	if err != nil {
+		respondWithReviewError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, review)
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/dto"
@@ -25,18 +28,28 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 }
 
 // @Summary Get all users
-// @Description Get a paginated list of all users
+// @Description Get a paginated list of all users, optionally filtered and sorted. Admins get full user documents and can search by email, everybody else gets public profiles
 // @Tags users
+// @Security BearerAuth
 // @Produce json
-// @Param page query int false "Page number (default: 1)"
+// @Param page query int false "Page number (default: 1), ignored with a cursor"
 // @Param page_size query int false "Page size (default: 10, max: 100)"
-// @Success 200 {object} map[string]interface{} "Paginated list of users"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, only valid with the same sort and order"
+// @Param role query string false "Filter by role (Admin only)" Enums(user, instructor, admin)
+// @Param q query string false "Case-insensitive name or email substring"
+// @Param created_from query string false "Created on or after, RFC3339 or YYYY-MM-DD (Admin only)"
+// @Param created_to query string false "Created on or before, RFC3339 or YYYY-MM-DD (Admin only)"
+// @Param sort query string false "Sort field (default: created_at for admins, name for everybody else), only name is open to non-admins" Enums(created_at, updated_at, name, email)
+// @Param order query string false "Sort order (default: desc, or asc for the name sort non-admins get by default)" Enums(asc, desc)
+// @Success 200 {object} handlers.PaginatedResponse "Paginated list of users, the Link header points to the next, previous and first pages"
+// @Failure 400 {object} map[string]string "Bad request - invalid filter or cursor"
+// @Failure 403 {object} map[string]string "Filter or sort order reserved for admins"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users [get]
 func (h *UserHandler) GetAllUsers(
 	w http.ResponseWriter, r *http.Request,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
@@ -49,11 +62,45 @@ func (h *UserHandler) GetAllUsers(
 		pageSize = 10 // Default to 10
 	}
 
-	users, totalCount, err := h.service.GetAllUsers(
-		ctx, int64(page),
+	listQuery, err := parseUserListQuery(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(listQuery)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	viewerRole, _ := r.Context().Value("userRole").(string)
+	listQuery.AdminView = viewerRole == "admin"
+
+	err = checkPublicUserListQuery(listQuery)
+	if err != nil {
+		RespondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	// Everybody else gets the list by name, sorting by date would give
+	// away when users joined
+	if !listQuery.AdminView && listQuery.Sort == "" {
+		listQuery.Sort = "name"
+		if listQuery.Order == "" {
+			listQuery.Order = "asc"
+		}
+	}
+
+	users, totalCount, cursors, err := h.service.GetAllUsers(
+		ctx, listQuery, int64(page),
 		int64(pageSize),
 	)
 
+	if errors.Is(err, helpers.ErrInvalidCursor) {
+		RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		RespondWithError(
@@ -63,19 +110,29 @@ func (h *UserHandler) GetAllUsers(
 		return
 	}
 
-	hasMore := false
-	count := pageSize
-	if int(totalCount) > (page * pageSize) {
-		hasMore = true
-	} else {
-		count = pageSize - (page*pageSize - int(totalCount))
+	var data interface{} = users
+	if !listQuery.AdminView {
+		profiles := make([]models.PublicProfile, 0, len(users))
+		for i := range users {
+			profiles = append(profiles, users[i].ToPublicProfile())
+		}
+		data = profiles
+	}
+
+	// A page number means nothing once paging by cursor
+	if listQuery.Cursor != "" {
+		page = 0
 	}
 
-	PaginationResponse(
-		w, http.StatusOK, users, page, count,
-		totalCount,
-		hasMore,
-	)
+	CursorPaginationResponse(w, r, http.StatusOK, PaginatedResponse{
+		Data:       data,
+		Page:       page,
+		Count:      len(users),
+		TotalCount: totalCount,
+		NextCursor: cursors.Next,
+		PrevCursor: cursors.Prev,
+		HasMore:    cursors.Next != "",
+	})
 }
 
 // @Summary Get current user
@@ -90,7 +147,7 @@ func (h *UserHandler) GetAllUsers(
 func (h *UserHandler) GetMe(
 	w http.ResponseWriter, r *http.Request,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	userId := r.Context().Value("userId").(string)
@@ -108,17 +165,20 @@ func (h *UserHandler) GetMe(
 }
 
 // @Summary Create a new user
-// @Description Create a new user with name, email, password and optional role
+// @Description Create a new user with name, email, password and optional role (Admin only)
 // @Tags users
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateUserDto true "User details"
 // @Success 201 {object} github_com_AhmedHossam777_go-mongo_internal_models.UserResponse "User created successfully"
 // @Failure 400 {object} map[string]string "Bad request - validation error or user already exists"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - admin only"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users [post]
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var createUserDto dto.CreateUserDto
@@ -183,20 +243,31 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get user by ID
-// @Description Get a specific user by their ID
+// @Description Get a specific user by their ID. The user themselves and admins get the full document, everybody else gets the public profile allowed by the user's privacy settings
 // @Tags users
+// @Security BearerAuth
 // @Produce json
 // @Param id path string true "User ID"
-// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "User details"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.PublicProfile "Public profile, or the full user for self and admins"
+// @Failure 400 {object} map[string]string "Bad request - invalid user ID"
+// @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users/{id} [get]
 func (h *UserHandler) GetOneUser(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	userId := r.PathValue("id")
 	user, err := h.service.GetOneUser(ctx, userId)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserID) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		RespondWithError(
 			w, http.StatusInternalServerError,
 			"error while getting one user",
@@ -204,22 +275,33 @@ func (h *UserHandler) GetOneUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+	if viewerRole != "admin" && viewerId != user.ID.Hex() {
+		RespondWithJSON(w, http.StatusOK, user.ToPublicProfile())
+		return
+	}
+
 	RespondWithJSON(w, http.StatusOK, user)
 }
 
 // @Summary Update user
-// @Description Update user details by ID
+// @Description Update user details by ID (the user themselves or Admin only)
 // @Tags users
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
 // @Param request body dto.UpdateUserDto true "Updated user details"
 // @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "User updated successfully"
 // @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not your account"
+// @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users/{id} [patch]
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	var updateUserDto dto.UpdateUserDto
@@ -239,9 +321,30 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	callerId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+	callerRole, _ := r.Context().Value("userRole").(string)
+
 	userId := r.PathValue("id")
-	updatedUser, err := h.service.UpdateUser(ctx, userId, &updateUserDto)
+	updatedUser, err := h.service.UpdateUser(
+		ctx, userId, callerId, callerRole, &updateUserDto,
+	)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserID) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			RespondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		RespondWithError(
 			w, http.StatusInternalServerError,
 			"error while updating one user",
@@ -253,24 +356,34 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete user
-// @Description Delete a user by ID (Admin only)
+// @Description Soft delete a user by ID and revoke their sessions (Admin only). The user is purged permanently after the retention period
 // @Tags users
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 "User deleted successfully"
+// @Failure 400 {object} map[string]string "Bad request - invalid user ID"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	userId := r.PathValue("id")
 
 	err := h.service.DeleteUser(ctx, userId)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserID) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		RespondWithError(
 			w, http.StatusInternalServerError,
 			"error while deleting one user, "+err.Error(),
@@ -281,6 +394,43 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	RespondWithJSON(w, http.StatusOK, nil)
 }
 
+// @Summary Restore user
+// @Description Restore a soft deleted user before it is purged (Admin only)
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "User restored successfully"
+// @Failure 400 {object} map[string]string "Bad request - invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "Deleted user not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.service.RestoreUser(ctx, r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUserID) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, "deleted user not found")
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while restoring user, "+err.Error(),
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, user)
+}
+
 // @Summary Drop user collection
 // @Description Delete all users from the database (Admin only)
 // @Tags users
@@ -294,7 +444,7 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) DropUserCollection(
 	w http.ResponseWriter, r *http.Request,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	err := h.service.DropUserCollection(ctx)
@@ -308,3 +458,552 @@ func (h *UserHandler) DropUserCollection(
 
 	RespondWithJSON(w, http.StatusOK, nil)
 }
+
+// @Summary Upload or replace avatar
+// @Description Upload a profile picture for the authenticated user, replacing any existing one. Accepts JPEG, PNG, GIF or WebP up to 2MB
+// @Tags users
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param avatar formData file true "Avatar image"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Avatar updated successfully"
+// @Failure 400 {object} map[string]string "Bad request - missing file or unsupported image type"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 413 {object} map[string]string "Avatar too large"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/avatar [put]
+func (h *UserHandler) UpdateAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	// Leave room for the multipart envelope around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAvatarSize+1<<20)
+	defer r.Body.Close()
+
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			RespondWithError(
+				w, http.StatusRequestEntityTooLarge,
+				services.ErrAvatarTooLarge.Error(),
+			)
+			return
+		}
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"avatar file is required, "+err.Error(),
+		)
+		return
+	}
+	defer file.Close()
+
+	updatedUser, err := h.service.UpdateAvatar(ctx, userId, file, header.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAvatarTooLarge):
+			RespondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, services.ErrInvalidAvatarType):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrUserNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while uploading avatar",
+			)
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, updatedUser)
+}
+
+// @Summary Get user avatar
+// @Description Stream a user's avatar image. Clients may cache it but must revalidate with If-None-Match, so a hidden avatar stops showing right away
+// @Tags users
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param id path string true "User ID"
+// @Success 200 {file} file "Avatar image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]string "Bad request - invalid user ID"
+// @Failure 404 {object} map[string]string "User or avatar not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/{id}/avatar [get]
+func (h *UserHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	viewerId, _ := r.Context().Value("userId").(string)
+	viewerRole, _ := r.Context().Value("userRole").(string)
+
+	file, stream, err := h.service.GetAvatar(
+		ctx, r.PathValue("id"), viewerId, viewerRole,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidUserID):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrUserNotFound),
+			errors.Is(err, services.ErrAvatarNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while getting avatar",
+			)
+		}
+		return
+	}
+	defer stream.Close()
+
+	// A new upload always gets a new file id, so the id is a stable ETag.
+	// Every use is revalidated, the privacy check above has to run again
+	// once the user hides their avatar
+	etag := `"` + file.ID.Hex() + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Last-Modified", file.UploadDate.UTC().Format(http.TimeFormat))
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", file.Metadata.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Length, 10))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, stream)
+}
+
+// @Summary Delete avatar
+// @Description Remove the authenticated user's avatar
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Avatar deleted successfully"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Avatar not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/avatar [delete]
+func (h *UserHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	updatedUser, err := h.service.DeleteAvatar(ctx, userId)
+	if err != nil {
+		if errors.Is(err, services.ErrAvatarNotFound) ||
+			errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while deleting avatar",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, updatedUser)
+}
+
+// @Summary Delete my account
+// @Description Schedule the authenticated user's account for deletion after a grace period. All sessions are revoked immediately, logging in again before the deletion runs cancels it
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.DeleteAccountDto true "Password confirmation"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Deletion scheduled"
+// @Failure 400 {object} map[string]string "Bad request - validation error"
+// @Failure 401 {object} map[string]string "Unauthorized or incorrect password"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me [delete]
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var deleteAccountDto dto.DeleteAccountDto
+	err := json.NewDecoder(r.Body).Decode(&deleteAccountDto)
+	defer r.Body.Close()
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(deleteAccountDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	user, err := h.service.ScheduleDeletion(ctx, userId, deleteAccountDto.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrIncorrectPassword) {
+			RespondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while scheduling account deletion",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, user)
+}
+
+func parseUserListQuery(r *http.Request) (dto.UserListQuery, error) {
+	query := r.URL.Query()
+	listQuery := dto.UserListQuery{
+		Role:   query.Get("role"),
+		Search: strings.TrimSpace(query.Get("q")),
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	listQuery.CreatedFrom, err = parseDateParam(query.Get("created_from"), false)
+	if err != nil {
+		return listQuery, fmt.Errorf("invalid created_from, %w", err)
+	}
+
+	listQuery.CreatedTo, err = parseDateParam(query.Get("created_to"), true)
+	if err != nil {
+		return listQuery, fmt.Errorf("invalid created_to, %w", err)
+	}
+
+	if listQuery.CreatedFrom != nil && listQuery.CreatedTo != nil &&
+		listQuery.CreatedTo.Before(*listQuery.CreatedFrom) {
+		return listQuery, errors.New("created_to must not be before created_from")
+	}
+
+	return listQuery, nil
+}
+
+// checkPublicUserListQuery rejects the filters and sort orders that would
+// tell non-admins about fields public profiles hide
+func checkPublicUserListQuery(listQuery dto.UserListQuery) error {
+	if listQuery.AdminView {
+		return nil
+	}
+
+	switch {
+	case listQuery.Role != "":
+		return errors.New("only admins can filter by role")
+	case listQuery.CreatedFrom != nil || listQuery.CreatedTo != nil:
+		return errors.New("only admins can filter by created_from and created_to")
+	case listQuery.Sort != "" && listQuery.Sort != "name":
+		return errors.New("only admins can sort by " + listQuery.Sort)
+	}
+
+	return nil
+}
+
+// parseDateParam accepts RFC3339 timestamps or plain YYYY-MM-DD dates. A
+// plain date used as an upper bound covers that whole day
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return &parsed, nil
+	}
+
+	parsed, err = time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, errors.New("expected RFC3339 or YYYY-MM-DD")
+	}
+
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return &parsed, nil
+}
+
+// @Summary Update privacy settings
+// @Description Choose which profile fields other users can see. Omitted fields keep their current value
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdatePrivacyDto true "Privacy settings"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Privacy settings updated"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/privacy [patch]
+func (h *UserHandler) UpdatePrivacy(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var privacyDto dto.UpdatePrivacyDto
+	err := json.NewDecoder(r.Body).Decode(&privacyDto)
+	defer r.Body.Close()
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	user, err := h.service.UpdatePrivacy(ctx, userId, &privacyDto)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while updating privacy settings",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, user)
+}
+
+// @Summary Request email change
+// @Description Start changing the authenticated user's email. A confirmation link is sent to the new address and a notice to the current one, the email only changes once the link is used
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.RequestEmailChangeDto true "New email and password confirmation"
+// @Success 202 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Confirmation sent"
+// @Failure 400 {object} map[string]string "Bad request - validation error or unchanged email"
+// @Failure 401 {object} map[string]string "Unauthorized or incorrect password"
+// @Failure 409 {object} map[string]string "Email already in use"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/email [post]
+func (h *UserHandler) RequestEmailChange(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	var emailDto dto.RequestEmailChangeDto
+	err := json.NewDecoder(r.Body).Decode(&emailDto)
+	defer r.Body.Close()
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(emailDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	user, err := h.service.RequestEmailChange(ctx, userId, &emailDto)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrIncorrectPassword):
+			RespondWithError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, services.ErrEmailUnchanged):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrEmailAlreadyExists):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, services.ErrUserNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while requesting email change",
+			)
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusAccepted, user)
+}
+
+// @Summary Confirm email change
+// @Description Apply a pending email change using the token from the confirmation link
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.ConfirmEmailChangeDto true "Confirmation token"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Email changed"
+// @Failure 400 {object} map[string]string "Bad request - invalid or expired token"
+// @Failure 409 {object} map[string]string "Email already in use"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/email/confirm [post]
+func (h *UserHandler) ConfirmEmailChange(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var confirmDto dto.ConfirmEmailChangeDto
+	err := json.NewDecoder(r.Body).Decode(&confirmDto)
+	defer r.Body.Close()
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(confirmDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	user, err := h.service.ConfirmEmailChange(ctx, confirmDto.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEmailChangeToken) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrEmailAlreadyExists) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while confirming email change",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, user)
+}
+
+// @Summary Cancel email change
+// @Description Cancel the authenticated user's pending email change
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Email change cancelled"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "No pending email change"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/email [delete]
+func (h *UserHandler) CancelEmailChange(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userId, ok := r.Context().Value("userId").(string)
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "user id not found")
+		return
+	}
+
+	user, err := h.service.CancelEmailChange(ctx, userId)
+	if err != nil {
+		if errors.Is(err, services.ErrNoPendingEmailChange) ||
+			errors.Is(err, services.ErrUserNotFound) {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while cancelling email change",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, user)
+}
+
+// @Summary Set initial password
+// @Description Choose the first password of an invited or imported account using the token from the invitation email
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.CompletePasswordSetupDto true "Setup token and new password"
+// @Success 200 {object} github_com_AhmedHossam777_go-mongo_internal_models.User "Password set"
+// @Failure 400 {object} map[string]string "Bad request - validation error or invalid token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/password/setup [post]
+func (h *UserHandler) CompletePasswordSetup(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var setupDto dto.CompletePasswordSetupDto
+	err := json.NewDecoder(r.Body).Decode(&setupDto)
+	defer r.Body.Close()
+	if err != nil {
+		RespondWithError(
+			w, http.StatusBadRequest,
+			"Error while decoding request body: "+err.Error(),
+		)
+		return
+	}
+
+	validationErr := helpers.ValidateStruct(setupDto)
+	if validationErr != nil {
+		RespondWithValidationErrors(w, validationErr)
+		return
+	}
+
+	user, err := h.service.CompletePasswordSetup(
+		ctx, setupDto.Token, setupDto.Password,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPasswordSetup) {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		RespondWithError(
+			w, http.StatusInternalServerError,
+			"error while setting password",
+		)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, user)
+}
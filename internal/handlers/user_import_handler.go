@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/services"
+)
+
+type UserImportHandler struct {
+	service services.UserImportService
+}
+
+func NewUserImportHandler(
+	service services.UserImportService,
+) *UserImportHandler {
+	return &UserImportHandler{service: service}
+}
+
+// @Summary Import users from CSV
+// @Description Create users in bulk from a CSV with a name,email[,role] header (Admin only). Send the file as the "file" field of a multipart form or as a raw text/csv body
+// @Tags admin
+// @Security BearerAuth
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Param file formData file false "CSV file"
+// @Param dry_run query bool false "Validate only, nothing is written"
+// @Param send_invites query bool false "Email created users a link to set their password"
+// @Success 200 {object} dto.UserImportReport "Per-row import report"
+// @Failure 400 {object} map[string]string "Bad request - missing or malformed file"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 413 {object} map[string]string "File too large"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/import [post]
+func (h *UserImportHandler) ImportUsers(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	maxMegabytes := helpers.GetEnvInt("USER_IMPORT_MAX_MB", 10)
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxMegabytes)<<20)
+	defer r.Body.Close()
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	sendInvites, _ := strconv.ParseBool(r.URL.Query().Get("send_invites"))
+
+	source, err := importSource(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.service.ImportUsers(
+		ctx, source, dto.UserImportOptions{
+			DryRun:      dryRun,
+			SendInvites: sendInvites,
+		},
+	)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			RespondWithError(
+				w, http.StatusRequestEntityTooLarge,
+				"import file exceeds "+strconv.Itoa(maxMegabytes)+"MB",
+			)
+		case errors.Is(err, services.ErrInvalidImportFile):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while importing users, "+err.Error(),
+			)
+		}
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, report)
+}
+
+// @Summary Resend a password setup link
+// @Description Email a user who hasn't chosen a password yet, e.g. one imported without an invitation, a new link to set it. Earlier links stop working (Admin only)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 "Setup link sent"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden - Admin access required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User has already set a password"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/{id}/password-setup [post]
+func (h *UserImportHandler) ResendPasswordSetup(
+	w http.ResponseWriter, r *http.Request,
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	err := h.service.ResendPasswordSetup(ctx, r.PathValue("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidUserID):
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrUserNotFound):
+			RespondWithError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrPasswordAlreadySet):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			RespondWithError(
+				w, http.StatusInternalServerError,
+				"error while sending the password setup link, "+err.Error(),
+			)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// importSource streams the CSV straight from the request, either the "file"
+// part of a multipart form or the raw body, without buffering it
+func importSource(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	multipartReader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := multipartReader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("multipart form has no \"file\" field")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"strings"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+)
+
+// DefaultCurrency is the currency prices fall back to when none is given,
+// read from DEFAULT_CURRENCY. An unsupported value falls back to USD
+func DefaultCurrency() string {
+	code := strings.ToUpper(GetEnvString("DEFAULT_CURRENCY", "USD"))
+	if _, ok := models.Currencies[code]; !ok {
+		return "USD"
+	}
+	return code
+}
@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a list between the document with the sort
+// Value and ID and its neighbour. Sort names the order the position is
+// valid for, so a cursor can't be replayed against a differently sorted list
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+	// Backward continues with the documents before the position instead of
+	// the ones after it
+	Backward bool `json:"b,omitempty"`
+}
+
+// EncodeCursor turns cursor into an opaque token encrypted with a key
+// derived from JWT_SECRET. Clients can neither build or alter one nor read
+// the sort value it holds, which may be a field they aren't shown
+func EncodeCursor(cursor Cursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := cursorCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, payload, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecodeCursor decrypts the token, checks it was issued for the sort order
+// and returns the position it holds
+func DecodeCursor(token string, sort string) (*Cursor, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	aead, err := cursorCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCursor
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	payload, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	err = json.Unmarshal(payload, &cursor)
+	if err != nil || cursor.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// cursorCipher keys AES-GCM with a hash of JWT_SECRET, so cursors don't
+// share a key with tokens and signed links
+func cursorCipher() (cipher.AEAD, error) {
+	secretKey := os.Getenv("JWT_SECRET")
+	if secretKey == "" {
+		return nil, errors.New("JWT_SECRET is not set")
+	}
+
+	key := sha256.Sum256([]byte("cursor:" + secretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
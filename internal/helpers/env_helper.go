@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"os"
+	"strconv"
+)
+
+// GetEnvInt reads an integer environment variable, falling back to the
+// given default when it is unset or malformed
+func GetEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+
+	return parsed
+}
+
+func GetEnvString(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -18,11 +18,15 @@ type JWTClaims struct {
 	UserId string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// TenantId is set for members of an organization and scopes every
+	// request made with the token to it
+	TenantId string `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
 func GenerateToken(
 	userId primitive.ObjectID, email string, role string,
+	tenantId *primitive.ObjectID,
 ) (string, error) {
 	secretKey := os.Getenv("JWT_SECRET")
 	if secretKey == "" {
@@ -51,6 +55,10 @@ func GenerateToken(
 		},
 	}
 
+	if tenantId != nil {
+		claims.TenantId = tenantId.Hex()
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(secretKey))
 	if err != nil {
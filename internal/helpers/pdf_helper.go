@@ -0,0 +1,171 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PDF fonts, both are standard PDF fonts every reader ships with, so
+// nothing has to be embedded
+const (
+	FontRegular = "F1"
+	FontBold    = "F2"
+)
+
+// PDF is a single page PDF document drawn with text, lines and rectangles.
+// Coordinates are in points from the bottom left corner of the page
+type PDF struct {
+	width   float64
+	height  float64
+	title   string
+	content bytes.Buffer
+}
+
+func NewPDF(width, height float64, title string) *PDF {
+	return &PDF{width: width, height: height, title: title}
+}
+
+// TextWidth is how wide text is set in font at size
+func TextWidth(font string, size float64, text string) float64 {
+	widths := helveticaWidths
+	if font == FontBold {
+		widths = helveticaBoldWidths
+	}
+
+	total := 0
+	for _, b := range winAnsi(text) {
+		if b >= 32 && int(b-32) < len(widths) {
+			total += widths[b-32]
+		} else {
+			total += 556
+		}
+	}
+
+	return float64(total) * size / 1000
+}
+
+// CenteredText draws text centered on x, shrinking it until it fits
+// maxWidth
+func (p *PDF) CenteredText(
+	x, y float64, font string, size float64, maxWidth float64, text string,
+) {
+	for size > 6 && TextWidth(font, size, text) > maxWidth {
+		size--
+	}
+
+	left := x - TextWidth(font, size, text)/2
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		font, size, left, y, escapePDFText(winAnsi(text)))
+}
+
+// SetColor sets the RGB color, each component from 0 to 1, of what is
+// drawn next
+func (p *PDF) SetColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f rg %.3f %.3f %.3f RG\n",
+		r, g, b, r, g, b)
+}
+
+func (p *PDF) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, y1, x2, y2)
+}
+
+// Rect strokes the outline of a rectangle
+func (p *PDF) Rect(x, y, width, height, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f %.2f %.2f re S\n",
+		lineWidth, x, y, width, height)
+}
+
+// Bytes lays out the document
+func (p *PDF) Bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /%s 4 0 R /%s 5 0 R >> >> /Contents 6 0 R >>",
+			p.width, p.height, FontRegular, FontBold),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica " +
+			"/Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold " +
+			"/Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream",
+			p.content.Len(), p.content.String()),
+		fmt.Sprintf("<< /Title (%s) /Producer (go-mongo) /CreationDate (D:%s) >>",
+			escapePDFText(winAnsi(p.title)),
+			time.Now().UTC().Format("20060102150405Z")),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out,
+		"trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref)
+
+	return out.Bytes()
+}
+
+// winAnsi encodes text for the standard fonts, characters they don't have
+// become question marks
+func winAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r < 127, r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case r == '–':
+			encoded = append(encoded, 0x96)
+		case r == '—':
+			encoded = append(encoded, 0x97)
+		case r == '’':
+			encoded = append(encoded, 0x92)
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+func escapePDFText(text []byte) string {
+	var escaped strings.Builder
+	for _, b := range text {
+		if b == '(' || b == ')' || b == '\\' {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(b)
+	}
+	return escaped.String()
+}
+
+// helveticaWidths and helveticaBoldWidths are the glyph widths of the
+// printable ASCII characters, in thousandths of the font size
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = []int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// Sign returns a URL safe HMAC-SHA256 signature of payload, used for links
+// that must work without an access token. The key is a hash of JWT_SECRET,
+// so a link signature can't pass for a token signature or the other way
+// round
+func Sign(payload string) (string, error) {
+	secretKey := os.Getenv("JWT_SECRET")
+	if secretKey == "" {
+		return "", errors.New("JWT_SECRET is not set")
+	}
+
+	key := sha256.Sum256([]byte("signed-link:" + secretKey))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func VerifySignature(payload string, signature string) bool {
+	expected, err := Sign(payload)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
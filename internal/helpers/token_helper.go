@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// GenerateRandomToken returns a random hex token for single-use links such
+// as email confirmations
+func GenerateRandomToken() (string, error) {
+	return GenerateRefreshToken()
+}
+
+// HashToken hashes a random token for storage. Unlike bcrypt the result is
+// deterministic, so the token can be looked up by its hash
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AppURL builds an absolute link into the application from APP_BASE_URL
+func AppURL(path string) string {
+	return GetEnvString("APP_BASE_URL", "http://localhost:8080") + path
+}
@@ -2,15 +2,36 @@ package helpers
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/AhmedHossam777/go-mongo/internal/models"
 	"github.com/go-playground/validator/v10"
 )
 
 var validate *validator.Validate
 
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 func init() {
 	validate = validator.New()
+	validate.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
+	validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		_, ok := models.Currencies[fl.Field().String()]
+		return ok
+	})
+}
+
+func supportedCurrencies() string {
+	codes := make([]string, 0, len(models.Currencies))
+	for code := range models.Currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
 }
 
 type ValidationError struct {
@@ -47,6 +68,19 @@ func getErrorMessage(err validator.FieldError) string {
 	case "max":
 		return fmt.Sprintf("%s must be at most %s characters", err.Field(),
 			err.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param())
+	case "timezone":
+		return fmt.Sprintf("%s must be a valid IANA time zone", err.Field())
+	case "bcp47_language_tag":
+		return fmt.Sprintf("%s must be a valid language tag", err.Field())
+	case "slug":
+		return fmt.Sprintf(
+			"%s may only contain lowercase letters, digits and hyphens",
+			err.Field(),
+		)
+	case "currency":
+		return fmt.Sprintf("%s must be one of: %s", err.Field(), supportedCurrencies())
 	default:
 		return fmt.Sprintf("%s is invalid", err.Field())
 	}
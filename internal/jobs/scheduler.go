@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Task is a unit of background work, any returned error is only logged
+type Task func(ctx context.Context) error
+
+// Schedule runs task once right away and then every interval, in its own
+// goroutine, for the lifetime of the process
+func Schedule(name string, interval time.Duration, task Task) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run(name, interval, task)
+			<-ticker.C
+		}
+	}()
+}
+
+func run(name string, timeout time.Duration, task Task) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// A panicking task must not take the whole server down with it
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("job %q panicked: %v", name, r)
+		}
+	}()
+
+	err := task(ctx)
+	if err != nil {
+		log.Printf("job %q failed: %v", name, err)
+	}
+}
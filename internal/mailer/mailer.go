@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+)
+
+type Mailer interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+// NewMailer sends mail through SMTP when SMTP_HOST is configured and only
+// logs messages otherwise, which is enough for local development
+func NewMailer() Mailer {
+	host := helpers.GetEnvString("SMTP_HOST", "")
+	if host == "" {
+		log.Println("Warning: SMTP_HOST is not set, emails will only be logged")
+		return &logMailer{}
+	}
+
+	return &smtpMailer{
+		host:     host,
+		port:     helpers.GetEnvString("SMTP_PORT", "587"),
+		username: helpers.GetEnvString("SMTP_USERNAME", ""),
+		password: helpers.GetEnvString("SMTP_PASSWORD", ""),
+		from:     helpers.GetEnvString("SMTP_FROM", "no-reply@localhost"),
+	}
+}
+
+type smtpMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func (m *smtpMailer) Send(
+	ctx context.Context, to string, subject string, body string,
+) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	err := smtp.SendMail(
+		net.JoinHostPort(m.host, m.port), auth, m.from, []string{to},
+		[]byte(message),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to, err)
+	}
+
+	return nil
+}
+
+type logMailer struct{}
+
+func (m *logMailer) Send(
+	ctx context.Context, to string, subject string, body string,
+) error {
+	log.Printf("email to %s\nSubject: %s\n\n%s", to, subject, body)
+	return nil
+}
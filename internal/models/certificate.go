@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Certificate records that a learner completed a course. The names are
+// copied in when it is issued, so it still verifies after the learner
+// renames themselves or the course goes away
+type Certificate struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Code             string              `json:"code" bson:"code"`
+	UserId           primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	CourseId         primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	TenantId         *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	LearnerName      string              `json:"learner_name" bson:"learner_name"`
+	CourseName       string              `json:"course_name" bson:"course_name"`
+	InstructorName   string              `json:"instructor_name" bson:"instructor_name"`
+	CompletedAt      time.Time           `json:"completed_at" bson:"completed_at"`
+	IssuedAt         time.Time           `json:"issued_at" bson:"issued_at"`
+	RevokedAt        *time.Time          `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	RevokedBy        *primitive.ObjectID `json:"revoked_by,omitempty" bson:"revoked_by,omitempty"`
+	RevocationReason string              `json:"revocation_reason,omitempty" bson:"revocation_reason,omitempty"`
+}
+
+func (c *Certificate) Revoked() bool {
+	return c.RevokedAt != nil
+}
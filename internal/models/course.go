@@ -1,12 +1,85 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CourseStatusDraft     = "draft"
+	CourseStatusPublished = "published"
+	CourseStatusArchived  = "archived"
+)
+
+const (
+	CourseLevelBeginner     = "beginner"
+	CourseLevelIntermediate = "intermediate"
+	CourseLevelAdvanced     = "advanced"
+	CourseLevelAll          = "all"
+)
 
 type Course struct {
-	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"` // If ID is empty, don't include it in JSON/BSON
-	CourseName   string             `json:"course_name" bson:"course_name"`
-	Price        int                `json:"price" bson:"course_price"`
-	InstructorId primitive.ObjectID `json:"instructor_id" bson:"instructor_id"`
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"` // If ID is empty, don't include it in JSON/BSON
+	CourseName  string             `json:"course_name" bson:"course_name"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Tags        []string           `json:"tags,omitempty" bson:"tags,omitempty"`
+	Category    string             `json:"category,omitempty" bson:"category,omitempty"`
+	Level       string             `json:"level,omitempty" bson:"level,omitempty"`
+	// Language picks the stemming rules the text index uses for the course
+	Language string `json:"language,omitempty" bson:"language,omitempty"`
+	Price    Money  `json:"price" bson:"course_price"`
+	// PriceList prices the course in further currencies, each currency at
+	// most once and never the one Price is in
+	PriceList    []Money             `json:"price_list,omitempty" bson:"price_list,omitempty"`
+	InstructorId primitive.ObjectID  `json:"instructor_id" bson:"instructor_id"`
+	TenantId     *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	Status       string              `json:"status" bson:"status"`
+	// Prerequisites are the courses a learner has to complete before
+	// enrolling
+	Prerequisites []primitive.ObjectID `json:"prerequisites,omitempty" bson:"prerequisites,omitempty"`
+	// EnrollmentCount counts active and completed enrollments, kept up to
+	// date as learners enroll and unenroll
+	EnrollmentCount int64 `json:"enrollment_count" bson:"enrollment_count"`
+	// AverageRating and RatingCount summarize the course's reviews, they are
+	// updated together with RatingSum whenever a rating changes
+	AverageRating float64    `json:"average_rating" bson:"average_rating"`
+	RatingCount   int64      `json:"rating_count" bson:"rating_count"`
+	RatingSum     int64      `json:"-" bson:"rating_sum"`
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" bson:"updated_at"`
+	PublishedAt   *time.Time `json:"published_at,omitempty" bson:"published_at,omitempty"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+}
+
+// PriceIn returns what the course costs in currency, from Price or its
+// price list
+func (c *Course) PriceIn(currency string) (Money, bool) {
+	if c.Price.Currency == currency {
+		return c.Price, true
+	}
+	for _, price := range c.PriceList {
+		if price.Currency == currency {
+			return price, true
+		}
+	}
+	return Money{}, false
+}
+
+// courseTransitions lists the statuses a course may move to from each status
+var courseTransitions = map[string][]string{
+	CourseStatusDraft:     {CourseStatusPublished},
+	CourseStatusPublished: {CourseStatusDraft, CourseStatusArchived},
+}
+
+// CanTransitionTo reports whether the course may move to status
+func (c *Course) CanTransitionTo(status string) bool {
+	for _, allowed := range courseTransitions[c.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
 }
 
 //type Author struct {
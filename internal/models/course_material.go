@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CourseMaterial is a file attached to a course, its content lives in the
+// "materials" GridFS bucket
+type CourseMaterial struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	CourseId    primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	TenantId    *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	FileId      primitive.ObjectID  `json:"-" bson:"file_id"`
+	Filename    string              `json:"filename" bson:"filename"`
+	ContentType string              `json:"content_type" bson:"content_type"`
+	Size        int64               `json:"size" bson:"size"`
+	UploadedBy  primitive.ObjectID  `json:"uploaded_by" bson:"uploaded_by"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CourseProgress is how far a learner got through a course's curriculum.
+// It is kept when they unenroll, so coming back picks up where they were
+type CourseProgress struct {
+	ID               primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	UserId           primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	CourseId         primitive.ObjectID   `json:"course_id" bson:"course_id"`
+	TenantId         *primitive.ObjectID  `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	CompletedLessons []primitive.ObjectID `json:"completed_lessons" bson:"completed_lessons"`
+	// LessonCount and CompletionPercent are as of the last activity,
+	// lessons added or removed since then change them on the next one
+	LessonCount       int `json:"lesson_count" bson:"lesson_count"`
+	CompletionPercent int `json:"completion_percent" bson:"completion_percent"`
+	// LastLessonId and LastPositionSeconds are where the learner left off
+	LastLessonId        *primitive.ObjectID `json:"last_lesson_id,omitempty" bson:"last_lesson_id,omitempty"`
+	LastPositionSeconds int                 `json:"last_position_seconds" bson:"last_position_seconds"`
+	TimeSpentSeconds    int64               `json:"time_spent_seconds" bson:"time_spent_seconds"`
+	StartedAt           time.Time           `json:"started_at" bson:"started_at"`
+	UpdatedAt           time.Time           `json:"updated_at" bson:"updated_at"`
+	CompletedAt         *time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// Completion works out how many of the curriculum's lessons are completed,
+// ignoring completed lessons that have since been removed, and what
+// percentage of the course that is. Only a fully completed course is at 100
+func (p *CourseProgress) Completion(curriculum *Curriculum) (int, int) {
+	completed := make(map[primitive.ObjectID]bool, len(p.CompletedLessons))
+	for _, lessonId := range p.CompletedLessons {
+		completed[lessonId] = true
+	}
+
+	done := 0
+	for _, section := range curriculum.Sections {
+		for _, lesson := range section.Lessons {
+			if completed[lesson.ID] {
+				done++
+			}
+		}
+	}
+
+	if curriculum.LessonCount == 0 {
+		return 0, 0
+	}
+	return done, done * 100 / curriculum.LessonCount
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// RevisionActionInitial is what a course looked like before its first
+	// recorded change
+	RevisionActionInitial = "initial"
+	RevisionActionUpdate  = "update"
+	RevisionActionRestore = "restore"
+)
+
+// CourseSnapshot is the part of a course its instructor edits, which is
+// what revisions keep and restore. Status, counts and ratings aren't part
+// of it
+type CourseSnapshot struct {
+	CourseName  string   `json:"course_name" bson:"course_name"`
+	Description string   `json:"description,omitempty" bson:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	Category    string   `json:"category,omitempty" bson:"category,omitempty"`
+	Level       string   `json:"level,omitempty" bson:"level,omitempty"`
+	Language    string   `json:"language,omitempty" bson:"language,omitempty"`
+	Price       Money    `json:"price" bson:"course_price"`
+	PriceList   []Money  `json:"price_list,omitempty" bson:"price_list,omitempty"`
+}
+
+// CourseRevision is a course as it was after a change, numbered from 1 per
+// course
+type CourseRevision struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	CourseId primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	TenantId *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	Revision int64               `json:"revision" bson:"revision"`
+	Action   string              `json:"action" bson:"action"`
+	// RestoredFrom is the revision a restore brought back
+	RestoredFrom *int64             `json:"restored_from,omitempty" bson:"restored_from,omitempty"`
+	AuthorId     primitive.ObjectID `json:"author_id" bson:"author_id"`
+	Snapshot     CourseSnapshot     `json:"snapshot" bson:"snapshot"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+func (c *Course) Snapshot() CourseSnapshot {
+	snapshot := CourseSnapshot{
+		CourseName:  c.CourseName,
+		Description: c.Description,
+		Category:    c.Category,
+		Level:       c.Level,
+		Language:    c.Language,
+		Price:       c.Price,
+	}
+	if len(c.Tags) > 0 {
+		snapshot.Tags = append([]string(nil), c.Tags...)
+	}
+	if len(c.PriceList) > 0 {
+		snapshot.PriceList = append([]Money(nil), c.PriceList...)
+	}
+	return snapshot
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	LessonContentVideo      = "video"
+	LessonContentArticle    = "article"
+	LessonContentQuiz       = "quiz"
+	LessonContentAssignment = "assignment"
+)
+
+// Curriculum is a course's sections and their lessons, kept in a single
+// document so that reordering them is one atomic write
+type Curriculum struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	CourseId primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	TenantId *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	Sections []Section           `json:"sections" bson:"sections"`
+	// LessonCount and DurationSeconds total up every section
+	LessonCount     int `json:"lesson_count" bson:"lesson_count"`
+	DurationSeconds int `json:"duration_seconds" bson:"duration_seconds"`
+	// Version goes up with every write, a write based on an older version
+	// is refused
+	Version   int64     `json:"version" bson:"version"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+type Section struct {
+	ID        primitive.ObjectID `json:"id" bson:"id"`
+	Title     string             `json:"title" bson:"title"`
+	Position  int                `json:"position" bson:"position"`
+	Lessons   []Lesson           `json:"lessons" bson:"lessons"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type Lesson struct {
+	ID              primitive.ObjectID `json:"id" bson:"id"`
+	Title           string             `json:"title" bson:"title"`
+	ContentType     string             `json:"content_type" bson:"content_type"`
+	DurationSeconds int                `json:"duration_seconds" bson:"duration_seconds"`
+	Position        int                `json:"position" bson:"position"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Renumber sets every section's and lesson's position from its place in
+// the curriculum, counting from 1, and recomputes the totals
+func (c *Curriculum) Renumber() {
+	c.LessonCount = 0
+	c.DurationSeconds = 0
+	for i := range c.Sections {
+		section := &c.Sections[i]
+		section.Position = i + 1
+		for j := range section.Lessons {
+			section.Lessons[j].Position = j + 1
+			c.DurationSeconds += section.Lessons[j].DurationSeconds
+		}
+		c.LessonCount += len(section.Lessons)
+	}
+}
+
+// Section returns the section with id, or nil
+func (c *Curriculum) Section(id primitive.ObjectID) *Section {
+	for i := range c.Sections {
+		if c.Sections[i].ID == id {
+			return &c.Sections[i]
+		}
+	}
+	return nil
+}
+
+// Lesson returns the lesson with id, or nil
+func (s *Section) Lesson(id primitive.ObjectID) *Lesson {
+	for i := range s.Lessons {
+		if s.Lessons[i].ID == id {
+			return &s.Lessons[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusCompleted  = "completed"
+	ExportStatusFailed     = "failed"
+)
+
+// DataExport tracks a personal data export job and the archive it produced
+type DataExport struct {
+	ID          primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserId      primitive.ObjectID  `json:"userId" bson:"user_id"`
+	RequestedBy primitive.ObjectID  `json:"requestedBy" bson:"requested_by"`
+	Status      string              `json:"status" bson:"status"`
+	FileId      *primitive.ObjectID `json:"-" bson:"file_id,omitempty"`
+	Error       string              `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt" bson:"created_at"`
+	CompletedAt *time.Time          `json:"completedAt,omitempty" bson:"completed_at,omitempty"`
+	ExpiresAt   *time.Time          `json:"expiresAt,omitempty" bson:"expires_at,omitempty"`
+	// Active is set while the export is pending or processing, a user has
+	// at most one active export
+	Active bool `json:"-" bson:"active,omitempty"`
+	// ClaimedAt is when a worker started building the archive. An export
+	// still processing past its lease is claimed again
+	ClaimedAt *time.Time `json:"-" bson:"claimed_at,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EnrollmentStatusActive    = "active"
+	EnrollmentStatusCompleted = "completed"
+	EnrollmentStatusCancelled = "cancelled"
+)
+
+// Enrollment is a learner's place in a course. There is at most one per
+// user and course; unenrolling cancels it and enrolling again reactivates it
+type Enrollment struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserId      primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	CourseId    primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	TenantId    *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	Status      string              `json:"status" bson:"status"`
+	EnrolledAt  time.Time           `json:"enrolled_at" bson:"enrolled_at"`
+	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CancelledAt *time.Time          `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+}
+
+// Counted reports whether the enrollment counts towards the course's
+// enrollment count
+func (e *Enrollment) Counted() bool {
+	return e.Status != EnrollmentStatusCancelled
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// File mirrors a document in a GridFS "<bucket>.files" collection
+type File struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	Filename   string             `json:"filename" bson:"filename"`
+	Length     int64              `json:"length" bson:"length"`
+	UploadDate time.Time          `json:"uploadDate" bson:"uploadDate"`
+	Metadata   FileMetadata       `json:"metadata" bson:"metadata"`
+}
+
+type FileMetadata struct {
+	ContentType string             `json:"contentType" bson:"content_type"`
+	OwnerId     primitive.ObjectID `json:"ownerId" bson:"owner_id"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ApplicationStatusPending  = "pending"
+	ApplicationStatusApproved = "approved"
+	ApplicationStatusRejected = "rejected"
+)
+
+// InstructorApplication is a user's request for the instructor role, which
+// an admin approves or rejects
+type InstructorApplication struct {
+	ID              primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserId          primitive.ObjectID  `json:"userId" bson:"user_id"`
+	TenantId        *primitive.ObjectID `json:"tenantId,omitempty" bson:"tenant_id,omitempty"`
+	Bio             string              `json:"bio" bson:"bio"`
+	SampleMaterial  []string            `json:"sampleMaterial" bson:"sample_material"`
+	Status          string              `json:"status" bson:"status"`
+	RejectionReason string              `json:"rejectionReason,omitempty" bson:"rejection_reason,omitempty"`
+	ReviewedBy      *primitive.ObjectID `json:"reviewedBy,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time          `json:"reviewedAt,omitempty" bson:"reviewed_at,omitempty"`
+	CreatedAt       time.Time           `json:"createdAt" bson:"created_at"`
+}
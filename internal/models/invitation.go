@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+)
+
+// Invitation lets someone register with a role chosen by whoever invited
+// them. The token is single use and only its hash is stored
+type Invitation struct {
+	ID         primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	Email      string              `json:"email" bson:"email"`
+	Role       string              `json:"role" bson:"role"`
+	TokenHash  string              `json:"-" bson:"token_hash"`
+	Status     string              `json:"status" bson:"status"`
+	InvitedBy  primitive.ObjectID  `json:"invitedBy" bson:"invited_by"`
+	TenantId   *primitive.ObjectID `json:"tenantId,omitempty" bson:"tenant_id,omitempty"`
+	ExpiresAt  time.Time           `json:"expiresAt" bson:"expires_at"`
+	CreatedAt  time.Time           `json:"createdAt" bson:"created_at"`
+	AcceptedAt *time.Time          `json:"acceptedAt,omitempty" bson:"accepted_at,omitempty"`
+	RevokedAt  *time.Time          `json:"revokedAt,omitempty" bson:"revoked_at,omitempty"`
+}
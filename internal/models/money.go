@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency the catalog sells in
+type Currency struct {
+	Code string
+	// MinorUnits is how many digits follow the decimal point, e.g. 2 for
+	// cents
+	MinorUnits int
+	Symbol     string
+}
+
+// Currencies are the currencies prices may be set in, by code
+var Currencies = map[string]Currency{
+	"EGP": {Code: "EGP", MinorUnits: 2, Symbol: "E£"},
+	"EUR": {Code: "EUR", MinorUnits: 2, Symbol: "€"},
+	"USD": {Code: "USD", MinorUnits: 2, Symbol: "$"},
+}
+
+// Money is an amount in the minor units of its currency, 1250 USD is $12.50
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// MinorUnitFactor is how many minor units make up one unit of the currency
+func (c Currency) MinorUnitFactor() int64 {
+	factor := int64(1)
+	for i := 0; i < c.MinorUnits; i++ {
+		factor *= 10
+	}
+	return factor
+}
+
+// Format renders the amount for display with the currency's symbol and
+// number of decimals, e.g. "$1,234.50". Unknown currencies are shown by code
+func (m Money) Format() string {
+	currency, ok := Currencies[m.Currency]
+	if !ok {
+		currency = Currency{Code: m.Currency, Symbol: m.Currency + " "}
+	}
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	factor := currency.MinorUnitFactor()
+	formatted := sign + currency.Symbol + groupThousands(amount/factor)
+	if currency.MinorUnits > 0 {
+		minor := strconv.FormatInt(amount%factor, 10)
+		formatted += "." + strings.Repeat("0", currency.MinorUnits-len(minor)) + minor
+	}
+
+	return formatted
+}
+
+// MarshalJSON adds the display form next to the amount and currency
+func (m Money) MarshalJSON() ([]byte, error) {
+	type money Money
+	return json.Marshal(struct {
+		money
+		Formatted string `json:"formatted"`
+	}{money(m), m.Format()})
+}
+
+func groupThousands(value int64) string {
+	digits := strconv.FormatInt(value, 10)
+
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return grouped.String()
+}
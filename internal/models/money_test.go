@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyFormat(t *testing.T) {
+	formatted := map[string]Money{
+		"$12.00":         {Amount: 1200, Currency: "USD"},
+		"$12.50":         {Amount: 1250, Currency: "USD"},
+		"€12.05":         {Amount: 1205, Currency: "EUR"},
+		"$0.05":          {Amount: 5, Currency: "USD"},
+		"$0.00":          {Amount: 0, Currency: "USD"},
+		"€1,000.00":      {Amount: 100000, Currency: "EUR"},
+		"E£1,234,567.89": {Amount: 123456789, Currency: "EGP"},
+		"-$12.50":        {Amount: -1250, Currency: "USD"},
+		"JPY 1,250":      {Amount: 1250, Currency: "JPY"},
+	}
+
+	for want, money := range formatted {
+		if got := money.Format(); got != want {
+			t.Errorf("%d %s formats as %q, want %q", money.Amount, money.Currency, got, want)
+		}
+	}
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	payload, err := json.Marshal(Money{Amount: 1250, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	want := `{"amount":1250,"currency":"USD","formatted":"$12.50"}`
+	if string(payload) != want {
+		t.Errorf("json = %s, want %s", payload, want)
+	}
+}
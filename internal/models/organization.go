@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization is a tenant, e.g. a school sharing the deployment. Users and
+// courses that belong to it carry its ID as their tenant_id
+type Organization struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Slug      string             `json:"slug" bson:"slug"`
+	CreatedBy primitive.ObjectID `json:"createdBy" bson:"created_by"`
+	CreatedAt time.Time          `json:"createdAt" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updated_at"`
+}
+
+// Membership records a user's role inside an organization. A user belongs
+// to at most one organization and the role is mirrored onto the user so
+// tokens and role checks pick it up
+type Membership struct {
+	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganizationId primitive.ObjectID `json:"organizationId" bson:"organization_id"`
+	UserId         primitive.ObjectID `json:"userId" bson:"user_id"`
+	Role           string             `json:"role" bson:"role"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+)
+
+// Review is a learner's rating of a course, at most one per learner and
+// course
+type Review struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	CourseId primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	UserId   primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	TenantId *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	Rating   int                 `json:"rating" bson:"rating"`
+	Text     string              `json:"text,omitempty" bson:"text,omitempty"`
+	// HelpfulCount counts the learners who found the review helpful
+	HelpfulCount int64        `json:"helpful_count" bson:"helpful_count"`
+	Reply        *ReviewReply `json:"reply,omitempty" bson:"reply,omitempty"`
+	CreatedAt    time.Time    `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" bson:"updated_at"`
+}
+
+// ReviewReply is the course instructor's answer to a review
+type ReviewReply struct {
+	AuthorId  primitive.ObjectID `json:"author_id" bson:"author_id"`
+	Text      string             `json:"text" bson:"text"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ReviewVote records that a learner found a review helpful
+type ReviewVote struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ReviewId  primitive.ObjectID  `json:"review_id" bson:"review_id"`
+	CourseId  primitive.ObjectID  `json:"course_id" bson:"course_id"`
+	UserId    primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	TenantId  *primitive.ObjectID `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
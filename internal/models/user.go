@@ -7,13 +7,79 @@ import (
 )
 
 type User struct {
-	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name      string             `json:"name" bson:"name"`
-	Email     string             `json:"email" bson:"email"`
-	Password  string             `json:"-" bson:"password"`
-	Role      string             `json:"role" bson:"role"`
-	CreatedAt time.Time          `json:"createdAt" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updated_at"`
+	ID       primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	Name     string              `json:"name" bson:"name"`
+	Email    string              `json:"email" bson:"email"`
+	Password string              `json:"-" bson:"password"`
+	Role     string              `json:"role" bson:"role"`
+	TenantId *primitive.ObjectID `json:"tenantId,omitempty" bson:"tenant_id,omitempty"`
+	AvatarId *primitive.ObjectID `json:"avatarId,omitempty" bson:"avatar_id,omitempty"`
+	Bio      string              `json:"bio,omitempty" bson:"bio,omitempty"`
+	Headline string              `json:"headline,omitempty" bson:"headline,omitempty"`
+	Timezone string              `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Language string              `json:"language,omitempty" bson:"language,omitempty"`
+	Privacy  *PrivacySettings    `json:"privacy,omitempty" bson:"privacy,omitempty"`
+	// PendingEmail holds an email change waiting for confirmation from the
+	// new address
+	PendingEmail *PendingEmailChange `json:"pendingEmail,omitempty" bson:"pending_email,omitempty"`
+	// PasswordSetup is set for accounts created without a password, e.g. by
+	// a bulk import, until the user picks one through the emailed link
+	PasswordSetup *PasswordSetup `json:"-" bson:"password_setup,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt" bson:"created_at"`
+	UpdatedAt     time.Time      `json:"updatedAt" bson:"updated_at"`
+	DeletedAt     *time.Time     `json:"deletedAt,omitempty" bson:"deleted_at,omitempty"`
+	// DeletionScheduledAt is set when the user asked to delete their account
+	// and holds the moment the deletion runs unless they log in before then
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt,omitempty" bson:"deletion_scheduled_at,omitempty"`
+	// PurgeBlockedAt is when the purge job first found the deleted user
+	// still owning courses, so it reports them only once
+	PurgeBlockedAt *time.Time `json:"-" bson:"purge_blocked_at,omitempty"`
+}
+
+type PendingEmailChange struct {
+	Email       string    `json:"email" bson:"email"`
+	TokenHash   string    `json:"-" bson:"token_hash"`
+	RequestedAt time.Time `json:"requestedAt" bson:"requested_at"`
+	ExpiresAt   time.Time `json:"expiresAt" bson:"expires_at"`
+}
+
+type PasswordSetup struct {
+	TokenHash string    `bson:"token_hash"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// PrivacySettings controls which profile fields are visible to anyone other
+// than the user themselves and admins
+type PrivacySettings struct {
+	ShowEmail    bool `json:"showEmail" bson:"show_email"`
+	ShowAvatar   bool `json:"showAvatar" bson:"show_avatar"`
+	ShowBio      bool `json:"showBio" bson:"show_bio"`
+	ShowHeadline bool `json:"showHeadline" bson:"show_headline"`
+	ShowJoinDate bool `json:"showJoinDate" bson:"show_join_date"`
+}
+
+// DefaultPrivacySettings applies to users who never changed their settings,
+// everything but the email address is public
+func DefaultPrivacySettings() PrivacySettings {
+	return PrivacySettings{
+		ShowEmail:    false,
+		ShowAvatar:   true,
+		ShowBio:      true,
+		ShowHeadline: true,
+		ShowJoinDate: true,
+	}
+}
+
+// PublicProfile is the projection of a user shown to other users and to
+// anonymous callers
+type PublicProfile struct {
+	ID        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email,omitempty"`
+	AvatarURL string             `json:"avatarUrl,omitempty"`
+	Bio       string             `json:"bio,omitempty"`
+	Headline  string             `json:"headline,omitempty"`
+	CreatedAt *time.Time         `json:"createdAt,omitempty"`
 }
 
 type UserResponse struct {
@@ -49,3 +115,37 @@ func (u *User) ToResponse() UserResponse {
 		CreatedAt: u.CreatedAt,
 	}
 }
+
+func (u *User) EffectivePrivacy() PrivacySettings {
+	if u.Privacy == nil {
+		return DefaultPrivacySettings()
+	}
+	return *u.Privacy
+}
+
+func (u *User) ToPublicProfile() PublicProfile {
+	privacy := u.EffectivePrivacy()
+	profile := PublicProfile{
+		ID:   u.ID,
+		Name: u.Name,
+	}
+
+	if privacy.ShowEmail {
+		profile.Email = u.Email
+	}
+	if privacy.ShowAvatar && u.AvatarId != nil {
+		profile.AvatarURL = "/api/v1/users/" + u.ID.Hex() + "/avatar"
+	}
+	if privacy.ShowBio {
+		profile.Bio = u.Bio
+	}
+	if privacy.ShowHeadline {
+		profile.Headline = u.Headline
+	}
+	if privacy.ShowJoinDate {
+		createdAt := u.CreatedAt
+		profile.CreatedAt = &createdAt
+	}
+
+	return profile
+}
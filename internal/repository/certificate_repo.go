@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CertificateRepository interface {
+	// Create inserts a certificate, a duplicate key error means the learner
+	// already has one for the course or, very rarely, the code is taken
+	Create(ctx context.Context, certificate *models.Certificate) (
+		*models.Certificate, error,
+	)
+	FindByCode(ctx context.Context, code string) (*models.Certificate, error)
+	FindOne(
+		ctx context.Context, userId primitive.ObjectID,
+		courseId primitive.ObjectID,
+	) (*models.Certificate, error)
+	FindByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.Certificate, error,
+	)
+	// Revoke revokes a certificate that isn't revoked yet, returning
+	// mongo.ErrNoDocuments otherwise
+	Revoke(
+		ctx context.Context, code string, revokedBy primitive.ObjectID,
+		reason string,
+	) (*models.Certificate, error)
+	DeleteByUser(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type certificateRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewCertificateRepo(db *mongo.Database) CertificateRepository {
+	return &certificateRepository{
+		collection: db.Collection("certificates"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *certificateRepository) Create(
+	ctx context.Context, certificate *models.Certificate,
+) (*models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	certificate.ID = primitive.NewObjectID()
+	if certificate.TenantId == nil {
+		certificate.TenantId = tenantOf(ctx)
+	}
+
+	_, err := r.collection.InsertOne(ctx, certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return certificate, nil
+}
+
+// FindByCode looks a certificate up across tenants, codes are unique
+// everywhere and verifying one needs no account
+func (r *certificateRepository) FindByCode(
+	ctx context.Context, code string,
+) (*models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var certificate models.Certificate
+	err := r.collection.FindOne(ctx, bson.M{"code": code}).Decode(&certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
+
+func (r *certificateRepository) FindOne(
+	ctx context.Context, userId primitive.ObjectID, courseId primitive.ObjectID,
+) (*models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var certificate models.Certificate
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"user_id": userId, "course_id": courseId}),
+	).Decode(&certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
+
+// FindByUser lists the learner's certificates, most recent first
+func (r *certificateRepository) FindByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, inTenant(ctx, bson.M{"user_id": userId}),
+		options.Find().SetSort(bson.D{{Key: "issued_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var certificates []models.Certificate
+	err = cursor.All(ctx, &certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	if certificates == nil {
+		certificates = []models.Certificate{}
+	}
+
+	return certificates, nil
+}
+
+func (r *certificateRepository) Revoke(
+	ctx context.Context, code string, revokedBy primitive.ObjectID,
+	reason string,
+) (*models.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{
+		"code": code, "revoked_at": bson.M{"$exists": false},
+	})
+	update := bson.M{"$set": bson.M{
+		"revoked_at":        time.Now(),
+		"revoked_by":        revokedBy,
+		"revocation_reason": reason,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var certificate models.Certificate
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
+
+func (r *certificateRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"user_id": userId}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every certificate, or only the tenant's when ctx is scoped to
+// one
+func (r *certificateRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
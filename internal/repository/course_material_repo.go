@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CourseMaterialRepository interface {
+	Create(ctx context.Context, material *models.CourseMaterial) (
+		*models.CourseMaterial, error,
+	)
+	FindOne(
+		ctx context.Context, courseId primitive.ObjectID, id primitive.ObjectID,
+	) (*models.CourseMaterial, error)
+	FindByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) ([]models.CourseMaterial, error)
+	FindAll(ctx context.Context) ([]models.CourseMaterial, error)
+	// UsedBytes is the size of all of a course's materials together
+	UsedBytes(ctx context.Context, courseId primitive.ObjectID) (int64, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type courseMaterialRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewCourseMaterialRepo(db *mongo.Database) CourseMaterialRepository {
+	return &courseMaterialRepository{
+		collection: db.Collection("course_materials"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *courseMaterialRepository) Create(
+	ctx context.Context, material *models.CourseMaterial,
+) (*models.CourseMaterial, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	material.ID = primitive.NewObjectID()
+	if material.TenantId == nil {
+		material.TenantId = tenantOf(ctx)
+	}
+
+	_, err := r.collection.InsertOne(ctx, material)
+	if err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+func (r *courseMaterialRepository) FindOne(
+	ctx context.Context, courseId primitive.ObjectID, id primitive.ObjectID,
+) (*models.CourseMaterial, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var material models.CourseMaterial
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"_id": id, "course_id": courseId}),
+	).Decode(&material)
+	if err != nil {
+		return nil, err
+	}
+
+	return &material, nil
+}
+
+// FindByCourses lists the courses' materials, oldest first
+func (r *courseMaterialRepository) FindByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) ([]models.CourseMaterial, error) {
+	return r.find(ctx, bson.M{"course_id": bson.M{"$in": courseIds}})
+}
+
+// FindAll lists every material, or the tenant's when ctx is scoped to one
+func (r *courseMaterialRepository) FindAll(
+	ctx context.Context,
+) ([]models.CourseMaterial, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *courseMaterialRepository) find(
+	ctx context.Context, filter bson.M,
+) ([]models.CourseMaterial, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, inTenant(ctx, filter),
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var materials []models.CourseMaterial
+	err = cursor.All(ctx, &materials)
+	if err != nil {
+		return nil, err
+	}
+
+	if materials == nil {
+		materials = []models.CourseMaterial{}
+	}
+
+	return materials, nil
+}
+
+func (r *courseMaterialRepository) UsedBytes(
+	ctx context.Context, courseId primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: inTenant(ctx, bson.M{"course_id": courseId})}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil, "size": bson.M{"$sum": "$size"},
+		}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Size int64 `bson:"size"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil || len(results) == 0 {
+		return 0, err
+	}
+
+	return results[0].Size, nil
+}
+
+func (r *courseMaterialRepository) Delete(
+	ctx context.Context, id primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteOne(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if deleteResult.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *courseMaterialRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"course_id": bson.M{"$in": courseIds}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every material, or only the tenant's when ctx is scoped to one
+func (r *courseMaterialRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProgressActivity is what a learner did in a lesson
+type ProgressActivity struct {
+	LessonId primitive.ObjectID
+	// PositionSeconds is where they stopped, nil leaves it as it was
+	PositionSeconds *int
+	// TimeSpentSeconds is added to the time spent in the course
+	TimeSpentSeconds int
+	// Completed marks the lesson complete or not, nil leaves it as it was
+	Completed *bool
+}
+
+// ProgressSummary totals up the progress of a course's learners
+type ProgressSummary struct {
+	Started               int64   `bson:"started"`
+	AverageCompletion     float64 `bson:"average_completion"`
+	TotalTimeSpentSeconds int64   `bson:"total_time_spent_seconds"`
+}
+
+type CourseProgressRepository interface {
+	FindOne(
+		ctx context.Context, userId primitive.ObjectID,
+		courseId primitive.ObjectID,
+	) (*models.CourseProgress, error)
+	// Record applies activity to the learner's progress, creating it on
+	// their first activity in the course
+	Record(
+		ctx context.Context, userId primitive.ObjectID, course *models.Course,
+		activity ProgressActivity,
+	) (*models.CourseProgress, error)
+	SetCompletion(
+		ctx context.Context, id primitive.ObjectID, lessonCount int, percent int,
+		completedAt *time.Time,
+	) (*models.CourseProgress, error)
+	FindByCourseUsers(
+		ctx context.Context, courseId primitive.ObjectID,
+		userIds []primitive.ObjectID,
+	) ([]models.CourseProgress, error)
+	// FindUnfinishedByUser lists the courses the user is part way through,
+	// most recently active first
+	FindUnfinishedByUser(
+		ctx context.Context, userId primitive.ObjectID, limit int64,
+	) ([]models.CourseProgress, error)
+	FindAllByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.CourseProgress, error,
+	)
+	Summarize(ctx context.Context, courseId primitive.ObjectID) (
+		*ProgressSummary, error,
+	)
+	DeleteByUser(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type courseProgressRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewCourseProgressRepo(db *mongo.Database) CourseProgressRepository {
+	return &courseProgressRepository{
+		collection: db.Collection("course_progress"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *courseProgressRepository) FindOne(
+	ctx context.Context, userId primitive.ObjectID, courseId primitive.ObjectID,
+) (*models.CourseProgress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var progress models.CourseProgress
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"user_id": userId, "course_id": courseId}),
+	).Decode(&progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+func (r *courseProgressRepository) Record(
+	ctx context.Context, userId primitive.ObjectID, course *models.Course,
+	activity ProgressActivity,
+) (*models.CourseProgress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"last_lesson_id": activity.LessonId, "updated_at": now}
+	if activity.PositionSeconds != nil {
+		set["last_position_seconds"] = *activity.PositionSeconds
+	}
+
+	// An inserted document takes user, course and tenant from the filter,
+	// or the course's tenant when the filter isn't scoped to one
+	setOnInsert := bson.M{"started_at": now}
+
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": setOnInsert,
+		"$inc":         bson.M{"time_spent_seconds": activity.TimeSpentSeconds},
+	}
+
+	switch {
+	case activity.Completed == nil:
+		setOnInsert["completed_lessons"] = []primitive.ObjectID{}
+	case *activity.Completed:
+		update["$addToSet"] = bson.M{"completed_lessons": activity.LessonId}
+	default:
+		update["$pull"] = bson.M{"completed_lessons": activity.LessonId}
+	}
+
+	filter := inTenant(ctx, bson.M{"user_id": userId, "course_id": course.ID})
+	if _, scoped := filter["tenant_id"]; !scoped && course.TenantId != nil {
+		setOnInsert["tenant_id"] = course.TenantId
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var progress models.CourseProgress
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&progress)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first activities raced, the loser's retry finds the document
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+			Decode(&progress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+func (r *courseProgressRepository) SetCompletion(
+	ctx context.Context, id primitive.ObjectID, lessonCount int, percent int,
+	completedAt *time.Time,
+) (*models.CourseProgress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	set := bson.M{"lesson_count": lessonCount, "completion_percent": percent}
+	update := bson.M{"$set": set}
+	if completedAt != nil {
+		set["completed_at"] = completedAt
+	} else {
+		update["$unset"] = bson.M{"completed_at": ""}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var progress models.CourseProgress
+	err := r.collection.FindOneAndUpdate(
+		ctx, inTenant(ctx, bson.M{"_id": id}), update, opts,
+	).Decode(&progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+func (r *courseProgressRepository) FindByCourseUsers(
+	ctx context.Context, courseId primitive.ObjectID,
+	userIds []primitive.ObjectID,
+) ([]models.CourseProgress, error) {
+	return r.find(ctx, bson.M{
+		"course_id": courseId, "user_id": bson.M{"$in": userIds},
+	}, options.Find())
+}
+
+func (r *courseProgressRepository) FindUnfinishedByUser(
+	ctx context.Context, userId primitive.ObjectID, limit int64,
+) ([]models.CourseProgress, error) {
+	return r.find(ctx, bson.M{
+		"user_id": userId, "completion_percent": bson.M{"$lt": 100},
+	}, options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetLimit(limit))
+}
+
+func (r *courseProgressRepository) FindAllByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.CourseProgress, error) {
+	return r.find(ctx, bson.M{"user_id": userId}, options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}))
+}
+
+func (r *courseProgressRepository) find(
+	ctx context.Context, filter bson.M, opts *options.FindOptions,
+) ([]models.CourseProgress, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, inTenant(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var progress []models.CourseProgress
+	err = cursor.All(ctx, &progress)
+	if err != nil {
+		return nil, err
+	}
+
+	if progress == nil {
+		progress = []models.CourseProgress{}
+	}
+
+	return progress, nil
+}
+
+func (r *courseProgressRepository) Summarize(
+	ctx context.Context, courseId primitive.ObjectID,
+) (*ProgressSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: inTenant(ctx, bson.M{"course_id": courseId})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                      nil,
+			"started":                  bson.M{"$sum": 1},
+			"average_completion":       bson.M{"$avg": "$completion_percent"},
+			"total_time_spent_seconds": bson.M{"$sum": "$time_spent_seconds"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []ProgressSummary
+	err = cursor.All(ctx, &summaries)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(summaries) == 0 {
+		return &ProgressSummary{}, nil
+	}
+	return &summaries[0], nil
+}
+
+func (r *courseProgressRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"user_id": userId}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+func (r *courseProgressRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"course_id": bson.M{"$in": courseIds}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every progress record, or only the tenant's when ctx is
+// scoped to one
+func (r *courseProgressRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
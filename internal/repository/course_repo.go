@@ -2,7 +2,8 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/models"
@@ -12,11 +13,77 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// CourseFilter decides which courses FindAll returns. By default only
+// published courses are listed
+type CourseFilter struct {
+	// AllStatuses lists courses in every status, for admins
+	AllStatuses bool
+	// OwnedBy also lists this instructor's courses that aren't published
+	OwnedBy *primitive.ObjectID
+
+	// Keyset pages from a position in the list instead of skipping
+	Keyset *Keyset
+
+	// The catalog filters below narrow the list down further, zero values
+	// mean "no restriction". Prices are inclusive and in the minor units of
+	// Currency, courses match on their price or their price list entry in it
+	MinPrice     *int
+	MaxPrice     *int
+	Currency     string
+	InstructorId *primitive.ObjectID
+	Category     string
+	Level        string
+	Tag          string
+}
+
+// FacetCount is how many courses share a facet value
+type FacetCount struct {
+	Value string `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+// PriceRangeCount is how many courses cost between Min and Max, Max is nil
+// for the open-ended top range. Both are in minor units
+type PriceRangeCount struct {
+	Min   int
+	Max   *int
+	Count int64
+}
+
+// CourseFacets counts the courses matched by FindAll per facet value
+type CourseFacets struct {
+	PriceRanges []PriceRangeCount
+	Instructors []FacetCount
+	Categories  []FacetCount
+	Levels      []FacetCount
+	Tags        []FacetCount
+}
+
+// coursePriceBoundaries are the lower bounds of the price range facet in
+// whole units of the currency, the last range has no upper bound
+var coursePriceBoundaries = []int{0, 25, 50, 100, 200, 500}
+
+// courseFacetLimit caps how many values the open-ended facets return, the
+// most common ones first
+const courseFacetLimit = 20
+
+// CourseSearchHit is a course matched by Search with its text score
+type CourseSearchHit struct {
+	models.Course `bson:",inline"`
+	Score         float64 `bson:"score"`
+}
+
 type CourseRepository interface {
 	Create(ctx context.Context, course *models.Course) (*models.Course, error)
-	FindAll(ctx context.Context, page int64, pageSize int64) (
-		[]models.Course, int, error,
+	FindAll(
+		ctx context.Context, filter CourseFilter, page int64, pageSize int64,
+	) (
+		[]models.Course, int, *CourseFacets, error,
 	)
+	Search(
+		ctx context.Context, text string, language string, page int64,
+		pageSize int64,
+	) ([]CourseSearchHit, int64, error)
 	FindOne(ctx context.Context, courseId primitive.ObjectID) (
 		*models.Course, error,
 	)
@@ -25,7 +92,51 @@ type CourseRepository interface {
 	) (
 		*models.Course, error,
 	)
+	Transition(
+		ctx context.Context, courseId primitive.ObjectID, from string,
+		update bson.M,
+	) (*models.Course, error)
 	DeleteOne(ctx context.Context, courseId primitive.ObjectID) error
+	FindByIds(ctx context.Context, courseIds []primitive.ObjectID) (
+		[]models.Course, error,
+	)
+	// AdjustEnrollmentCount moves the course's enrollment count by delta
+	AdjustEnrollmentCount(
+		ctx context.Context, courseId primitive.ObjectID, delta int64,
+	) error
+	// AdjustRating adds sumDelta to the course's rating sum and countDelta
+	// to its rating count, recomputing the average in the same update
+	AdjustRating(
+		ctx context.Context, courseId primitive.ObjectID, sumDelta int64,
+		countDelta int64,
+	) error
+	// AddPrerequisite adds a prerequisite to a course that has fewer than
+	// limit, returning mongo.ErrNoDocuments when it is full
+	AddPrerequisite(
+		ctx context.Context, courseId primitive.ObjectID,
+		prerequisiteId primitive.ObjectID, limit int,
+	) (*models.Course, error)
+	RemovePrerequisite(
+		ctx context.Context, courseId primitive.ObjectID,
+		prerequisiteId primitive.ObjectID,
+	) (*models.Course, error)
+	// PullPrerequisites removes deleted courses from every course requiring
+	// them
+	PullPrerequisites(
+		ctx context.Context, prerequisiteIds []primitive.ObjectID,
+	) (int64, error)
+	FindByInstructor(
+		ctx context.Context, instructorId primitive.ObjectID,
+	) ([]models.Course, error)
+	CountByInstructor(
+		ctx context.Context, instructorId primitive.ObjectID,
+	) (int64, error)
+	DeleteByInstructor(
+		ctx context.Context, instructorId primitive.ObjectID,
+	) (int64, error)
+	ReassignInstructor(
+		ctx context.Context, from primitive.ObjectID, to primitive.ObjectID,
+	) (int64, error)
 	Drop(ctx context.Context) error
 }
 
@@ -48,6 +159,9 @@ func (r *courseRepository) Create(
 	defer cancel()
 
 	course.ID = primitive.NewObjectID()
+	if course.TenantId == nil {
+		course.TenantId = tenantOf(ctx)
+	}
 
 	_, err := r.collection.InsertOne(ctx, course)
 	if err != nil {
@@ -57,46 +171,323 @@ func (r *courseRepository) Create(
 	return course, nil
 }
 
+// FindAll lists the courses matching filter, newest first, together with
+// the facet counts. Each facet counts the courses matching every filter but
+// its own, so picking a category still shows the other categories. The
+// page, the total and the facets come from a single aggregation. Like
+// GetAllUsers it returns up to pageSize+1 courses, the extra one telling
+// whether another page follows
 func (r *courseRepository) FindAll(
-	ctx context.Context, page int64, pageSize int64,
+	ctx context.Context, filter CourseFilter, page int64, pageSize int64,
 ) (
-	[]models.Course, int, error,
+	[]models.Course, int, *CourseFacets, error,
 ) {
 
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	skip := (page - 1) * pageSize
+	// Sort by newest first, _id breaks ties between courses created together
+	coursesPage := catalogStages(filter)
+	if filter.Keyset != nil {
+		coursesPage = append(coursesPage, bson.M{
+			"$match": keysetCondition("created_at", true, filter.Keyset),
+		})
+	} else {
+		coursesPage = append(coursesPage, bson.M{"$skip": (page - 1) * pageSize})
+	}
+	coursesPage = append(coursesPage, bson.M{"$limit": pageSize + 1})
+
+	withoutPrice := filter
+	withoutPrice.MinPrice, withoutPrice.MaxPrice = nil, nil
+	withoutInstructor := filter
+	withoutInstructor.InstructorId = nil
+	withoutCategory := filter
+	withoutCategory.Category = ""
+	withoutLevel := filter
+	withoutLevel.Level = ""
+	withoutTag := filter
+	withoutTag.Tag = ""
+
+	// $facet sub-pipelines can't use indexes, so the visibility rules every
+	// facet shares are matched and sorted before it. The catalog filters
+	// differ per facet and are matched inside each sub-pipeline
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: courseVisibilityQuery(ctx, filter)}},
+		{{Key: "$sort", Value: keysetSort("created_at", true, filter.Keyset)}},
+		{{Key: "$facet", Value: bson.M{
+			"courses": coursesPage,
+			"total":   catalogStages(filter, bson.M{"$count": "count"}),
+			"price_ranges": catalogStages(withoutPrice,
+				bson.M{"$bucket": bson.M{
+					"groupBy":    priceInCurrency(filter.Currency),
+					"boundaries": priceBucketBoundaries(filter.Currency),
+					"default":    "other",
+				}},
+			),
+			"instructors": catalogStages(withoutInstructor, facetCounts(
+				bson.M{"$toString": "$instructor_id"}, courseFacetLimit,
+			)...),
+			"categories": catalogStages(withoutCategory, append(
+				bson.A{bson.M{"$match": bson.M{"category": bson.M{"$exists": true}}}},
+				facetCounts("$category", courseFacetLimit)...,
+			)...),
+			"levels": catalogStages(withoutLevel, append(
+				bson.A{bson.M{"$match": bson.M{"level": bson.M{"$exists": true}}}},
+				facetCounts("$level", 0)...,
+			)...),
+			"tags": catalogStages(withoutTag, append(
+				bson.A{bson.M{"$unwind": "$tags"}},
+				facetCounts("$tags", courseFacetLimit)...,
+			)...),
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Courses []models.Course `bson:"courses"`
+		Total   []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		PriceRanges []struct {
+			Min   interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:"price_ranges"`
+		Instructors []FacetCount `bson:"instructors"`
+		Categories  []FacetCount `bson:"categories"`
+		Levels      []FacetCount `bson:"levels"`
+		Tags        []FacetCount `bson:"tags"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	// $facet always yields exactly one document
+	result := results[0]
+
+	// if courses table is empty return empty slice not nil
+	courses := result.Courses
+	if courses == nil {
+		courses = []models.Course{}
+	}
+	reverseBackward(courses, filter.Keyset)
+
+	totalCount := 0
+	if len(result.Total) > 0 {
+		totalCount = result.Total[0].Count
+	}
+
+	facets := &CourseFacets{
+		PriceRanges: []PriceRangeCount{},
+		Instructors: nonNilFacets(result.Instructors),
+		Categories:  nonNilFacets(result.Categories),
+		Levels:      nonNilFacets(result.Levels),
+		Tags:        nonNilFacets(result.Tags),
+	}
+	for _, bucket := range result.PriceRanges {
+		// Prices are never negative, so only courses without a price in
+		// the currency land in the default bucket
+		lower, ok := bucket.Min.(int64)
+		if !ok {
+			continue
+		}
+		facets.PriceRanges = append(facets.PriceRanges, priceRange(
+			filter.Currency, int(lower), bucket.Count,
+		))
+	}
+
+	return courses, totalCount, facets, nil
+}
+
+// courseVisibilityQuery matches the courses filter lets the caller see
+func courseVisibilityQuery(ctx context.Context, filter CourseFilter) bson.M {
+	query := inTenant(ctx, bson.M{})
+	if !filter.AllStatuses {
+		if filter.OwnedBy != nil {
+			query["$or"] = bson.A{
+				bson.M{"status": models.CourseStatusPublished},
+				bson.M{"instructor_id": *filter.OwnedBy},
+			}
+		} else {
+			query["status"] = models.CourseStatusPublished
+		}
+	}
+
+	return query
+}
+
+// catalogStages narrows the courses down by filter's catalog filters, when
+// any are set, and runs stages on what's left
+func catalogStages(filter CourseFilter, stages ...interface{}) bson.A {
+	query := catalogQuery(filter)
+	if len(query) == 0 {
+		return append(bson.A{}, stages...)
+	}
+	return append(bson.A{bson.M{"$match": query}}, stages...)
+}
+
+func catalogQuery(filter CourseFilter) bson.M {
+	query := bson.M{}
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		amount := bson.M{}
+		if filter.MinPrice != nil {
+			amount["$gte"] = *filter.MinPrice
+		}
+		if filter.MaxPrice != nil {
+			amount["$lte"] = *filter.MaxPrice
+		}
+		query["$or"] = bson.A{
+			bson.M{
+				"course_price.currency": filter.Currency,
+				"course_price.amount":   amount,
+			},
+			bson.M{"price_list": bson.M{"$elemMatch": bson.M{
+				"currency": filter.Currency,
+				"amount":   amount,
+			}}},
+		}
+	}
+	if filter.InstructorId != nil {
+		query["instructor_id"] = *filter.InstructorId
+	}
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+	if filter.Level != "" {
+		query["level"] = filter.Level
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
 
+	return query
+}
+
+// facetCounts groups the documents by value and counts them, most common
+// first. A limit of 0 returns every value
+func facetCounts(value interface{}, limit int) bson.A {
+	stages := bson.A{
+		bson.M{"$group": bson.M{"_id": value, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{
+			{Key: "count", Value: -1},
+			{Key: "_id", Value: 1},
+		}},
+	}
+	if limit > 0 {
+		stages = append(stages, bson.M{"$limit": limit})
+	}
+	return stages
+}
+
+// priceInCurrency is the course's price amount in currency, taken from its
+// price or else its price list. Courses not priced in currency get none
+func priceInCurrency(currency string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$course_price.currency", currency}},
+		"$course_price.amount",
+		bson.M{"$arrayElemAt": bson.A{
+			bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$price_list", bson.A{}}},
+					"cond":  bson.M{"$eq": bson.A{"$$this.currency", currency}},
+				}},
+				"in": "$$this.amount",
+			}},
+			0,
+		}},
+	}}
+}
+
+// priceBucketBoundaries converts the price ranges to minor units of the
+// currency and closes the last one with the largest possible price, $bucket
+// needs an upper bound for every range
+func priceBucketBoundaries(currency string) bson.A {
+	factor := minorUnitFactor(currency)
+	boundaries := make(bson.A, 0, len(coursePriceBoundaries)+1)
+	for _, boundary := range coursePriceBoundaries {
+		boundaries = append(boundaries, int64(boundary)*factor)
+	}
+	return append(boundaries, int64(math.MaxInt64))
+}
+
+// priceRange looks up the upper bound of the range starting at lower, both
+// in minor units of the currency
+func priceRange(currency string, lower int, count int64) PriceRangeCount {
+	factor := int(minorUnitFactor(currency))
+	priceRange := PriceRangeCount{Min: lower, Count: count}
+	for i, boundary := range coursePriceBoundaries {
+		if boundary*factor == lower && i+1 < len(coursePriceBoundaries) {
+			upper := coursePriceBoundaries[i+1] * factor
+			priceRange.Max = &upper
+		}
+	}
+	return priceRange
+}
+
+func minorUnitFactor(currency string) int64 {
+	return models.Currencies[currency].MinorUnitFactor()
+}
+
+func nonNilFacets(counts []FacetCount) []FacetCount {
+	if counts == nil {
+		return []FacetCount{}
+	}
+	return counts
+}
+
+// Search runs a full-text search over published courses, best matches
+// first. language selects the stemming applied to the search terms and
+// defaults to the index language
+func (r *courseRepository) Search(
+	ctx context.Context, text string, language string, page int64,
+	pageSize int64,
+) ([]CourseSearchHit, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	textQuery := bson.M{"$search": text}
+	if language != "" {
+		textQuery["$language"] = language
+	}
+	filter := inTenant(ctx, bson.M{
+		"$text":  textQuery,
+		"status": models.CourseStatusPublished,
+	})
+
+	score := bson.M{"$meta": "textScore"}
 	findOptions := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: -1}}). // Sort by newest first
-		SetSkip(skip).
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip((page - 1) * pageSize).
 		SetLimit(pageSize)
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
-	defer cursor.Close(ctx)
-
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
 		return nil, 0, err
 	}
+	defer cursor.Close(ctx)
 
-	var courses []models.Course
-	err = cursor.All(ctx, &courses)
+	var hits []CourseSearchHit
+	err = cursor.All(ctx, &hits)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// if courses table is empty return empty slice not nil
-	if courses == nil {
-		courses = []models.Course{}
+	if hits == nil {
+		hits = []CourseSearchHit{}
 	}
 
-	totalCount, err := r.collection.CountDocuments(ctx, bson.M{})
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return courses, int(totalCount), nil
+	return hits, totalCount, nil
 }
 
 func (r *courseRepository) FindOne(
@@ -107,7 +498,9 @@ func (r *courseRepository) FindOne(
 
 	var course *models.Course
 
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&course)
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+	).Decode(&course)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +515,7 @@ func (r *courseRepository) UpdateOne(
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	filter := bson.M{"_id": id}
+	filter := inTenant(ctx, bson.M{"_id": id})
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updatedCourse models.Course
 
@@ -130,22 +523,42 @@ func (r *courseRepository) UpdateOne(
 		opts).Decode(&updatedCourse)
 
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("course not found")
-		}
 		return nil, err
 	}
 
 	return &updatedCourse, nil
 }
 
+// Transition applies update only while the course is still in the from
+// status, so concurrent status changes can't both succeed
+func (r *courseRepository) Transition(
+	ctx context.Context, id primitive.ObjectID, from string, update bson.M,
+) (*models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{"_id": id, "status": from})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var course *models.Course
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&course)
+	if err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
 func (r *courseRepository) DeleteOne(
 	ctx context.Context, id primitive.ObjectID,
 ) error {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	deleteResult, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	deleteResult, err := r.collection.DeleteOne(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+	)
 	if err != nil {
 		return err
 	}
@@ -157,14 +570,225 @@ func (r *courseRepository) DeleteOne(
 	return nil
 }
 
-func (r *courseRepository) Drop(ctx context.Context) error {
+func (r *courseRepository) FindByIds(
+	ctx context.Context, ids []primitive.ObjectID,
+) ([]models.Course, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	err := r.collection.Drop(ctx)
+	cursor, err := r.collection.Find(
+		ctx, inTenant(ctx, bson.M{"_id": bson.M{"$in": ids}}),
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	return nil
+	var courses []models.Course
+	err = cursor.All(ctx, &courses)
+	if err != nil {
+		return nil, err
+	}
+
+	if courses == nil {
+		courses = []models.Course{}
+	}
+
+	return courses, nil
+}
+
+func (r *courseRepository) AdjustEnrollmentCount(
+	ctx context.Context, id primitive.ObjectID, delta int64,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+		bson.M{"$inc": bson.M{"enrollment_count": delta}},
+	)
+	return err
+}
+
+func (r *courseRepository) AdjustRating(
+	ctx context.Context, id primitive.ObjectID, sumDelta int64, countDelta int64,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// A pipeline update reads the stored totals, so concurrent reviews
+	// can't leave the average out of step with them
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"rating_sum": bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$rating_sum", 0}}, sumDelta,
+			}},
+			"rating_count": bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$rating_count", 0}}, countDelta,
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"average_rating": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$rating_count", 0}},
+				bson.M{"$round": bson.A{
+					bson.M{"$divide": bson.A{"$rating_sum", "$rating_count"}}, 2,
+				}},
+				0,
+			}},
+		}}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, inTenant(ctx, bson.M{"_id": id}), update)
+	return err
+}
+
+func (r *courseRepository) AddPrerequisite(
+	ctx context.Context, id primitive.ObjectID, prerequisiteId primitive.ObjectID,
+	limit int,
+) (*models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{
+		"_id": id,
+		// The array has no element at index limit-1, so it has room
+		fmt.Sprintf("prerequisites.%d", limit-1): bson.M{"$exists": false},
+	})
+	update := bson.M{
+		"$addToSet": bson.M{"prerequisites": prerequisiteId},
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var course models.Course
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&course)
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+func (r *courseRepository) RemovePrerequisite(
+	ctx context.Context, id primitive.ObjectID, prerequisiteId primitive.ObjectID,
+) (*models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$pull": bson.M{"prerequisites": prerequisiteId},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var course models.Course
+	err := r.collection.FindOneAndUpdate(
+		ctx, inTenant(ctx, bson.M{"_id": id}), update, opts,
+	).Decode(&course)
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+func (r *courseRepository) PullPrerequisites(
+	ctx context.Context, prerequisiteIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	updateResult, err := r.collection.UpdateMany(
+		ctx,
+		inTenant(ctx, bson.M{"prerequisites": bson.M{"$in": prerequisiteIds}}),
+		bson.M{"$pull": bson.M{"prerequisites": bson.M{"$in": prerequisiteIds}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return updateResult.ModifiedCount, nil
+}
+
+func (r *courseRepository) FindByInstructor(
+	ctx context.Context, instructorId primitive.ObjectID,
+) ([]models.Course, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, inTenant(ctx, bson.M{"instructor_id": instructorId}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var courses []models.Course
+	err = cursor.All(ctx, &courses)
+	if err != nil {
+		return nil, err
+	}
+
+	if courses == nil {
+		courses = []models.Course{}
+	}
+
+	return courses, nil
+}
+
+func (r *courseRepository) CountByInstructor(
+	ctx context.Context, instructorId primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.collection.CountDocuments(
+		ctx, inTenant(ctx, bson.M{"instructor_id": instructorId}),
+	)
+}
+
+func (r *courseRepository) DeleteByInstructor(
+	ctx context.Context, instructorId primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"instructor_id": instructorId}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+func (r *courseRepository) ReassignInstructor(
+	ctx context.Context, from primitive.ObjectID, to primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	updateResult, err := r.collection.UpdateMany(
+		ctx, inTenant(ctx, bson.M{"instructor_id": from}),
+		bson.M{"$set": bson.M{"instructor_id": to}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return updateResult.ModifiedCount, nil
+}
+
+// Drop deletes every course, or only the tenant's courses when ctx is scoped
+// to one. The collection itself stays, dropping it would take the indexes
+// created at startup with it
+func (r *courseRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
 }
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCatalogStagesWithoutFilters(t *testing.T) {
+	count := bson.M{"$count": "count"}
+
+	stages := catalogStages(CourseFilter{Currency: "USD"}, count)
+	if !reflect.DeepEqual(stages, bson.A{count}) {
+		t.Errorf("stages = %v, want only the facet's own stages", stages)
+	}
+}
+
+func TestCatalogStagesDropTheClearedFilter(t *testing.T) {
+	filter := CourseFilter{Category: "design", Level: "beginner", Tag: "figma"}
+
+	// The category facet counts every category the other filters allow
+	withoutCategory := filter
+	withoutCategory.Category = ""
+
+	stages := catalogStages(withoutCategory)
+	want := bson.A{bson.M{"$match": bson.M{"level": "beginner", "tags": "figma"}}}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("stages = %v, want %v", stages, want)
+	}
+}
+
+func TestCatalogQueryPriceRange(t *testing.T) {
+	minPrice := 1000
+	query := catalogQuery(CourseFilter{MinPrice: &minPrice, Currency: "EUR"})
+
+	// A course matches on its price or on its price list entry in the
+	// currency
+	amount := bson.M{"$gte": 1000}
+	want := bson.M{"$or": bson.A{
+		bson.M{"course_price.currency": "EUR", "course_price.amount": amount},
+		bson.M{"price_list": bson.M{"$elemMatch": bson.M{
+			"currency": "EUR", "amount": amount,
+		}}},
+	}}
+	if !reflect.DeepEqual(query, want) {
+		t.Errorf("query = %v, want %v", query, want)
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CourseRevisionRepository interface {
+	// Create inserts a revision, a duplicate key error means another
+	// change took its number first
+	Create(ctx context.Context, revision *models.CourseRevision) (
+		*models.CourseRevision, error,
+	)
+	FindOne(
+		ctx context.Context, courseId primitive.ObjectID, revision int64,
+	) (*models.CourseRevision, error)
+	FindLatest(ctx context.Context, courseId primitive.ObjectID) (
+		*models.CourseRevision, error,
+	)
+	FindByCourse(
+		ctx context.Context, courseId primitive.ObjectID, page int64,
+		pageSize int64,
+	) ([]models.CourseRevision, int64, error)
+	// Prune deletes the course's revisions numbered below revision
+	Prune(
+		ctx context.Context, courseId primitive.ObjectID, revision int64,
+	) (int64, error)
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type courseRevisionRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewCourseRevisionRepo(db *mongo.Database) CourseRevisionRepository {
+	return &courseRevisionRepository{
+		collection: db.Collection("course_revisions"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *courseRevisionRepository) Create(
+	ctx context.Context, revision *models.CourseRevision,
+) (*models.CourseRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	revision.ID = primitive.NewObjectID()
+	if revision.TenantId == nil {
+		revision.TenantId = tenantOf(ctx)
+	}
+
+	_, err := r.collection.InsertOne(ctx, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func (r *courseRevisionRepository) FindOne(
+	ctx context.Context, courseId primitive.ObjectID, revision int64,
+) (*models.CourseRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var courseRevision models.CourseRevision
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"course_id": courseId, "revision": revision}),
+	).Decode(&courseRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &courseRevision, nil
+}
+
+func (r *courseRevisionRepository) FindLatest(
+	ctx context.Context, courseId primitive.ObjectID,
+) (*models.CourseRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var courseRevision models.CourseRevision
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"course_id": courseId}),
+		options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}}),
+	).Decode(&courseRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &courseRevision, nil
+}
+
+// FindByCourse lists a course's revisions, most recent first
+func (r *courseRevisionRepository) FindByCourse(
+	ctx context.Context, courseId primitive.ObjectID, page int64, pageSize int64,
+) ([]models.CourseRevision, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{"course_id": courseId})
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []models.CourseRevision
+	err = cursor.All(ctx, &revisions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if revisions == nil {
+		revisions = []models.CourseRevision{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, totalCount, nil
+}
+
+func (r *courseRevisionRepository) Prune(
+	ctx context.Context, courseId primitive.ObjectID, revision int64,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{
+		"course_id": courseId, "revision": bson.M{"$lt": revision},
+	}))
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+func (r *courseRevisionRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"course_id": bson.M{"$in": courseIds}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every revision, or only the tenant's when ctx is scoped to one
+func (r *courseRevisionRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CurriculumRepository interface {
+	FindByCourse(ctx context.Context, courseId primitive.ObjectID) (
+		*models.Curriculum, error,
+	)
+	Save(ctx context.Context, curriculum *models.Curriculum) error
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type curriculumRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewCurriculumRepo(db *mongo.Database) CurriculumRepository {
+	return &curriculumRepository{
+		collection: db.Collection("curricula"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *curriculumRepository) FindByCourse(
+	ctx context.Context, courseId primitive.ObjectID,
+) (*models.Curriculum, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var curriculum models.Curriculum
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"course_id": courseId}),
+	).Decode(&curriculum)
+	if err != nil {
+		return nil, err
+	}
+
+	return &curriculum, nil
+}
+
+// Save writes curriculum if nobody else wrote it since it was read, and
+// bumps its version. A curriculum that was never saved is inserted. When
+// somebody else got there first it returns mongo.ErrNoDocuments, or a
+// duplicate key error for two first saves
+func (r *curriculumRepository) Save(
+	ctx context.Context, curriculum *models.Curriculum,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	readVersion := curriculum.Version
+	curriculum.Version++
+	curriculum.UpdatedAt = time.Now()
+
+	if readVersion == 0 {
+		curriculum.ID = primitive.NewObjectID()
+		if curriculum.TenantId == nil {
+			curriculum.TenantId = tenantOf(ctx)
+		}
+
+		_, err := r.collection.InsertOne(ctx, curriculum)
+		if err != nil {
+			curriculum.Version = readVersion
+			return err
+		}
+		return nil
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		inTenant(ctx, bson.M{"_id": curriculum.ID, "version": readVersion}),
+		bson.M{"$set": bson.M{
+			"sections":         curriculum.Sections,
+			"lesson_count":     curriculum.LessonCount,
+			"duration_seconds": curriculum.DurationSeconds,
+			"version":          curriculum.Version,
+			"updated_at":       curriculum.UpdatedAt,
+		}},
+	)
+	if err == nil && result.MatchedCount == 0 {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		curriculum.Version = readVersion
+		return err
+	}
+
+	return nil
+}
+
+func (r *curriculumRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"course_id": bson.M{"$in": courseIds}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every curriculum, or only the tenant's when ctx is scoped to
+// one
+func (r *curriculumRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) (
+		*models.DataExport, error,
+	)
+	FindOne(ctx context.Context, id primitive.ObjectID) (
+		*models.DataExport, error,
+	)
+	FindActiveByUser(ctx context.Context, userId primitive.ObjectID) (
+		*models.DataExport, error,
+	)
+	ClaimPending(ctx context.Context, staleBefore time.Time) (
+		*models.DataExport, error,
+	)
+	MarkCompleted(
+		ctx context.Context, export *models.DataExport, fileId primitive.ObjectID,
+		expiresAt time.Time,
+	) error
+	MarkFailed(
+		ctx context.Context, export *models.DataExport, reason string,
+	) error
+	FindExpired(ctx context.Context) ([]models.DataExport, error)
+	FindByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.DataExport, error,
+	)
+	DeleteOne(ctx context.Context, id primitive.ObjectID) error
+}
+
+type dataExportRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewDataExportRepo(db *mongo.Database) DataExportRepository {
+	return &dataExportRepository{
+		collection: db.Collection("data_exports"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *dataExportRepository) Create(
+	ctx context.Context, export *models.DataExport,
+) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	export.ID = primitive.NewObjectID()
+
+	_, err := r.collection.InsertOne(ctx, export)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) FindOne(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var export *models.DataExport
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&export)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) FindActiveByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"user_id": userId,
+		"status": bson.M{"$in": []string{
+			models.ExportStatusPending, models.ExportStatusProcessing,
+		}},
+	}
+
+	var export *models.DataExport
+	err := r.collection.FindOne(ctx, filter).Decode(&export)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// ClaimPending atomically moves the oldest pending export to processing so
+// that only one worker at a time builds a given archive. Exports claimed
+// before staleBefore, or by a version that didn't record the claim, belong
+// to a worker that died or gave up, they are claimed again
+func (r *dataExportRepository) ClaimPending(
+	ctx context.Context, staleBefore time.Time,
+) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.ExportStatusPending},
+		bson.M{
+			"status":     models.ExportStatusProcessing,
+			"claimed_at": bson.M{"$not": bson.M{"$gte": staleBefore}},
+		},
+	}}
+
+	var export *models.DataExport
+	err := r.collection.FindOneAndUpdate(
+		ctx, filter,
+		bson.M{"$set": bson.M{
+			"status":     models.ExportStatusProcessing,
+			"claimed_at": time.Now(),
+		}},
+		opts,
+	).Decode(&export)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// MarkCompleted finishes an export claimed by this worker. It returns
+// mongo.ErrNoDocuments when another worker has claimed the export since
+func (r *dataExportRepository) MarkCompleted(
+	ctx context.Context, export *models.DataExport, fileId primitive.ObjectID,
+	expiresAt time.Time,
+) error {
+	return r.finish(ctx, export, bson.M{
+		"status":       models.ExportStatusCompleted,
+		"file_id":      fileId,
+		"completed_at": time.Now(),
+		"expires_at":   expiresAt,
+	})
+}
+
+// MarkFailed gives up an export claimed by this worker. It returns
+// mongo.ErrNoDocuments when another worker has claimed the export since
+func (r *dataExportRepository) MarkFailed(
+	ctx context.Context, export *models.DataExport, reason string,
+) error {
+	return r.finish(ctx, export, bson.M{
+		"status":       models.ExportStatusFailed,
+		"error":        reason,
+		"completed_at": time.Now(),
+	})
+}
+
+func (r *dataExportRepository) finish(
+	ctx context.Context, export *models.DataExport, set bson.M,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx, bson.M{"_id": export.ID, "claimed_at": export.ClaimedAt},
+		bson.M{"$set": set, "$unset": bson.M{"active": ""}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) FindExpired(ctx context.Context) (
+	[]models.DataExport, error,
+) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, bson.M{"expires_at": bson.M{"$lt": time.Now()}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var exports []models.DataExport
+	err = cursor.All(ctx, &exports)
+	if err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+func (r *dataExportRepository) FindByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, bson.M{"user_id": userId},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var exports []models.DataExport
+	err = cursor.All(ctx, &exports)
+	if err != nil {
+		return nil, err
+	}
+
+	if exports == nil {
+		exports = []models.DataExport{}
+	}
+
+	return exports, nil
+}
+
+func (r *dataExportRepository) DeleteOne(
+	ctx context.Context, id primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if deleteResult.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
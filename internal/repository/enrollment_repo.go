@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EnrollmentRepository interface {
+	// Create inserts a new enrollment, a duplicate key error means the user
+	// already has one for the course
+	Create(ctx context.Context, enrollment *models.Enrollment) (
+		*models.Enrollment, error,
+	)
+	FindOne(
+		ctx context.Context, userId primitive.ObjectID,
+		courseId primitive.ObjectID,
+	) (*models.Enrollment, error)
+	// Transition applies update only while the enrollment is still in one
+	// of the from statuses, returning mongo.ErrNoDocuments otherwise
+	Transition(
+		ctx context.Context, id primitive.ObjectID, from []string, update bson.M,
+	) (*models.Enrollment, error)
+	FindByUser(
+		ctx context.Context, userId primitive.ObjectID, status string, page int64,
+		pageSize int64,
+	) ([]models.Enrollment, int64, error)
+	FindByCourse(
+		ctx context.Context, courseId primitive.ObjectID, status string,
+		page int64, pageSize int64,
+	) ([]models.Enrollment, int64, error)
+	CountByCourse(
+		ctx context.Context, courseId primitive.ObjectID, status string,
+	) (int64, error)
+	FindAllByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.Enrollment, error,
+	)
+	DeleteByUser(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type enrollmentRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewEnrollmentRepo(db *mongo.Database) EnrollmentRepository {
+	return &enrollmentRepository{
+		collection: db.Collection("enrollments"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *enrollmentRepository) Create(
+	ctx context.Context, enrollment *models.Enrollment,
+) (*models.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	enrollment.ID = primitive.NewObjectID()
+	if enrollment.TenantId == nil {
+		enrollment.TenantId = tenantOf(ctx)
+	}
+
+	_, err := r.collection.InsertOne(ctx, enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+func (r *enrollmentRepository) FindOne(
+	ctx context.Context, userId primitive.ObjectID, courseId primitive.ObjectID,
+) (*models.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var enrollment models.Enrollment
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"user_id": userId, "course_id": courseId}),
+	).Decode(&enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+func (r *enrollmentRepository) Transition(
+	ctx context.Context, id primitive.ObjectID, from []string, update bson.M,
+) (*models.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{"_id": id, "status": bson.M{"$in": from}})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var enrollment models.Enrollment
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// FindByUser lists the user's enrollments, most recent first, optionally
+// only those in status
+func (r *enrollmentRepository) FindByUser(
+	ctx context.Context, userId primitive.ObjectID, status string, page int64,
+	pageSize int64,
+) ([]models.Enrollment, int64, error) {
+	return r.findPage(ctx, bson.M{"user_id": userId}, status, page, pageSize)
+}
+
+// FindByCourse lists the course's enrollments, most recent first,
+// optionally only those in status
+func (r *enrollmentRepository) FindByCourse(
+	ctx context.Context, courseId primitive.ObjectID, status string, page int64,
+	pageSize int64,
+) ([]models.Enrollment, int64, error) {
+	return r.findPage(ctx, bson.M{"course_id": courseId}, status, page, pageSize)
+}
+
+func (r *enrollmentRepository) findPage(
+	ctx context.Context, filter bson.M, status string, page int64,
+	pageSize int64,
+) ([]models.Enrollment, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if status != "" {
+		filter["status"] = status
+	}
+	filter = inTenant(ctx, filter)
+
+	findOptions := options.Find().
+		SetSort(bson.D{
+			{Key: "enrolled_at", Value: -1},
+			{Key: "_id", Value: -1},
+		}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var enrollments []models.Enrollment
+	err = cursor.All(ctx, &enrollments)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if enrollments == nil {
+		enrollments = []models.Enrollment{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return enrollments, totalCount, nil
+}
+
+func (r *enrollmentRepository) CountByCourse(
+	ctx context.Context, courseId primitive.ObjectID, status string,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.collection.CountDocuments(
+		ctx, inTenant(ctx, bson.M{"course_id": courseId, "status": status}),
+	)
+}
+
+func (r *enrollmentRepository) FindAllByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, inTenant(ctx, bson.M{"user_id": userId}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var enrollments []models.Enrollment
+	err = cursor.All(ctx, &enrollments)
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollments, nil
+}
+
+func (r *enrollmentRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"user_id": userId}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+func (r *enrollmentRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"course_id": bson.M{"$in": courseIds}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every enrollment, or only the tenant's when ctx is scoped to
+// one
+func (r *enrollmentRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
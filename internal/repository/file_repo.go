@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FileRepository interface {
+	Upload(
+		ctx context.Context, filename string, source io.Reader,
+		metadata models.FileMetadata,
+	) (*models.File, error)
+	FindOne(ctx context.Context, id primitive.ObjectID) (*models.File, error)
+	Open(ctx context.Context, id primitive.ObjectID) (
+		*models.File, *gridfs.DownloadStream, error,
+	)
+	// OpenSeekable opens a file that can be read from any offset, for
+	// range requests
+	OpenSeekable(ctx context.Context, id primitive.ObjectID) (
+		*models.File, io.ReadSeekCloser, error,
+	)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type fileRepository struct {
+	bucket  *gridfs.Bucket
+	timeout time.Duration
+}
+
+// NewFileRepo returns a repository backed by the GridFS bucket with the
+// given name, e.g. "avatars" stores its files in avatars.files/avatars.chunks
+func NewFileRepo(db *mongo.Database, bucketName string) FileRepository {
+	bucket, err := gridfs.NewBucket(
+		db, options.GridFSBucket().SetName(bucketName),
+	)
+	if err != nil {
+		// NewBucket only fails on invalid options
+		panic(err)
+	}
+
+	return &fileRepository{
+		bucket:  bucket,
+		timeout: 60 * time.Second,
+	}
+}
+
+func (r *fileRepository) Upload(
+	ctx context.Context, filename string, source io.Reader,
+	metadata models.FileMetadata,
+) (*models.File, error) {
+	uploadStream, err := r.bucket.OpenUploadStream(
+		filename, options.GridFSUpload().SetMetadata(metadata),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer uploadStream.Close()
+
+	err = uploadStream.SetWriteDeadline(r.deadline(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := io.Copy(uploadStream, source)
+	if err != nil {
+		uploadStream.Abort()
+		return nil, err
+	}
+
+	// Close flushes the last chunk and writes the files document
+	err = uploadStream.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.File{
+		ID:         uploadStream.FileID.(primitive.ObjectID),
+		Filename:   filename,
+		Length:     length,
+		UploadDate: time.Now(),
+		Metadata:   metadata,
+	}, nil
+}
+
+func (r *fileRepository) FindOne(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var file *models.File
+	err := r.bucket.GetFilesCollection().FindOne(
+		ctx, bson.M{"_id": id},
+	).Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (r *fileRepository) Open(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.File, *gridfs.DownloadStream, error) {
+	file, err := r.FindOne(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	downloadStream, err := r.bucket.OpenDownloadStream(id)
+	if err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return nil, nil, mongo.ErrNoDocuments
+		}
+		return nil, nil, err
+	}
+
+	err = downloadStream.SetReadDeadline(r.deadline(ctx))
+	if err != nil {
+		downloadStream.Close()
+		return nil, nil, err
+	}
+
+	return file, downloadStream, nil
+}
+
+func (r *fileRepository) OpenSeekable(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.File, io.ReadSeekCloser, error) {
+	file, stream, err := r.Open(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, &seekableDownload{
+		repo: r, ctx: ctx, id: id, size: file.Length, stream: stream,
+	}, nil
+}
+
+func (r *fileRepository) Delete(
+	ctx context.Context, id primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.bucket.DeleteContext(ctx, id)
+	if err == gridfs.ErrFileNotFound {
+		return mongo.ErrNoDocuments
+	}
+
+	return err
+}
+
+func (r *fileRepository) deadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(r.timeout)
+}
+
+// seekableDownload lets a GridFS download be read from any offset. Seeking
+// only records the offset, the next read skips ahead on the open stream or,
+// to go back, reopens it
+type seekableDownload struct {
+	repo   *fileRepository
+	ctx    context.Context
+	id     primitive.ObjectID
+	size   int64
+	stream *gridfs.DownloadStream
+	// read is where the stream is, offset where the next read starts
+	read   int64
+	offset int64
+}
+
+func (d *seekableDownload) Read(p []byte) (int, error) {
+	if d.offset >= d.size {
+		return 0, io.EOF
+	}
+
+	if d.offset < d.read {
+		d.stream.Close()
+
+		stream, err := d.repo.bucket.OpenDownloadStream(d.id)
+		if err != nil {
+			return 0, err
+		}
+		err = stream.SetReadDeadline(d.repo.deadline(d.ctx))
+		if err != nil {
+			stream.Close()
+			return 0, err
+		}
+		d.stream, d.read = stream, 0
+	}
+
+	if d.offset > d.read {
+		skipped, err := d.stream.Skip(d.offset - d.read)
+		d.read += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := d.stream.Read(p)
+	d.read += int64(n)
+	d.offset = d.read
+	return n, err
+}
+
+func (d *seekableDownload) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		offset += d.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	d.offset = offset
+	return offset, nil
+}
+
+func (d *seekableDownload) Close() error {
+	return d.stream.Close()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,12 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Server error codes returned when dropping an index that isn't there
+const (
+	namespaceNotFoundCode = 26
+	indexNotFoundCode     = 27
+)
+
 func InitializeIndexes(db *mongo.Database) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -26,18 +33,130 @@ func InitializeIndexes(db *mongo.Database) error {
 		return err
 	}
 
+	err = initCurriculumIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize curriculum index, " + err.Error())
+		return err
+	}
+
+	err = initCourseMaterialIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize course material index, " + err.Error())
+		return err
+	}
+
+	err = initEnrollmentIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize enrollment index, " + err.Error())
+		return err
+	}
+
+	err = initCourseProgressIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize course progress index, " + err.Error())
+		return err
+	}
+
+	err = initCertificateIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize certificate index, " + err.Error())
+		return err
+	}
+
+	err = initReviewIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize review index, " + err.Error())
+		return err
+	}
+
+	err = initCourseRevisionIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize course revision index, " + err.Error())
+		return err
+	}
+
+	err = initOrganizationIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize organization index, " + err.Error())
+		return err
+	}
+
+	err = initInstructorApplicationIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize instructor application index, " + err.Error())
+		return err
+	}
+
+	err = initInvitationIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize invitation index, " + err.Error())
+		return err
+	}
+
+	err = initDataExportIndexes(ctx, db)
+	if err != nil {
+		fmt.Println("failed to initialize data export index, " + err.Error())
+		return err
+	}
+
 	fmt.Println("✓ All indexes initialized successfully")
 	return nil
 }
 
 func initUserIndexes(ctx context.Context, db *mongo.Database) error {
 	userCollection := db.Collection("users")
-	indexes := mongo.IndexModel{
-		Keys:    bson.D{{Key: "email", Value: 1}},
-		Options: options.Index().SetUnique(true).SetName("email_unique"),
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("email_unique"),
+		},
+		{
+			// Only soft deleted users carry the field, keeping the index small
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("deleted_at_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "pending_email.token_hash", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("pending_email_token_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "password_setup.token_hash", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("password_setup_token_index"),
+		},
+		{
+			// Backs the default listing and the created_at range filter
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("created_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "role", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("role_created_at_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetName("name_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "updated_at", Value: -1}},
+			Options: options.Index().SetName("updated_at_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "deletion_scheduled_at", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("deletion_scheduled_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "tenant_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("tenant_created_at_index"),
+		},
 	}
 
-	_, err := userCollection.Indexes().CreateOne(ctx, indexes)
+	_, err := userCollection.Indexes().CreateMany(ctx, indexes)
 	if err != nil {
 		return err
 	}
@@ -45,26 +164,539 @@ func initUserIndexes(ctx context.Context, db *mongo.Database) error {
 	return nil
 }
 
+// dropIndex drops an index that has been replaced, one that is already
+// gone is fine
+func dropIndex(
+	ctx context.Context, collection *mongo.Collection, name string,
+) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var commandErr mongo.CommandError
+	if err != nil && !(errors.As(err, &commandErr) &&
+		(commandErr.HasErrorCode(indexNotFoundCode) ||
+			commandErr.HasErrorCode(namespaceNotFoundCode))) {
+		return err
+	}
+	return nil
+}
+
 func initCourseIndexes(ctx context.Context, db *mongo.Database) error {
 	courseCollection := db.Collection("courses")
 
+	// Course names stay unique across the whole deployment, clones pick
+	// their names by it. A per-tenant index briefly replaced it
+	err := dropIndex(ctx, courseCollection, "tenant_course_name_unique")
+	if err != nil {
+		return err
+	}
+
+	// Prices used to be a bare number, they now carry their currency
+	err = dropIndex(ctx, courseCollection, "status_price_index")
+	if err != nil {
+		return err
+	}
+
 	indexes := []mongo.IndexModel{
 		{
-			Keys: bson.D{
-				{
-					Key:   "course_name",
-					Value: 1,
-				},
-			},
+			Keys:    bson.D{{Key: "course_name", Value: 1}},
 			Options: options.Index().SetUnique(true).SetName("course_name_unique"),
 		},
 		{
 			Keys:    bson.D{{Key: "instructor_id", Value: 1}},
 			Options: options.Index().SetName("instructor_index"),
 		},
+		{
+			Keys: bson.D{
+				{Key: "tenant_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("tenant_created_at_index"),
+		},
+		{
+			// Backs the public listing of published courses
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("status_created_at_index"),
+		},
+		{
+			// The catalog filters, each on top of the published status the
+			// public listing always matches
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "course_price.currency", Value: 1},
+				{Key: "course_price.amount", Value: 1},
+			},
+			Options: options.Index().SetName("status_price_amount_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "price_list.currency", Value: 1},
+				{Key: "price_list.amount", Value: 1},
+			},
+			Options: options.Index().SetName("status_price_list_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "category", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("status_category_created_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "level", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("status_level_created_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "tags", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("status_tags_created_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "instructor_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("instructor_status_created_at_index"),
+		},
+		{
+			// Finds the courses requiring a deleted course
+			Keys:    bson.D{{Key: "prerequisites", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("prerequisites_index"),
+		},
+		{
+			// A collection has a single text index, a name match ranks
+			// above a tag match, which ranks above a description match.
+			// Each course is stemmed in its own language
+			Keys: bson.D{
+				{Key: "course_name", Value: "text"},
+				{Key: "tags", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().
+				SetWeights(bson.M{"course_name": 10, "tags": 5, "description": 1}).
+				SetDefaultLanguage("english").
+				SetLanguageOverride("language").
+				SetName("course_text_index"),
+		},
+	}
+
+	_, err = courseCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initCurriculumIndexes(ctx context.Context, db *mongo.Database) error {
+	curriculumCollection := db.Collection("curricula")
+
+	indexes := []mongo.IndexModel{
+		{
+			// One curriculum per course, two concurrent first saves can't
+			// both create one
+			Keys:    bson.D{{Key: "course_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("course_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := curriculumCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initCourseMaterialIndexes(ctx context.Context, db *mongo.Database) error {
+	materialCollection := db.Collection("course_materials")
+
+	indexes := []mongo.IndexModel{
+		{
+			// Backs the listing and the quota sum
+			Keys: bson.D{
+				{Key: "course_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("course_created_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := materialCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initEnrollmentIndexes(ctx context.Context, db *mongo.Database) error {
+	enrollmentCollection := db.Collection("enrollments")
+
+	indexes := []mongo.IndexModel{
+		{
+			// One enrollment per learner and course
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "course_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("user_course_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "enrolled_at", Value: -1},
+			},
+			Options: options.Index().SetName("user_enrolled_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "course_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "enrolled_at", Value: -1},
+			},
+			Options: options.Index().SetName("course_status_enrolled_at_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := enrollmentCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initCourseProgressIndexes(ctx context.Context, db *mongo.Database) error {
+	progressCollection := db.Collection("course_progress")
+
+	indexes := []mongo.IndexModel{
+		{
+			// One record per learner and course, also backs the report
+			// lookups
+			Keys: bson.D{
+				{Key: "course_id", Value: 1},
+				{Key: "user_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("course_user_unique"),
+		},
+		{
+			// Backs "continue where you left off"
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "updated_at", Value: -1},
+			},
+			Options: options.Index().SetName("user_updated_at_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := progressCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initCertificateIndexes(ctx context.Context, db *mongo.Database) error {
+	certificateCollection := db.Collection("certificates")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("code_unique"),
+		},
+		{
+			// One certificate per learner and course
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "course_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("user_course_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := certificateCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initReviewIndexes(ctx context.Context, db *mongo.Database) error {
+	reviewCollection := db.Collection("reviews")
+
+	indexes := []mongo.IndexModel{
+		{
+			// One review per learner and course
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "course_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("user_course_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "course_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("course_created_at_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "course_id", Value: 1},
+				{Key: "helpful_count", Value: -1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("course_helpful_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := reviewCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	voteCollection := db.Collection("review_votes")
+
+	indexes = []mongo.IndexModel{
+		{
+			// One helpful vote per learner and review
+			Keys: bson.D{
+				{Key: "review_id", Value: 1},
+				{Key: "user_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("review_user_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("user_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "course_id", Value: 1}},
+			Options: options.Index().SetName("course_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err = voteCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initCourseRevisionIndexes(ctx context.Context, db *mongo.Database) error {
+	revisionCollection := db.Collection("course_revisions")
+
+	indexes := []mongo.IndexModel{
+		{
+			// Revision numbers are handed out once per course
+			Keys: bson.D{
+				{Key: "course_id", Value: 1},
+				{Key: "revision", Value: -1},
+			},
+			Options: options.Index().SetUnique(true).SetName("course_revision_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+	}
+
+	_, err := revisionCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initDataExportIndexes(ctx context.Context, db *mongo.Database) error {
+	exportCollection := db.Collection("data_exports")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("user_created_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("status_created_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("expires_at_index"),
+		},
+		{
+			// Two requests racing past the active export check can't both
+			// queue one
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}).
+				SetName("user_active_unique"),
+		},
+	}
+
+	_, err := exportCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initInvitationIndexes(ctx context.Context, db *mongo.Database) error {
+	invitationCollection := db.Collection("invitations")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "email", Value: 1},
+				{Key: "status", Value: 1},
+			},
+			Options: options.Index().SetName("email_status_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("tenant_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("status_created_index"),
+		},
+	}
+
+	_, err := invitationCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initOrganizationIndexes(ctx context.Context, db *mongo.Database) error {
+	organizationCollection := db.Collection("organizations")
+
+	_, err := organizationCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("slug_unique"),
+	})
+	if err != nil {
+		return err
+	}
+
+	membershipCollection := db.Collection("memberships")
+
+	indexes := []mongo.IndexModel{
+		{
+			// A user belongs to at most one organization
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("user_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "organization_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("organization_created_index"),
+		},
+	}
+
+	_, err = membershipCollection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func initInstructorApplicationIndexes(
+	ctx context.Context, db *mongo.Database,
+) error {
+	applicationCollection := db.Collection("instructor_applications")
+
+	indexes := []mongo.IndexModel{
+		{
+			// One open application per user
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "pending"}).
+				SetName("user_pending_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("user_created_index"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "tenant_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("tenant_status_created_index"),
+		},
 	}
 
-	_, err := courseCollection.Indexes().CreateMany(ctx, indexes)
+	_, err := applicationCollection.Indexes().CreateMany(ctx, indexes)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InstructorApplicationRepository interface {
+	Create(
+		ctx context.Context, application *models.InstructorApplication,
+	) (*models.InstructorApplication, error)
+	FindAll(ctx context.Context, status string, page int64, pageSize int64) (
+		[]models.InstructorApplication, int64, error,
+	)
+	FindByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.InstructorApplication, error,
+	)
+	Review(
+		ctx context.Context, id primitive.ObjectID, reviewerId primitive.ObjectID,
+		status string, reason string,
+	) (*models.InstructorApplication, error)
+	Reopen(ctx context.Context, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type instructorApplicationRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewInstructorApplicationRepo(
+	db *mongo.Database,
+) InstructorApplicationRepository {
+	return &instructorApplicationRepository{
+		collection: db.Collection("instructor_applications"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *instructorApplicationRepository) Create(
+	ctx context.Context, application *models.InstructorApplication,
+) (*models.InstructorApplication, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	application.ID = primitive.NewObjectID()
+	tenantId := tenantOf(ctx)
+	if tenantId != nil {
+		application.TenantId = tenantId
+	}
+
+	_, err := r.collection.InsertOne(ctx, application)
+	if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+// FindAll lists applications oldest first, so the review queue is worked
+// through in the order people applied
+func (r *instructorApplicationRepository) FindAll(
+	ctx context.Context, status string, page int64, pageSize int64,
+) ([]models.InstructorApplication, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{})
+	if status != "" {
+		filter["status"] = status
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var applications []models.InstructorApplication
+	err = cursor.All(ctx, &applications)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if applications == nil {
+		applications = []models.InstructorApplication{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return applications, totalCount, nil
+}
+
+func (r *instructorApplicationRepository) FindByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.InstructorApplication, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, inTenant(ctx, bson.M{"user_id": userId}),
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var applications []models.InstructorApplication
+	err = cursor.All(ctx, &applications)
+	if err != nil {
+		return nil, err
+	}
+
+	if applications == nil {
+		applications = []models.InstructorApplication{}
+	}
+
+	return applications, nil
+}
+
+// Review decides a pending application. Applications that were already
+// decided don't match, so two admins can't both review the same one
+func (r *instructorApplicationRepository) Review(
+	ctx context.Context, id primitive.ObjectID, reviewerId primitive.ObjectID,
+	status string, reason string,
+) (*models.InstructorApplication, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	set := bson.M{
+		"status":      status,
+		"reviewed_by": reviewerId,
+		"reviewed_at": time.Now(),
+	}
+	if reason != "" {
+		set["rejection_reason"] = reason
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var application *models.InstructorApplication
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		inTenant(ctx, bson.M{"_id": id, "status": models.ApplicationStatusPending}),
+		bson.M{"$set": set}, opts,
+	).Decode(&application)
+	if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+// Reopen puts a reviewed application back in the queue when applying the
+// decision failed
+func (r *instructorApplicationRepository) Reopen(
+	ctx context.Context, id primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+		bson.M{
+			"$set": bson.M{"status": models.ApplicationStatusPending},
+			"$unset": bson.M{
+				"reviewed_by": "", "reviewed_at": "", "rejection_reason": "",
+			},
+		},
+	)
+
+	return err
+}
+
+func (r *instructorApplicationRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{"user_id": userId}))
+	return err
+}
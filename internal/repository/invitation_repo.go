@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) (
+		*models.Invitation, error,
+	)
+	FindAll(ctx context.Context, status string, page int64, pageSize int64) (
+		[]models.Invitation, int64, error,
+	)
+	// FindByUser lists the invitations a user sent or was sent to email,
+	// whatever their status
+	FindByUser(
+		ctx context.Context, userId primitive.ObjectID, email string,
+	) ([]models.Invitation, error)
+	FindPendingByEmail(ctx context.Context, email string) (
+		*models.Invitation, error,
+	)
+	FindPendingByToken(ctx context.Context, tokenHash string) (
+		*models.Invitation, error,
+	)
+	Accept(ctx context.Context, id primitive.ObjectID) (*models.Invitation, error)
+	Release(ctx context.Context, id primitive.ObjectID) error
+	Revoke(ctx context.Context, id primitive.ObjectID) (*models.Invitation, error)
+	// DeleteByUser deletes the invitations a user sent or was sent to email
+	DeleteByUser(
+		ctx context.Context, userId primitive.ObjectID, email string,
+	) error
+}
+
+type invitationRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewInvitationRepo(db *mongo.Database) InvitationRepository {
+	return &invitationRepository{
+		collection: db.Collection("invitations"),
+		timeout:    10 * time.Second,
+	}
+}
+
+// usable matches invitations that can still be accepted
+func usable(filter bson.M) bson.M {
+	filter["status"] = models.InvitationStatusPending
+	filter["expires_at"] = bson.M{"$gt": time.Now()}
+	return filter
+}
+
+func (r *invitationRepository) Create(
+	ctx context.Context, invitation *models.Invitation,
+) (*models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	invitation.ID = primitive.NewObjectID()
+	tenantId := tenantOf(ctx)
+	if tenantId != nil {
+		invitation.TenantId = tenantId
+	}
+
+	_, err := r.collection.InsertOne(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *invitationRepository) FindAll(
+	ctx context.Context, status string, page int64, pageSize int64,
+) ([]models.Invitation, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{})
+	if status != "" {
+		filter["status"] = status
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []models.Invitation
+	err = cursor.All(ctx, &invitations)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if invitations == nil {
+		invitations = []models.Invitation{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return invitations, totalCount, nil
+}
+
+func (r *invitationRepository) FindByUser(
+	ctx context.Context, userId primitive.ObjectID, email string,
+) ([]models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{"$or": bson.A{
+		bson.M{"invited_by": userId},
+		bson.M{"email": email},
+	}})
+
+	cursor, err := r.collection.Find(
+		ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []models.Invitation
+	err = cursor.All(ctx, &invitations)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitations == nil {
+		invitations = []models.Invitation{}
+	}
+
+	return invitations, nil
+}
+
+func (r *invitationRepository) FindPendingByEmail(
+	ctx context.Context, email string,
+) (*models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var invitation *models.Invitation
+	err := r.collection.FindOne(
+		ctx, usable(inTenant(ctx, bson.M{"email": email})),
+	).Decode(&invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *invitationRepository) FindPendingByToken(
+	ctx context.Context, tokenHash string,
+) (*models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var invitation *models.Invitation
+	err := r.collection.FindOne(
+		ctx, usable(inTenant(ctx, bson.M{"token_hash": tokenHash})),
+	).Decode(&invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Accept claims a usable invitation. Only one caller can win the claim,
+// which is what makes the token single use
+func (r *invitationRepository) Accept(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.InvitationStatusAccepted,
+			"accepted_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invitation *models.Invitation
+	err := r.collection.FindOneAndUpdate(
+		ctx, usable(inTenant(ctx, bson.M{"_id": id})), update, opts,
+	).Decode(&invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Release hands back an invitation claimed by Accept when registration
+// failed afterwards
+func (r *invitationRepository) Release(
+	ctx context.Context, id primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx, inTenant(
+			ctx, bson.M{"_id": id, "status": models.InvitationStatusAccepted},
+		),
+		bson.M{
+			"$set":   bson.M{"status": models.InvitationStatusPending},
+			"$unset": bson.M{"accepted_at": ""},
+		},
+	)
+
+	return err
+}
+
+func (r *invitationRepository) Revoke(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.InvitationStatusRevoked,
+			"revoked_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invitation *models.Invitation
+	err := r.collection.FindOneAndUpdate(
+		ctx, inTenant(
+			ctx, bson.M{"_id": id, "status": models.InvitationStatusPending},
+		),
+		update, opts,
+	).Decode(&invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *invitationRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID, email string,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{"$or": bson.A{
+		bson.M{"invited_by": userId},
+		bson.M{"email": email},
+	}}))
+	return err
+}
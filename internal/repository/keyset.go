@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Keyset continues a list right after the document with SortValue and Id,
+// or right before it when Backward. Unlike skipping, it costs the same on
+// every page and doesn't repeat or miss documents while the list changes
+type Keyset struct {
+	SortValue interface{}
+	Id        primitive.ObjectID
+	Backward  bool
+}
+
+// keysetCondition selects the documents past keyset in a list sorted by
+// field and then _id, both ascending unless desc
+func keysetCondition(field string, desc bool, keyset *Keyset) bson.M {
+	operator := "$gt"
+	if desc != keyset.Backward {
+		operator = "$lt"
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{operator: keyset.SortValue}},
+		bson.M{field: keyset.SortValue, "_id": bson.M{operator: keyset.Id}},
+	}}
+}
+
+// withKeyset returns a copy of query that also matches keyset, query itself
+// keeps counting the whole list
+func withKeyset(query bson.M, field string, desc bool, keyset *Keyset) bson.M {
+	if keyset == nil {
+		return query
+	}
+
+	paged := bson.M{}
+	for key, value := range query {
+		paged[key] = value
+	}
+
+	// query may already hold an $or of its own
+	and, _ := paged["$and"].(bson.A)
+	paged["$and"] = append(append(bson.A{}, and...), keysetCondition(field, desc, keyset))
+
+	return paged
+}
+
+// keysetSort sorts by field and then _id, reversed when walking a keyset
+// backwards so the documents closest to the position come first
+func keysetSort(field string, desc bool, keyset *Keyset) bson.D {
+	direction := 1
+	if desc {
+		direction = -1
+	}
+	if keyset != nil && keyset.Backward {
+		direction = -direction
+	}
+
+	return bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+}
+
+// reverseBackward puts documents read backwards back into list order
+func reverseBackward[T any](documents []T, keyset *Keyset) {
+	if keyset == nil || !keyset.Backward {
+		return
+	}
+
+	for i, j := 0, len(documents)-1; i < j; i, j = i+1, j-1 {
+		documents[i], documents[j] = documents[j], documents[i]
+	}
+}
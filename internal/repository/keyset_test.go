@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestKeysetConditionDirection(t *testing.T) {
+	keyset := &Keyset{SortValue: "ada", Id: primitive.NewObjectID()}
+
+	// Forward through a descending list and backward through an ascending
+	// one both look for smaller values
+	for _, desc := range []bool{false, true} {
+		for _, backward := range []bool{false, true} {
+			keyset.Backward = backward
+			operator := "$gt"
+			if desc != backward {
+				operator = "$lt"
+			}
+
+			want := bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{operator: "ada"}},
+				bson.M{"name": "ada", "_id": bson.M{operator: keyset.Id}},
+			}}
+			got := keysetCondition("name", desc, keyset)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("desc=%v backward=%v: got %v, want %v", desc, backward, got, want)
+			}
+		}
+	}
+}
+
+func TestKeysetSortReversesBackward(t *testing.T) {
+	sort := keysetSort("created_at", true, &Keyset{Backward: true})
+	want := bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+	if !reflect.DeepEqual(sort, want) {
+		t.Errorf("backward sort = %v, want %v", sort, want)
+	}
+
+	documents := []string{"c", "b", "a"}
+	reverseBackward(documents, &Keyset{Backward: true})
+	if !reflect.DeepEqual(documents, []string{"a", "b", "c"}) {
+		t.Errorf("documents read backward = %v, want list order", documents)
+	}
+}
+
+func TestWithKeysetLeavesQueryAlone(t *testing.T) {
+	query := bson.M{"$and": bson.A{bson.M{"$or": bson.A{bson.M{"status": "published"}}}}}
+
+	paged := withKeyset(query, "name", false, &Keyset{SortValue: "ada"})
+
+	if and := paged["$and"].(bson.A); len(and) != 2 {
+		t.Errorf("paged query has %d conditions, want 2", len(and))
+	}
+	if and := query["$and"].(bson.A); len(and) != 1 {
+		t.Errorf("the counting query picked up the keyset condition")
+	}
+}
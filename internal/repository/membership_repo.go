@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *models.Membership) (
+		*models.Membership, error,
+	)
+	CreateMany(ctx context.Context, memberships []*models.Membership) error
+	FindByOrganization(
+		ctx context.Context, organizationId primitive.ObjectID, page int64,
+		pageSize int64,
+	) ([]models.Membership, int64, error)
+	UpdateRole(
+		ctx context.Context, organizationId primitive.ObjectID,
+		userId primitive.ObjectID, role string,
+	) (*models.Membership, error)
+	FindByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.Membership, error,
+	)
+	DeleteByUser(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type membershipRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewMembershipRepo(db *mongo.Database) MembershipRepository {
+	return &membershipRepository{
+		collection: db.Collection("memberships"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *membershipRepository) Create(
+	ctx context.Context, membership *models.Membership,
+) (*models.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	membership.ID = primitive.NewObjectID()
+
+	_, err := r.collection.InsertOne(ctx, membership)
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (r *membershipRepository) CreateMany(
+	ctx context.Context, memberships []*models.Membership,
+) error {
+	if len(memberships) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	documents := make([]interface{}, len(memberships))
+	for i, membership := range memberships {
+		membership.ID = primitive.NewObjectID()
+		documents[i] = membership
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return err
+}
+
+func (r *membershipRepository) FindByOrganization(
+	ctx context.Context, organizationId primitive.ObjectID, page int64,
+	pageSize int64,
+) ([]models.Membership, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"organization_id": organizationId}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []models.Membership
+	err = cursor.All(ctx, &memberships)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if memberships == nil {
+		memberships = []models.Membership{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return memberships, totalCount, nil
+}
+
+func (r *membershipRepository) UpdateRole(
+	ctx context.Context, organizationId primitive.ObjectID,
+	userId primitive.ObjectID, role string,
+) (*models.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"organization_id": organizationId, "user_id": userId}
+	update := bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var membership *models.Membership
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&membership)
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (r *membershipRepository) FindByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []models.Membership
+	err = cursor.All(ctx, &memberships)
+	if err != nil {
+		return nil, err
+	}
+
+	if memberships == nil {
+		memberships = []models.Membership{}
+	}
+
+	return memberships, nil
+}
+
+func (r *membershipRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userId})
+	return err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunMigrations brings documents written by older versions up to date. Each
+// migration only touches documents that still need it, so running them on
+// every start is cheap
+func RunMigrations(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	err := migrateCourseLifecycle(ctx, db)
+	if err != nil {
+		fmt.Println("failed to migrate course lifecycle, " + err.Error())
+		return err
+	}
+
+	err = migrateCoursePrices(ctx, db)
+	if err != nil {
+		fmt.Println("failed to migrate course prices, " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// migrateCourseLifecycle publishes courses created before they had a status,
+// since they were all publicly listed, and backfills their timestamps from
+// the ObjectID creation time
+func migrateCourseLifecycle(ctx context.Context, db *mongo.Database) error {
+	createdAt := bson.M{"$ifNull": bson.A{"$created_at", bson.M{"$toDate": "$_id"}}}
+
+	result, err := db.Collection("courses").UpdateMany(
+		ctx, bson.M{"status": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"status":       models.CourseStatusPublished,
+				"created_at":   createdAt,
+				"updated_at":   bson.M{"$ifNull": bson.A{"$updated_at", createdAt}},
+				"published_at": bson.M{"$ifNull": bson.A{"$published_at", createdAt}},
+			}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.ModifiedCount > 0 {
+		fmt.Printf("✓ Migrated %d courses to the course lifecycle\n", result.ModifiedCount)
+	}
+
+	return nil
+}
+
+// migrateCoursePrices turns prices stored as a bare number of whole units
+// into money in DEFAULT_CURRENCY, for courses and the revisions kept of them
+func migrateCoursePrices(ctx context.Context, db *mongo.Database) error {
+	currency := helpers.DefaultCurrency()
+	factor := models.Currencies[currency].MinorUnitFactor()
+
+	money := func(field string) bson.M {
+		return bson.M{
+			"amount":   bson.M{"$toLong": bson.M{"$multiply": bson.A{field, factor}}},
+			"currency": currency,
+		}
+	}
+
+	courses, err := db.Collection("courses").UpdateMany(
+		ctx, bson.M{"course_price": bson.M{"$type": "number"}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"course_price": money("$course_price")}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := db.Collection("course_revisions").UpdateMany(
+		ctx, bson.M{"snapshot.course_price": bson.M{"$type": "number"}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"snapshot.course_price": money("$snapshot.course_price"),
+			}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if courses.ModifiedCount > 0 || revisions.ModifiedCount > 0 {
+		fmt.Printf(
+			"✓ Migrated %d course and %d revision prices to %s\n",
+			courses.ModifiedCount, revisions.ModifiedCount, currency,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *models.Organization) (
+		*models.Organization, error,
+	)
+	FindAll(ctx context.Context, page int64, pageSize int64) (
+		[]models.Organization, int64, error,
+	)
+	FindOne(ctx context.Context, id primitive.ObjectID) (
+		*models.Organization, error,
+	)
+}
+
+type organizationRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewOrganizationRepo(db *mongo.Database) OrganizationRepository {
+	return &organizationRepository{
+		collection: db.Collection("organizations"),
+		timeout:    10 * time.Second,
+	}
+}
+
+// ownOrganization limits a tenant scoped caller to its own organization
+func ownOrganization(ctx context.Context, filter bson.M) bson.M {
+	id, ok := tenant.FromContext(ctx)
+	if ok {
+		filter["_id"] = id
+	}
+	return filter
+}
+
+func (r *organizationRepository) Create(
+	ctx context.Context, organization *models.Organization,
+) (*models.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	organization.ID = primitive.NewObjectID()
+
+	_, err := r.collection.InsertOne(ctx, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (r *organizationRepository) FindAll(
+	ctx context.Context, page int64, pageSize int64,
+) ([]models.Organization, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := ownOrganization(ctx, bson.M{})
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var organizations []models.Organization
+	err = cursor.All(ctx, &organizations)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if organizations == nil {
+		organizations = []models.Organization{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return organizations, totalCount, nil
+}
+
+func (r *organizationRepository) FindOne(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tenantId, ok := tenant.FromContext(ctx)
+	if ok && tenantId != id {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	var organization *models.Organization
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
@@ -34,6 +34,10 @@ type RefreshTokenRepository interface {
 		ctx context.Context, userID primitive.ObjectID,
 	) ([]*models.RefreshToken, error)
 
+	FindAllByUserID(
+		ctx context.Context, userID primitive.ObjectID,
+	) ([]*models.RefreshToken, error)
+
 	RevokeToken(
 		ctx context.Context, tokenId primitive.ObjectID,
 	) error
@@ -45,6 +49,10 @@ type RefreshTokenRepository interface {
 	DeleteExpiredTokens(ctx context.Context) (
 		int64, error,
 	)
+
+	DeleteAllUserTokens(
+		ctx context.Context, userID primitive.ObjectID,
+	) (int64, error)
 }
 
 func (r *refreshTokenRepository) Create(
@@ -101,6 +109,22 @@ func (r *refreshTokenRepository) FindActiveTokensByUserID(
 	return tokens, nil
 }
 
+func (r *refreshTokenRepository) FindAllByUserID(
+	ctx context.Context, userID primitive.ObjectID,
+) ([]*models.RefreshToken, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*models.RefreshToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
 func (r *refreshTokenRepository) RevokeToken(
 	ctx context.Context, tokenId primitive.ObjectID,
 ) error {
@@ -144,3 +168,13 @@ func (r *refreshTokenRepository) DeleteExpiredTokens(ctx context.Context) (
 	}
 	return result.DeletedCount, nil
 }
+
+func (r *refreshTokenRepository) DeleteAllUserTokens(
+	ctx context.Context, userID primitive.ObjectID,
+) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReviewRepository interface {
+	// Create inserts a review, a duplicate key error means the learner
+	// already reviewed the course
+	Create(ctx context.Context, review *models.Review) (*models.Review, error)
+	FindById(
+		ctx context.Context, courseId primitive.ObjectID,
+		reviewId primitive.ObjectID,
+	) (*models.Review, error)
+	FindByCourse(
+		ctx context.Context, courseId primitive.ObjectID, sort string,
+		page int64, pageSize int64,
+	) ([]models.Review, int64, error)
+	// Update applies update only while the review still has rating,
+	// returning mongo.ErrNoDocuments otherwise, so the course's rating
+	// totals can be adjusted by exactly what changed
+	Update(
+		ctx context.Context, id primitive.ObjectID, rating int, update bson.M,
+	) (*models.Review, error)
+	// SetReply replaces the review's reply, or removes it when reply is nil
+	SetReply(
+		ctx context.Context, courseId primitive.ObjectID,
+		reviewId primitive.ObjectID, reply *models.ReviewReply,
+	) (*models.Review, error)
+	// Delete removes a review and returns it as it was when deleted
+	Delete(ctx context.Context, id primitive.ObjectID) (*models.Review, error)
+	AdjustHelpfulCount(
+		ctx context.Context, id primitive.ObjectID, delta int64,
+	) (*models.Review, error)
+	FindAllByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.Review, error,
+	)
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type reviewRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewReviewRepo(db *mongo.Database) ReviewRepository {
+	return &reviewRepository{
+		collection: db.Collection("reviews"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *reviewRepository) Create(
+	ctx context.Context, review *models.Review,
+) (*models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	review.ID = primitive.NewObjectID()
+	if review.TenantId == nil {
+		review.TenantId = tenantOf(ctx)
+	}
+
+	_, err := r.collection.InsertOne(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (r *reviewRepository) FindById(
+	ctx context.Context, courseId primitive.ObjectID, reviewId primitive.ObjectID,
+) (*models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var review models.Review
+	err := r.collection.FindOne(
+		ctx, inTenant(ctx, bson.M{"_id": reviewId, "course_id": courseId}),
+	).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// FindByCourse lists a course's reviews, newest first or the most helpful
+// first
+func (r *reviewRepository) FindByCourse(
+	ctx context.Context, courseId primitive.ObjectID, sort string, page int64,
+	pageSize int64,
+) ([]models.Review, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{"course_id": courseId})
+
+	order := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	if sort == models.ReviewSortHelpful {
+		order = append(bson.D{{Key: "helpful_count", Value: -1}}, order...)
+	}
+
+	findOptions := options.Find().
+		SetSort(order).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []models.Review
+	err = cursor.All(ctx, &reviews)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if reviews == nil {
+		reviews = []models.Review{}
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, totalCount, nil
+}
+
+func (r *reviewRepository) Update(
+	ctx context.Context, id primitive.ObjectID, rating int, update bson.M,
+) (*models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(ctx, bson.M{"_id": id, "rating": rating})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review models.Review
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepository) SetReply(
+	ctx context.Context, courseId primitive.ObjectID, reviewId primitive.ObjectID,
+	reply *models.ReviewReply,
+) (*models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"reply": ""}}
+	if reply != nil {
+		update = bson.M{"$set": bson.M{"reply": reply}}
+	}
+
+	filter := inTenant(ctx, bson.M{"_id": reviewId, "course_id": courseId})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review models.Review
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).
+		Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepository) Delete(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var review models.Review
+	err := r.collection.FindOneAndDelete(ctx, inTenant(ctx, bson.M{"_id": id})).
+		Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepository) AdjustHelpfulCount(
+	ctx context.Context, id primitive.ObjectID, delta int64,
+) (*models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review models.Review
+	err := r.collection.FindOneAndUpdate(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+		bson.M{"$inc": bson.M{"helpful_count": delta}}, opts,
+	).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepository) FindAllByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.Review, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, inTenant(ctx, bson.M{"user_id": userId}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []models.Review
+	err = cursor.All(ctx, &reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (r *reviewRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(
+		ctx, inTenant(ctx, bson.M{"course_id": bson.M{"$in": courseIds}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every review, or only the tenant's when ctx is scoped to one
+func (r *reviewRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
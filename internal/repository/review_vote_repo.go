@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReviewVoteRepository interface {
+	// Create records a helpful vote, a duplicate key error means the user
+	// already voted for the review
+	Create(ctx context.Context, vote *models.ReviewVote) (*models.ReviewVote, error)
+	// Delete removes the user's vote for a review, reporting whether there
+	// was one
+	Delete(
+		ctx context.Context, reviewId primitive.ObjectID,
+		userId primitive.ObjectID,
+	) (bool, error)
+	FindAllByUser(ctx context.Context, userId primitive.ObjectID) (
+		[]models.ReviewVote, error,
+	)
+	DeleteByUser(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	DeleteByReviews(
+		ctx context.Context, reviewIds []primitive.ObjectID,
+	) (int64, error)
+	DeleteByCourses(
+		ctx context.Context, courseIds []primitive.ObjectID,
+	) (int64, error)
+	Drop(ctx context.Context) error
+}
+
+type reviewVoteRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewReviewVoteRepo(db *mongo.Database) ReviewVoteRepository {
+	return &reviewVoteRepository{
+		collection: db.Collection("review_votes"),
+		timeout:    10 * time.Second,
+	}
+}
+
+func (r *reviewVoteRepository) Create(
+	ctx context.Context, vote *models.ReviewVote,
+) (*models.ReviewVote, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	vote.ID = primitive.NewObjectID()
+	if vote.TenantId == nil {
+		vote.TenantId = tenantOf(ctx)
+	}
+
+	_, err := r.collection.InsertOne(ctx, vote)
+	if err != nil {
+		return nil, err
+	}
+
+	return vote, nil
+}
+
+func (r *reviewVoteRepository) Delete(
+	ctx context.Context, reviewId primitive.ObjectID, userId primitive.ObjectID,
+) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteOne(
+		ctx, inTenant(ctx, bson.M{"review_id": reviewId, "user_id": userId}),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return deleteResult.DeletedCount > 0, nil
+}
+
+func (r *reviewVoteRepository) FindAllByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.ReviewVote, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, inTenant(ctx, bson.M{"user_id": userId}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var votes []models.ReviewVote
+	err = cursor.All(ctx, &votes)
+	if err != nil {
+		return nil, err
+	}
+
+	return votes, nil
+}
+
+func (r *reviewVoteRepository) DeleteByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) (int64, error) {
+	return r.deleteMany(ctx, bson.M{"user_id": userId})
+}
+
+func (r *reviewVoteRepository) DeleteByReviews(
+	ctx context.Context, reviewIds []primitive.ObjectID,
+) (int64, error) {
+	return r.deleteMany(ctx, bson.M{"review_id": bson.M{"$in": reviewIds}})
+}
+
+func (r *reviewVoteRepository) DeleteByCourses(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) (int64, error) {
+	return r.deleteMany(ctx, bson.M{"course_id": bson.M{"$in": courseIds}})
+}
+
+func (r *reviewVoteRepository) deleteMany(
+	ctx context.Context, filter bson.M,
+) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleteResult, err := r.collection.DeleteMany(ctx, inTenant(ctx, filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
+
+// Drop deletes every vote, or only the tenant's when ctx is scoped to one
+func (r *reviewVoteRepository) Drop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// inTenant scopes a filter to the organization carried by ctx. Every query
+// on tenant owned collections goes through it, so a request for one tenant
+// can't read or change another tenant's documents. Contexts scoped to no
+// tenant only match documents outside every organization
+func inTenant(ctx context.Context, filter bson.M) bson.M {
+	id, ok := tenant.FromContext(ctx)
+	if ok {
+		if id.IsZero() {
+			filter["tenant_id"] = nil
+		} else {
+			filter["tenant_id"] = id
+		}
+	}
+	return filter
+}
+
+// tenantOf returns the tenant new documents created with ctx belong to
+func tenantOf(ctx context.Context) *primitive.ObjectID {
+	id, ok := tenant.FromContext(ctx)
+	if !ok || id.IsZero() {
+		return nil
+	}
+	return &id
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"regexp"
 	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/models"
@@ -16,20 +18,63 @@ type userRepo struct {
 	timeout    time.Duration
 }
 
+// UserFilter narrows and orders the users returned by GetAllUsers, zero
+// values mean "no restriction"
+type UserFilter struct {
+	Role   string
+	Search string
+	// SearchEmail extends Search to email addresses as well as names
+	SearchEmail bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      string
+	SortDesc    bool
+	// Keyset pages from a position in the list instead of skipping
+	Keyset *Keyset
+}
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
-	GetAllUsers(ctx context.Context, page int64, pageSize int64) (
-		[]models.User, int64, error,
-	)
+	GetAllUsers(
+		ctx context.Context, filter UserFilter, page int64, pageSize int64,
+	) ([]models.User, int64, error)
 	GetOneUser(ctx context.Context, id primitive.ObjectID) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByEmailChangeToken(ctx context.Context, tokenHash string) (
+		*models.User, error,
+	)
+	GetUserByPasswordSetupToken(ctx context.Context, tokenHash string) (
+		*models.User, error,
+	)
+	FindExistingEmails(ctx context.Context, emails []string) (
+		map[string]bool, error,
+	)
+	CreateUsers(ctx context.Context, users []*models.User) (map[int]error, error)
 	UpdateOneUser(ctx context.Context, id primitive.ObjectID, update bson.M) (
 		*models.User, error,
 	)
 	DeleteOneUser(ctx context.Context, id primitive.ObjectID) error
+	SoftDeleteUser(ctx context.Context, id primitive.ObjectID) error
+	RestoreUser(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindDeletedBefore(ctx context.Context, cutoff time.Time) (
+		[]models.User, error,
+	)
+	// MarkPurgeBlocked records that a deleted user can't be purged yet and
+	// reports whether it wasn't recorded before
+	MarkPurgeBlocked(ctx context.Context, id primitive.ObjectID) (bool, error)
+	FindDueForDeletion(ctx context.Context, now time.Time) (
+		[]models.User, error,
+	)
 	DropUserCollection(ctx context.Context) error
 }
 
+// notDeleted scopes a filter to users that have not been soft deleted,
+// every read goes through it unless it explicitly targets deleted users
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return filter
+}
+
 func NewUserRepo(db *mongo.Database) UserRepository {
 	return &userRepo{
 		collection: db.Collection("users"),
@@ -44,6 +89,10 @@ func (r *userRepo) CreateUser(
 	defer cancel()
 
 	user.ID = primitive.NewObjectID()
+	tenantId := tenantOf(ctx)
+	if tenantId != nil {
+		user.TenantId = tenantId
+	}
 
 	_, err := r.collection.InsertOne(ctx, user)
 
@@ -54,24 +103,69 @@ func (r *userRepo) CreateUser(
 	return user, nil
 }
 
+// GetAllUsers returns up to pageSize+1 users, the extra one only tells
+// whether another page follows. Users read through a backward keyset come
+// back in list order, so the extra one is then the first
 func (r *userRepo) GetAllUsers(
-	ctx context.Context, page int64, pageSize int64,
+	ctx context.Context, filter UserFilter, page int64, pageSize int64,
 ) ([]models.User, int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	skip := (page - 1) * pageSize
 
+	query := notDeleted(inTenant(ctx, bson.M{}))
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.Search != "" {
+		// An unanchored regex can't seek, but it is matched against the
+		// name/email index keys instead of every document
+		pattern := primitive.Regex{
+			Pattern: regexp.QuoteMeta(filter.Search), Options: "i",
+		}
+		if filter.SearchEmail {
+			query["$or"] = bson.A{
+				bson.M{"name": pattern},
+				bson.M{"email": pattern},
+			}
+		} else {
+			query["name"] = pattern
+		}
+	}
+	if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+		createdAt := bson.M{}
+		if filter.CreatedFrom != nil {
+			createdAt["$gte"] = *filter.CreatedFrom
+		}
+		if filter.CreatedTo != nil {
+			createdAt["$lte"] = *filter.CreatedTo
+		}
+		query["created_at"] = createdAt
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+
 	findOptions := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: -1}}). // Sort by newest first
-		SetSkip(skip).
-		SetLimit(pageSize)
+		// _id breaks ties so pages stay stable when sort values repeat
+		SetSort(keysetSort(sortBy, filter.SortDesc, filter.Keyset)).
+		// One more than a page tells the caller whether the list goes on
+		SetLimit(pageSize + 1)
+	if filter.Keyset == nil {
+		findOptions.SetSkip(skip)
+	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
-	defer cursor.Close(ctx)
+	cursor, err := r.collection.Find(
+		ctx, withKeyset(query, sortBy, filter.SortDesc, filter.Keyset),
+		findOptions,
+	)
 	if err != nil {
 		return nil, 0, err
 	}
+	defer cursor.Close(ctx)
 
 	var users []models.User
 
@@ -83,8 +177,9 @@ func (r *userRepo) GetAllUsers(
 	if users == nil {
 		users = []models.User{}
 	}
+	reverseBackward(users, filter.Keyset)
 
-	totalCount, err := r.collection.CountDocuments(ctx, bson.M{})
+	totalCount, err := r.collection.CountDocuments(ctx, query)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -99,7 +194,9 @@ func (r *userRepo) GetOneUser(
 	defer cancel()
 
 	var user *models.User
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	err := r.collection.FindOne(
+		ctx, notDeleted(inTenant(ctx, bson.M{"_id": id})),
+	).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +210,9 @@ func (r *userRepo) GetUserByEmail(
 	defer cancel()
 
 	var user *models.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := r.collection.FindOne(
+		ctx, notDeleted(inTenant(ctx, bson.M{"email": email})),
+	).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +220,113 @@ func (r *userRepo) GetUserByEmail(
 	return user, nil
 }
 
+func (r *userRepo) GetUserByEmailChangeToken(
+	ctx context.Context, tokenHash string,
+) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var user *models.User
+	err := r.collection.FindOne(
+		ctx, notDeleted(
+			inTenant(ctx, bson.M{"pending_email.token_hash": tokenHash}),
+		),
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepo) GetUserByPasswordSetupToken(
+	ctx context.Context, tokenHash string,
+) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var user *models.User
+	err := r.collection.FindOne(
+		ctx, notDeleted(
+			inTenant(ctx, bson.M{"password_setup.token_hash": tokenHash}),
+		),
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// FindExistingEmails reports which of the given emails are already taken.
+// Soft deleted users are included since they still hold the unique index,
+// and so are other tenants' users because emails are unique deployment wide
+func (r *userRepo) FindExistingEmails(
+	ctx context.Context, emails []string,
+) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, bson.M{"email": bson.M{"$in": emails}},
+		options.Find().SetProjection(bson.M{"email": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	existing := make(map[string]bool)
+	for cursor.Next(ctx) {
+		var user models.User
+		err = cursor.Decode(&user)
+		if err != nil {
+			return nil, err
+		}
+		existing[user.Email] = true
+	}
+
+	return existing, cursor.Err()
+}
+
+// CreateUsers inserts users in a single unordered batch. Per document
+// failures, such as a duplicate email, are returned by index without
+// stopping the rest of the batch
+func (r *userRepo) CreateUsers(
+	ctx context.Context, users []*models.User,
+) (map[int]error, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tenantId := tenantOf(ctx)
+	documents := make([]interface{}, len(users))
+	for i, user := range users {
+		user.ID = primitive.NewObjectID()
+		if tenantId != nil {
+			user.TenantId = tenantId
+		}
+		documents[i] = user
+	}
+
+	failures := make(map[int]error)
+	_, err := r.collection.InsertMany(
+		ctx, documents, options.InsertMany().SetOrdered(false),
+	)
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			failures[writeErr.Index] = writeErr
+		}
+		return failures, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
 func (r *userRepo) UpdateOneUser(
 	ctx context.Context, id primitive.ObjectID, update bson.M,
 ) (
@@ -129,7 +335,9 @@ func (r *userRepo) UpdateOneUser(
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	updateResult, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	updateResult, err := r.collection.UpdateOne(
+		ctx, notDeleted(inTenant(ctx, bson.M{"_id": id})), update,
+	)
 
 	if err != nil {
 		return nil, err
@@ -150,7 +358,9 @@ func (r *userRepo) DeleteOneUser(
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	deleteResult, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	deleteResult, err := r.collection.DeleteOne(
+		ctx, inTenant(ctx, bson.M{"_id": id}),
+	)
 	if err != nil {
 		return err
 	}
@@ -161,14 +371,128 @@ func (r *userRepo) DeleteOneUser(
 	return nil
 }
 
-func (r *userRepo) DropUserCollection(ctx context.Context) error {
+func (r *userRepo) SoftDeleteUser(
+	ctx context.Context, id primitive.ObjectID,
+) error {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	err := r.collection.Drop(ctx)
+	now := time.Now()
+	updateResult, err := r.collection.UpdateOne(
+		ctx, notDeleted(inTenant(ctx, bson.M{"_id": id})),
+		bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}},
+	)
 	if err != nil {
 		return err
 	}
+	if updateResult.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
 
 	return nil
 }
+
+func (r *userRepo) RestoreUser(
+	ctx context.Context, id primitive.ObjectID,
+) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := inTenant(
+		ctx, bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}},
+	)
+	update := bson.M{
+		"$unset": bson.M{
+			"deleted_at": "", "deletion_scheduled_at": "", "purge_blocked_at": "",
+		},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user *models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *userRepo) FindDeletedBefore(
+	ctx context.Context, cutoff time.Time,
+) ([]models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, inTenant(ctx, bson.M{"deleted_at": bson.M{"$lte": cutoff}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *userRepo) MarkPurgeBlocked(
+	ctx context.Context, id primitive.ObjectID,
+) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	updateResult, err := r.collection.UpdateOne(
+		ctx, inTenant(ctx, bson.M{
+			"_id":              id,
+			"deleted_at":       bson.M{"$exists": true},
+			"purge_blocked_at": bson.M{"$exists": false},
+		}),
+		bson.M{"$set": bson.M{"purge_blocked_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return updateResult.ModifiedCount > 0, nil
+}
+
+func (r *userRepo) FindDueForDeletion(
+	ctx context.Context, now time.Time,
+) ([]models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(
+		ctx, notDeleted(
+			inTenant(ctx, bson.M{"deletion_scheduled_at": bson.M{"$lte": now}}),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// DropUserCollection deletes every user, or only the tenant's users when ctx
+// is scoped to one
+func (r *userRepo) DropUserCollection(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, inTenant(ctx, bson.M{}))
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/AhmedHossam777/go-mongo/internal/helpers"
 	"github.com/AhmedHossam777/go-mongo/internal/models"
 	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -40,26 +42,48 @@ type AuthService interface {
 type authService struct {
 	userService      UserService
 	refreshTokenRepo repository.RefreshTokenRepository
+	invitationRepo   repository.InvitationRepository
 }
 
 func NewAuthService(
 	userService UserService, refreshTokenRepo repository.RefreshTokenRepository,
+	invitationRepo repository.InvitationRepository,
 ) AuthService {
 	return &authService{
 		userService: userService, refreshTokenRepo: refreshTokenRepo,
+		invitationRepo: invitationRepo,
 	}
 }
 
 func (s *authService) Register(
 	ctx context.Context, registerDto dto.RegisterDto, r *http.Request,
 ) (*dto.AuthResponse, error) {
+	// Emails are unique across tenants, and the only tenant an account can
+	// join on registration is the one its invitation was sent for
+	ctx = tenant.Unscoped(ctx)
+
 	user, _ := s.userService.GetUserByEmail(ctx, registerDto.Email)
 	if user != nil {
 		return nil, ErrEmailAlreadyExists
 	}
 
+	invitation, err := s.claimInvitation(ctx, registerDto)
+	if err != nil {
+		return nil, err
+	}
+
+	role := "user"
+	if invitation != nil {
+		role = invitation.Role
+		// The account joins the organization the invitation was sent for
+		if invitation.TenantId != nil {
+			ctx = tenant.WithTenant(ctx, *invitation.TenantId)
+		}
+	}
+
 	hashedPassword, err := helpers.HashPassword(registerDto.Password)
 	if err != nil {
+		s.releaseInvitation(invitation)
 		return nil, err
 	}
 
@@ -67,13 +91,14 @@ func (s *authService) Register(
 		Name:      registerDto.Name,
 		Email:     registerDto.Email,
 		Password:  hashedPassword,
-		Role:      "user",
+		Role:      role,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	createdUser, err := s.userService.CreateUser(ctx, userModel)
 	if err != nil {
+		s.releaseInvitation(invitation)
 		return nil, err
 	}
 
@@ -94,9 +119,66 @@ func (s *authService) Register(
 	return authResponse, nil
 }
 
+// claimInvitation uses up the invitation sent with a registration. Without
+// a token it returns nil, unless registration is invite only
+func (s *authService) claimInvitation(
+	ctx context.Context, registerDto dto.RegisterDto,
+) (*models.Invitation, error) {
+	if registerDto.InviteToken == "" {
+		mode := helpers.GetEnvString("REGISTRATION_MODE", "open")
+		if mode == RegistrationModeInviteOnly {
+			return nil, ErrInvitationRequired
+		}
+		return nil, nil
+	}
+
+	invitation, err := s.invitationRepo.FindPendingByToken(
+		ctx, helpers.HashToken(registerDto.InviteToken),
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(invitation.Email, strings.TrimSpace(registerDto.Email)) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	// Another registration may have used the token since it was read
+	claimed, err := s.invitationRepo.Accept(ctx, invitation.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// releaseInvitation makes a claimed invitation usable again after the
+// registration it was claimed for failed
+func (s *authService) releaseInvitation(invitation *models.Invitation) {
+	if invitation == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := s.invitationRepo.Release(ctx, invitation.ID)
+	if err != nil {
+		log.Printf("failed to release invitation %s: %v", invitation.ID.Hex(), err)
+	}
+}
+
 func (s *authService) Login(
 	ctx context.Context, loginDto dto.LoginDto, r *http.Request,
 ) (*dto.AuthResponse, error) {
+	// The account's tenant is only known once it is found
+	ctx = tenant.Unscoped(ctx)
 
 	existedUser, err := s.userService.GetUserByEmail(ctx, loginDto.Email)
 	if errors.Is(err, mongo.ErrNoDocuments) || existedUser == nil {
@@ -108,6 +190,16 @@ func (s *authService) Login(
 		return nil, ErrInvalidCredentials
 	}
 
+	// Logging in during the grace period cancels a pending self deletion
+	deletionCancelled := false
+	if existedUser.DeletionScheduledAt != nil {
+		_, err = s.userService.CancelScheduledDeletion(ctx, existedUser.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		deletionCancelled = true
+	}
+
 	tokenPair, err := s.createTokenPair(existedUser, r)
 
 	if err != nil {
@@ -121,6 +213,7 @@ func (s *authService) Login(
 			Name:  existedUser.Name,
 			Email: existedUser.Email,
 		},
+		DeletionCancelled: deletionCancelled,
 	}
 
 	return authResponse, nil
@@ -132,6 +225,7 @@ func (s *authService) RefreshTokens(
 	if refreshToken == "" {
 		return nil, errors.New("refresh token is required")
 	}
+	ctx = tenant.Unscoped(ctx)
 
 	matchedToken, err := s.findValidRefreshToken(ctx, refreshToken)
 	if err != nil {
@@ -204,7 +298,9 @@ func (s *authService) createTokenPair(user *models.User, r *http.Request) (
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	accessToken, err := helpers.GenerateToken(user.ID, user.Email, user.Role)
+	accessToken, err := helpers.GenerateToken(
+		user.ID, user.Email, user.Role, user.TenantId,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrCertificateNotFound       = errors.New("certificate not found")
+	ErrCourseNotCompleted        = errors.New("course has not been completed yet")
+	ErrCertificateRevoked        = errors.New("certificate has been revoked")
+	ErrCertificateAlreadyRevoked = errors.New("certificate is already revoked")
+)
+
+// certificateCodeAlphabet is Crockford's base32, it leaves out letters that
+// are easily mistaken for digits when a code is typed in
+const certificateCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// certificateAttempts bounds how often a certificate is inserted again when
+// its random code turns out to be taken
+const certificateAttempts = 3
+
+type CertificateService interface {
+	// IssueCertificate issues the certificate for a completed enrollment,
+	// or returns the one already issued
+	IssueCertificate(ctx context.Context, enrollment *models.Enrollment) (
+		*models.Certificate, error,
+	)
+	GetCertificate(ctx context.Context, courseId string, userId string) (
+		*models.Certificate, error,
+	)
+	RenderCertificate(ctx context.Context, courseId string, userId string) (
+		*models.Certificate, []byte, error,
+	)
+	GetUserCertificates(ctx context.Context, userId string) (
+		[]models.Certificate, error,
+	)
+	VerifyCertificate(ctx context.Context, code string) (
+		*dto.CertificateVerification, error,
+	)
+	RevokeCertificate(
+		ctx context.Context, code string, adminId string, reason string,
+	) (*models.Certificate, error)
+}
+
+type certificateService struct {
+	repo           repository.CertificateRepository
+	enrollmentRepo repository.EnrollmentRepository
+	courseRepo     repository.CourseRepository
+	userRepo       repository.UserRepository
+}
+
+func NewCertificateService(
+	repo repository.CertificateRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	courseRepo repository.CourseRepository, userRepo repository.UserRepository,
+) CertificateService {
+	return &certificateService{
+		repo:           repo,
+		enrollmentRepo: enrollmentRepo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+	}
+}
+
+func (s *certificateService) IssueCertificate(
+	ctx context.Context, enrollment *models.Enrollment,
+) (*models.Certificate, error) {
+	certificate, err := s.repo.FindOne(ctx, enrollment.UserId, enrollment.CourseId)
+	if err == nil {
+		return certificate, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	if enrollment.Status != models.EnrollmentStatusCompleted {
+		return nil, ErrCourseNotCompleted
+	}
+
+	course, err := s.courseRepo.FindOne(ctx, enrollment.CourseId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	learner, err := s.userRepo.GetOneUser(ctx, enrollment.UserId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	instructorName := ""
+	instructor, err := s.userRepo.GetOneUser(ctx, course.InstructorId)
+	if err == nil {
+		instructorName = instructor.Name
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	completedAt := time.Now()
+	if enrollment.CompletedAt != nil {
+		completedAt = *enrollment.CompletedAt
+	}
+
+	for attempt := 0; attempt < certificateAttempts; attempt++ {
+		code, err := certificateCode()
+		if err != nil {
+			return nil, err
+		}
+
+		certificate, err = s.repo.Create(ctx, &models.Certificate{
+			Code:           code,
+			UserId:         enrollment.UserId,
+			CourseId:       course.ID,
+			TenantId:       course.TenantId,
+			LearnerName:    learner.Name,
+			CourseName:     course.CourseName,
+			InstructorName: instructorName,
+			CompletedAt:    completedAt,
+			IssuedAt:       time.Now(),
+		})
+		if err == nil {
+			return certificate, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		// Either another request issued it first or the code is taken
+		certificate, err = s.repo.FindOne(ctx, enrollment.UserId, course.ID)
+		if err == nil {
+			return certificate, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("failed to generate a unique certificate code")
+}
+
+// GetCertificate returns the learner's certificate for a course, issuing
+// it if the course is completed and it wasn't issued yet
+func (s *certificateService) GetCertificate(
+	ctx context.Context, courseId string, userId string,
+) (*models.Certificate, error) {
+	courseObjId, err := primitive.ObjectIDFromHex(courseId)
+	if err != nil {
+		return nil, ErrInvalidCourseID
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	certificate, err := s.repo.FindOne(ctx, userObjId, courseObjId)
+	if err == nil {
+		return certificate, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	enrollment, err := s.enrollmentRepo.FindOne(ctx, userObjId, courseObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.IssueCertificate(ctx, enrollment)
+}
+
+// RenderCertificate lays the learner's certificate out as a PDF. Revoked
+// certificates aren't rendered
+func (s *certificateService) RenderCertificate(
+	ctx context.Context, courseId string, userId string,
+) (*models.Certificate, []byte, error) {
+	certificate, err := s.GetCertificate(ctx, courseId, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if certificate.Revoked() {
+		return nil, nil, ErrCertificateRevoked
+	}
+
+	return certificate, certificatePDF(certificate), nil
+}
+
+func (s *certificateService) GetUserCertificates(
+	ctx context.Context, userId string,
+) ([]models.Certificate, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	return s.repo.FindByUser(ctx, userObjId)
+}
+
+// VerifyCertificate tells anybody holding a code whether it belongs to a
+// genuine certificate and what it certifies
+func (s *certificateService) VerifyCertificate(
+	ctx context.Context, code string,
+) (*dto.CertificateVerification, error) {
+	// Anyone holding a code may check it, whichever school issued it
+	ctx = tenant.Unscoped(ctx)
+
+	certificate, err := s.repo.FindByCode(ctx, normalizeCertificateCode(code))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCertificateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CertificateVerification{
+		Code:             certificate.Code,
+		Valid:            !certificate.Revoked(),
+		LearnerName:      certificate.LearnerName,
+		CourseName:       certificate.CourseName,
+		InstructorName:   certificate.InstructorName,
+		CompletedAt:      certificate.CompletedAt,
+		IssuedAt:         certificate.IssuedAt,
+		RevokedAt:        certificate.RevokedAt,
+		RevocationReason: certificate.RevocationReason,
+	}, nil
+}
+
+func (s *certificateService) RevokeCertificate(
+	ctx context.Context, code string, adminId string, reason string,
+) (*models.Certificate, error) {
+	adminObjId, err := primitive.ObjectIDFromHex(adminId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	code = normalizeCertificateCode(code)
+	certificate, err := s.repo.Revoke(ctx, code, adminObjId, reason)
+	if err == nil {
+		return certificate, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Tell a missing certificate from one that was revoked before
+	_, err = s.repo.FindByCode(ctx, code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCertificateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrCertificateAlreadyRevoked
+}
+
+// issueCertificate issues the certificate of an enrollment that was just
+// completed. A failure only delays it, the certificate is issued when the
+// learner first asks for it
+func issueCertificate(
+	ctx context.Context, certificates CertificateService,
+	enrollment *models.Enrollment,
+) {
+	_, err := certificates.IssueCertificate(ctx, enrollment)
+	if err != nil {
+		log.Printf(
+			"failed to issue certificate for enrollment %s: %v",
+			enrollment.ID.Hex(), err,
+		)
+	}
+}
+
+// certificateCode returns a random code such as 7Q2M-XK4D-90HT
+func certificateCode() (string, error) {
+	random := make([]byte, 12)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, b := range random {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(certificateCodeAlphabet[int(b)%len(certificateCodeAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// normalizeCertificateCode accepts codes typed in lower case, with spaces,
+// or with the letters O, I and L where the digits 0 and 1 belong
+func normalizeCertificateCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("O", "0", "I", "1", "L", "1", " ", "").
+		Replace(code)
+}
+
+// certificatePDF lays a certificate out on a landscape A4 page
+func certificatePDF(certificate *models.Certificate) []byte {
+	const width, height = 842.0, 595.0
+	const center, textWidth = width / 2, width - 160
+
+	pdf := helpers.NewPDF(
+		width, height, "Certificate of Completion - "+certificate.CourseName,
+	)
+
+	pdf.SetColor(0.13, 0.27, 0.49)
+	pdf.Rect(30, 30, width-60, height-60, 3)
+	pdf.Rect(40, 40, width-80, height-80, 1)
+
+	pdf.CenteredText(center, 460, helpers.FontBold, 32, textWidth,
+		"CERTIFICATE OF COMPLETION")
+
+	pdf.SetColor(0.2, 0.2, 0.2)
+	pdf.CenteredText(center, 410, helpers.FontRegular, 14, textWidth,
+		"This certifies that")
+	pdf.CenteredText(center, 360, helpers.FontBold, 34, textWidth,
+		certificate.LearnerName)
+	pdf.Line(center-200, 348, center+200, 348, 0.75)
+	pdf.CenteredText(center, 315, helpers.FontRegular, 14, textWidth,
+		"has successfully completed the course")
+	pdf.CenteredText(center, 270, helpers.FontBold, 24, textWidth,
+		certificate.CourseName)
+
+	if certificate.InstructorName != "" {
+		pdf.CenteredText(center, 220, helpers.FontRegular, 14, textWidth,
+			"taught by "+certificate.InstructorName)
+	}
+	pdf.CenteredText(center, 195, helpers.FontRegular, 14, textWidth,
+		"Completed on "+certificate.CompletedAt.UTC().Format("January 2, 2006"))
+
+	pdf.SetColor(0.4, 0.4, 0.4)
+	pdf.CenteredText(center, 95, helpers.FontBold, 11, textWidth,
+		"Verification code: "+certificate.Code)
+	pdf.CenteredText(center, 78, helpers.FontRegular, 10, textWidth,
+		"Verify at "+helpers.AppURL("/api/v1/certificates/"+certificate.Code))
+
+	return pdf.Bytes()
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrOwnerNotInstructor = errors.New("new owner must be an instructor")
+
+// cloneNameAttempts bounds how many "(copy n)" names are tried before a
+// clone without a chosen name gives up
+const cloneNameAttempts = 10
+
+type CourseCloneService interface {
+	// CloneCourse copies a course with its content into a new draft course
+	CloneCourse(
+		ctx context.Context, courseId string, callerId string, callerRole string,
+		cloneDto *dto.CloneCourseDto,
+	) (*models.Course, error)
+}
+
+type courseCloneService struct {
+	courseRepo       repository.CourseRepository
+	curriculumRepo   repository.CurriculumRepository
+	materialRepo     repository.CourseMaterialRepository
+	materialFileRepo repository.FileRepository
+	userRepo         repository.UserRepository
+}
+
+func NewCourseCloneService(
+	courseRepo repository.CourseRepository,
+	curriculumRepo repository.CurriculumRepository,
+	materialRepo repository.CourseMaterialRepository,
+	materialFileRepo repository.FileRepository,
+	userRepo repository.UserRepository,
+) CourseCloneService {
+	return &courseCloneService{
+		courseRepo:       courseRepo,
+		curriculumRepo:   curriculumRepo,
+		materialRepo:     materialRepo,
+		materialFileRepo: materialFileRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// CloneCourse copies the course's details, and unless turned off its
+// curriculum, materials and price, into a draft owned by the caller or by
+// whoever an admin picks. Enrollments, reviews and revisions stay with the
+// original. A copy that fails half way is removed again
+func (s *courseCloneService) CloneCourse(
+	ctx context.Context, courseId string, callerId string, callerRole string,
+	cloneDto *dto.CloneCourseDto,
+) (*models.Course, error) {
+	original, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(original, callerId, callerRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	callerObjId, err := primitive.ObjectIDFromHex(callerId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	ownerId, err := s.cloneOwner(ctx, callerObjId, callerRole, cloneDto.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := &models.Course{
+		Description:   original.Description,
+		Tags:          original.Tags,
+		Category:      original.Category,
+		Level:         original.Level,
+		Language:      original.Language,
+		InstructorId:  ownerId,
+		Status:        models.CourseStatusDraft,
+		Prerequisites: original.Prerequisites,
+		TenantId:      original.TenantId,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	// Without pricing the copy keeps the currency but has to be priced
+	// before it can be published
+	clone.Price = models.Money{Currency: original.Price.Currency}
+	if copies(cloneDto.Pricing) {
+		clone.Price = original.Price
+		clone.PriceList = original.PriceList
+	}
+
+	clone, err = s.createClone(ctx, clone, original.CourseName, cloneDto.CourseName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.copyContent(ctx, original, clone, callerObjId, cloneDto)
+	if err != nil {
+		s.removeClone(ctx, clone)
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+// cloneOwner picks who owns the copy, only admins choose someone other
+// than themselves and the owner has to be able to teach
+func (s *courseCloneService) cloneOwner(
+	ctx context.Context, callerId primitive.ObjectID, callerRole string,
+	ownerId string,
+) (primitive.ObjectID, error) {
+	if ownerId == "" || ownerId == callerId.Hex() {
+		return callerId, nil
+	}
+
+	if callerRole != "admin" {
+		return primitive.NilObjectID, ErrForbidden
+	}
+
+	ownerObjId, err := primitive.ObjectIDFromHex(ownerId)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidUserID
+	}
+
+	owner, err := s.userRepo.GetOneUser(ctx, ownerObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, ErrUserNotFound
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if owner.Role != "instructor" && owner.Role != "admin" {
+		return primitive.NilObjectID, ErrOwnerNotInstructor
+	}
+
+	return owner.ID, nil
+}
+
+// createClone stores the copy under the chosen name, or under the first
+// free "(copy)" name when none was chosen
+func (s *courseCloneService) createClone(
+	ctx context.Context, clone *models.Course, originalName string,
+	chosenName string,
+) (*models.Course, error) {
+	if chosenName != "" {
+		clone.CourseName = chosenName
+		created, err := s.courseRepo.Create(ctx, clone)
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrCourseNameTaken
+		}
+		return created, err
+	}
+
+	for attempt := 1; attempt <= cloneNameAttempts; attempt++ {
+		clone.CourseName = copyName(originalName, attempt)
+		created, err := s.courseRepo.Create(ctx, clone)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		return created, err
+	}
+
+	return nil, ErrCourseNameTaken
+}
+
+func (s *courseCloneService) copyContent(
+	ctx context.Context, original *models.Course, clone *models.Course,
+	callerId primitive.ObjectID, cloneDto *dto.CloneCourseDto,
+) error {
+	if copies(cloneDto.Curriculum) {
+		err := s.copyCurriculum(ctx, original.ID, clone)
+		if err != nil {
+			return err
+		}
+	}
+
+	if copies(cloneDto.Materials) {
+		err := s.copyMaterials(ctx, original.ID, clone, callerId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyCurriculum copies the sections and lessons under new IDs, so
+// progress in the original doesn't count towards the copy
+func (s *courseCloneService) copyCurriculum(
+	ctx context.Context, originalId primitive.ObjectID, clone *models.Course,
+) error {
+	curriculum, err := s.curriculumRepo.FindByCourse(ctx, originalId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sections := make([]models.Section, len(curriculum.Sections))
+	for i, section := range curriculum.Sections {
+		lessons := make([]models.Lesson, len(section.Lessons))
+		for j, lesson := range section.Lessons {
+			lesson.ID = primitive.NewObjectID()
+			lesson.CreatedAt = now
+			lesson.UpdatedAt = now
+			lessons[j] = lesson
+		}
+		section.ID = primitive.NewObjectID()
+		section.Lessons = lessons
+		section.CreatedAt = now
+		section.UpdatedAt = now
+		sections[i] = section
+	}
+
+	copied := &models.Curriculum{
+		CourseId: clone.ID, TenantId: clone.TenantId, Sections: sections,
+	}
+	copied.Renumber()
+
+	return s.curriculumRepo.Save(ctx, copied)
+}
+
+// copyMaterials stores a copy of every material's content for the clone,
+// owned by the clone's instructor
+func (s *courseCloneService) copyMaterials(
+	ctx context.Context, originalId primitive.ObjectID, clone *models.Course,
+	callerId primitive.ObjectID,
+) error {
+	materials, err := s.materialRepo.FindByCourses(
+		ctx, []primitive.ObjectID{originalId},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, material := range materials {
+		file, err := s.copyMaterialFile(ctx, material, clone.InstructorId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Content that went missing can't be copied, the original
+			// can't serve it either
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.materialRepo.Create(ctx, &models.CourseMaterial{
+			CourseId:    clone.ID,
+			TenantId:    clone.TenantId,
+			FileId:      file.ID,
+			Filename:    material.Filename,
+			ContentType: material.ContentType,
+			Size:        file.Length,
+			UploadedBy:  callerId,
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			_ = s.materialFileRepo.Delete(ctx, file.ID)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *courseCloneService) copyMaterialFile(
+	ctx context.Context, material models.CourseMaterial,
+	ownerId primitive.ObjectID,
+) (*models.File, error) {
+	_, source, err := s.materialFileRepo.Open(ctx, material.FileId)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	return s.materialFileRepo.Upload(
+		ctx, material.Filename, source,
+		models.FileMetadata{ContentType: material.ContentType, OwnerId: ownerId},
+	)
+}
+
+// removeClone deletes a copy that couldn't be completed. Every part is
+// tried even when another fails, failures are only logged so that the
+// error that stopped the copy gets reported
+func (s *courseCloneService) removeClone(
+	ctx context.Context, clone *models.Course,
+) {
+	courseIds := []primitive.ObjectID{clone.ID}
+
+	materials, err := s.materialRepo.FindByCourses(ctx, courseIds)
+	if err == nil {
+		_, err = s.materialRepo.DeleteByCourses(ctx, courseIds)
+	}
+	if err == nil {
+		err = deleteMaterialFiles(ctx, s.materialFileRepo, materials)
+	}
+	if err != nil {
+		log.Printf(
+			"failed to remove materials of course clone %s: %v",
+			clone.ID.Hex(), err,
+		)
+	}
+
+	_, err = s.curriculumRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		log.Printf(
+			"failed to remove curriculum of course clone %s: %v",
+			clone.ID.Hex(), err,
+		)
+	}
+
+	err = s.courseRepo.DeleteOne(ctx, clone.ID)
+	if err != nil {
+		log.Printf("failed to remove course clone %s: %v", clone.ID.Hex(), err)
+	}
+}
+
+// copyName names the attempt-th try at a free name for a copy, shortening
+// the original's name to stay within the course name limit
+func copyName(originalName string, attempt int) string {
+	suffix := " (copy)"
+	if attempt > 1 {
+		suffix = fmt.Sprintf(" (copy %d)", attempt)
+	}
+
+	name := []rune(originalName)
+	if limit := 100 - len(suffix); len(name) > limit {
+		name = name[:limit]
+	}
+
+	return string(name) + suffix
+}
+
+// copies reports whether an optional copy flag is on, flags left out are
+func copies(flag *bool) bool {
+	return flag == nil || *flag
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrMaterialNotFound      = errors.New("material not found")
+	ErrInvalidMaterialID     = errors.New("invalid material ID")
+	ErrMaterialTooLarge      = errors.New("material exceeds the maximum allowed size")
+	ErrMaterialQuotaExceeded = errors.New("course has no storage left for this material")
+	ErrInvalidMaterialType   = errors.New("unsupported material type")
+	ErrEmptyMaterial         = errors.New("material is empty")
+)
+
+// allowedMaterialTypes are the sniffed content types accepted as course
+// materials
+var allowedMaterialTypes = map[string]bool{
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"video/mp4":                 true,
+	"video/webm":                true,
+	"audio/mpeg":                true,
+	"audio/wave":                true,
+	"application/zip":           true,
+}
+
+// officeTypes name the office formats content sniffing can't tell apart:
+// the current ones are zip archives, the legacy ones compound documents
+var officeTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".doc":  "application/msword",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".xls":  "application/vnd.ms-excel",
+}
+
+// compoundDocumentSignature starts every legacy office file
+var compoundDocumentSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// MaxMaterialSize is the largest material accepted, in bytes
+func MaxMaterialSize() int64 {
+	return int64(helpers.GetEnvInt("MATERIAL_MAX_SIZE_MB", 500)) << 20
+}
+
+// materialQuota is how many bytes of materials a course may hold
+func materialQuota() int64 {
+	return int64(helpers.GetEnvInt("COURSE_MATERIAL_QUOTA_MB", 2048)) << 20
+}
+
+type CourseMaterialService interface {
+	ListMaterials(
+		ctx context.Context, courseId string, viewerId string, viewerRole string,
+	) ([]models.CourseMaterial, error)
+	UploadMaterial(
+		ctx context.Context, courseId string, callerId string, filename string,
+		source io.Reader,
+	) (*models.CourseMaterial, error)
+	OpenMaterial(
+		ctx context.Context, courseId string, materialId string, viewerId string,
+		viewerRole string,
+	) (*models.CourseMaterial, io.ReadSeekCloser, error)
+	DeleteMaterial(
+		ctx context.Context, courseId string, materialId string, callerId string,
+	) error
+}
+
+type courseMaterialService struct {
+	repo           repository.CourseMaterialRepository
+	fileRepo       repository.FileRepository
+	courseRepo     repository.CourseRepository
+	enrollmentRepo repository.EnrollmentRepository
+}
+
+func NewCourseMaterialService(
+	repo repository.CourseMaterialRepository, fileRepo repository.FileRepository,
+	courseRepo repository.CourseRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+) CourseMaterialService {
+	return &courseMaterialService{
+		repo: repo, fileRepo: fileRepo, courseRepo: courseRepo,
+		enrollmentRepo: enrollmentRepo,
+	}
+}
+
+// ListMaterials lists the course's materials, oldest first. They are as
+// visible as the course itself
+func (s *courseMaterialService) ListMaterials(
+	ctx context.Context, courseId string, viewerId string, viewerRole string,
+) ([]models.CourseMaterial, error) {
+	course, err := s.visibleCourse(ctx, courseId, viewerId, viewerRole)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByCourses(ctx, []primitive.ObjectID{course.ID})
+}
+
+// UploadMaterial streams source into storage, checking its real content
+// type, the size limit and the course's quota on the way. Only the course's
+// instructor can upload
+func (s *courseMaterialService) UploadMaterial(
+	ctx context.Context, courseId string, callerId string, filename string,
+	source io.Reader,
+) (*models.CourseMaterial, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.InstructorId.Hex() != callerId {
+		return nil, ErrNotCourseOwner
+	}
+
+	quota := materialQuota()
+	used, err := s.repo.UsedBytes(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+	if used >= quota {
+		return nil, ErrMaterialQuotaExceeded
+	}
+
+	filename = cleanFilename(filename)
+
+	// Sniff the real content type instead of trusting the client header
+	head := make([]byte, 512)
+	n, err := io.ReadFull(source, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) &&
+		!errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	if n == 0 {
+		return nil, ErrEmptyMaterial
+	}
+
+	contentType, ok := materialContentType(head, filename)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMaterialType, contentType)
+	}
+
+	// Whichever runs out first, the size limit or the quota, stops the
+	// upload one byte past it
+	maxSize := MaxMaterialSize()
+	limit := min(maxSize, quota-used)
+
+	file, err := s.fileRepo.Upload(
+		ctx, filename,
+		io.LimitReader(io.MultiReader(bytes.NewReader(head), source), limit+1),
+		models.FileMetadata{ContentType: contentType, OwnerId: course.InstructorId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Length > limit {
+		_ = s.fileRepo.Delete(ctx, file.ID)
+		if file.Length > maxSize {
+			return nil, ErrMaterialTooLarge
+		}
+		return nil, ErrMaterialQuotaExceeded
+	}
+
+	callerObjId, _ := primitive.ObjectIDFromHex(callerId)
+	material, err := s.repo.Create(ctx, &models.CourseMaterial{
+		CourseId:    course.ID,
+		TenantId:    course.TenantId,
+		FileId:      file.ID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        file.Length,
+		UploadedBy:  callerObjId,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		_ = s.fileRepo.Delete(ctx, file.ID)
+		return nil, err
+	}
+
+	// Uploads running side by side each passed the check above on their
+	// own, the one that tipped the course over the quota backs out
+	used, err = s.repo.UsedBytes(ctx, course.ID)
+	if err == nil && used > quota {
+		err = ErrMaterialQuotaExceeded
+	}
+	if err != nil {
+		_ = s.repo.Delete(ctx, material.ID)
+		_ = s.fileRepo.Delete(ctx, file.ID)
+		return nil, err
+	}
+
+	return material, nil
+}
+
+// OpenMaterial opens a material for reading from any offset, so downloads
+// can be resumed and videos seeked. Only learners with an active or
+// completed enrollment, the course's instructor and admins can download
+func (s *courseMaterialService) OpenMaterial(
+	ctx context.Context, courseId string, materialId string, viewerId string,
+	viewerRole string,
+) (*models.CourseMaterial, io.ReadSeekCloser, error) {
+	course, err := s.visibleCourse(ctx, courseId, viewerId, viewerRole)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !canManageCourse(course, viewerId, viewerRole) {
+		err = s.checkEnrolled(ctx, course.ID, viewerId)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	material, err := s.findMaterial(ctx, course.ID, materialId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, content, err := s.fileRepo.OpenSeekable(ctx, material.FileId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrMaterialNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return material, content, nil
+}
+
+func (s *courseMaterialService) DeleteMaterial(
+	ctx context.Context, courseId string, materialId string, callerId string,
+) error {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return err
+	}
+
+	if course.InstructorId.Hex() != callerId {
+		return ErrNotCourseOwner
+	}
+
+	material, err := s.findMaterial(ctx, course.ID, materialId)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Delete(ctx, material.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrMaterialNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return deleteMaterialFiles(ctx, s.fileRepo, []models.CourseMaterial{*material})
+}
+
+func (s *courseMaterialService) checkEnrolled(
+	ctx context.Context, courseId primitive.ObjectID, viewerId string,
+) error {
+	viewerObjId, err := primitive.ObjectIDFromHex(viewerId)
+	if err != nil {
+		return ErrNotEnrolled
+	}
+
+	enrollment, err := s.enrollmentRepo.FindOne(ctx, viewerObjId, courseId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	if enrollment.Status != models.EnrollmentStatusActive &&
+		enrollment.Status != models.EnrollmentStatusCompleted {
+		return ErrNotEnrolled
+	}
+
+	return nil
+}
+
+func (s *courseMaterialService) visibleCourse(
+	ctx context.Context, courseId string, viewerId string, viewerRole string,
+) (*models.Course, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.Status != models.CourseStatusPublished &&
+		!canManageCourse(course, viewerId, viewerRole) {
+		return nil, ErrCourseNotFound
+	}
+
+	return course, nil
+}
+
+func (s *courseMaterialService) findMaterial(
+	ctx context.Context, courseId primitive.ObjectID, materialId string,
+) (*models.CourseMaterial, error) {
+	materialObjId, err := primitive.ObjectIDFromHex(materialId)
+	if err != nil {
+		return nil, ErrInvalidMaterialID
+	}
+
+	material, err := s.repo.FindOne(ctx, courseId, materialObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMaterialNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+// deleteMaterialFiles removes the stored content of materials whose
+// records are gone, content that is already missing is skipped
+func deleteMaterialFiles(
+	ctx context.Context, fileRepo repository.FileRepository,
+	materials []models.CourseMaterial,
+) error {
+	for _, material := range materials {
+		err := fileRepo.Delete(ctx, material.FileId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+	return nil
+}
+
+// materialContentType sniffs the content type of a material from its first
+// bytes, using the file extension only to name office formats
+func materialContentType(head []byte, filename string) (string, bool) {
+	contentType := http.DetectContentType(head)
+	extension := strings.ToLower(path.Ext(filename))
+
+	switch {
+	case contentType == "application/zip" &&
+		strings.HasSuffix(extension, "x") && officeTypes[extension] != "":
+		return officeTypes[extension], true
+	case bytes.HasPrefix(head, compoundDocumentSignature) &&
+		officeTypes[extension] != "" && !strings.HasSuffix(extension, "x"):
+		return officeTypes[extension], true
+	}
+
+	return contentType, allowedMaterialTypes[contentType]
+}
+
+// cleanFilename keeps only the base name of an uploaded file and drops
+// control characters, so it is safe to echo back in headers
+func cleanFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filename)
+	filename = strings.TrimSpace(filename)
+
+	if runes := []rune(filename); len(runes) > 255 {
+		filename = string(runes[:255])
+	}
+	if filename == "" || filename == "." || filename == "/" {
+		filename = "material"
+	}
+
+	return filename
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxResumePoints bounds how many courses "continue where you left off"
+// returns
+const maxResumePoints = 20
+
+type CourseProgressService interface {
+	// RecordProgress records what the learner did in a lesson. Completing
+	// the last lesson completes their enrollment
+	RecordProgress(
+		ctx context.Context, courseId string, lessonId string, userId string,
+		progressDto *dto.RecordProgressDto,
+	) (*models.CourseProgress, error)
+	GetProgress(ctx context.Context, courseId string, userId string) (
+		*dto.CourseProgressDetails, error,
+	)
+	// ContinueLearning lists where to pick up the learner's unfinished
+	// courses, most recently active first
+	ContinueLearning(ctx context.Context, userId string, limit int) (
+		[]dto.ResumePoint, error,
+	)
+	GetCourseReport(
+		ctx context.Context, courseId string, callerId string, callerRole string,
+		status string, page int64, pageSize int64,
+	) (*dto.CourseProgressReport, error)
+}
+
+type courseProgressService struct {
+	repo           repository.CourseProgressRepository
+	enrollmentRepo repository.EnrollmentRepository
+	curriculumRepo repository.CurriculumRepository
+	courseRepo     repository.CourseRepository
+	certificates   CertificateService
+}
+
+func NewCourseProgressService(
+	repo repository.CourseProgressRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	curriculumRepo repository.CurriculumRepository,
+	courseRepo repository.CourseRepository, certificates CertificateService,
+) CourseProgressService {
+	return &courseProgressService{
+		repo:           repo,
+		enrollmentRepo: enrollmentRepo,
+		curriculumRepo: curriculumRepo,
+		courseRepo:     courseRepo,
+		certificates:   certificates,
+	}
+}
+
+func (s *courseProgressService) RecordProgress(
+	ctx context.Context, courseId string, lessonId string, userId string,
+	progressDto *dto.RecordProgressDto,
+) (*models.CourseProgress, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	lessonObjId, err := primitive.ObjectIDFromHex(lessonId)
+	if err != nil {
+		return nil, ErrInvalidLessonID
+	}
+
+	enrollment, err := s.enrollment(ctx, course.ID, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !enrollment.Counted() {
+		return nil, ErrNotEnrolled
+	}
+
+	curriculum, err := s.curriculum(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, lesson := findLesson(curriculum, lessonObjId); lesson == nil {
+		return nil, ErrLessonNotFound
+	}
+
+	progress, err := s.repo.Record(
+		ctx, enrollment.UserId, course, repository.ProgressActivity{
+			LessonId:         lessonObjId,
+			PositionSeconds:  progressDto.PositionSeconds,
+			TimeSpentSeconds: progressDto.TimeSpentSeconds,
+			Completed:        progressDto.Completed,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, percent := progress.Completion(curriculum)
+
+	completedAt := progress.CompletedAt
+	switch {
+	case percent == 100 && completedAt == nil:
+		now := time.Now()
+		completedAt = &now
+	case percent < 100:
+		completedAt = nil
+	}
+
+	if percent != progress.CompletionPercent ||
+		curriculum.LessonCount != progress.LessonCount ||
+		(completedAt == nil) != (progress.CompletedAt == nil) {
+		progress, err = s.repo.SetCompletion(
+			ctx, progress.ID, curriculum.LessonCount, percent, completedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if percent == 100 && enrollment.Status == models.EnrollmentStatusActive {
+		completed, err := setEnrollmentStatus(
+			ctx, s.enrollmentRepo, s.courseRepo, enrollment,
+			models.EnrollmentStatusCompleted,
+		)
+		// Losing a race means the enrollment was just completed or
+		// cancelled, either way there is nothing left to do
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		if err == nil {
+			issueCertificate(ctx, s.certificates, completed)
+		}
+	}
+
+	return withCompletedLessons(progress), nil
+}
+
+// GetProgress returns the learner's progress measured against the current
+// curriculum, and where to continue
+func (s *courseProgressService) GetProgress(
+	ctx context.Context, courseId string, userId string,
+) (*dto.CourseProgressDetails, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.enrollment(ctx, course.ID, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	curriculum, err := s.curriculum(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := s.repo.FindOne(ctx, enrollment.UserId, course.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		progress = &models.CourseProgress{
+			UserId: enrollment.UserId, CourseId: course.ID,
+		}
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	progress = withCompletedLessons(progress)
+	_, progress.CompletionPercent = progress.Completion(curriculum)
+	progress.LessonCount = curriculum.LessonCount
+
+	return &dto.CourseProgressDetails{
+		Progress: *progress,
+		Next:     resumePoint(course, curriculum, progress),
+	}, nil
+}
+
+func (s *courseProgressService) ContinueLearning(
+	ctx context.Context, userId string, limit int,
+) ([]dto.ResumePoint, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if limit < 1 || limit > maxResumePoints {
+		limit = 5
+	}
+
+	progresses, err := s.repo.FindUnfinishedByUser(ctx, userObjId, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	courseIds := make([]primitive.ObjectID, len(progresses))
+	for i, progress := range progresses {
+		courseIds[i] = progress.CourseId
+	}
+
+	courses, err := s.courseRepo.FindByIds(ctx, courseIds)
+	if err != nil {
+		return nil, err
+	}
+
+	coursesById := make(map[primitive.ObjectID]*models.Course, len(courses))
+	for i := range courses {
+		coursesById[courses[i].ID] = &courses[i]
+	}
+
+	resumePoints := make([]dto.ResumePoint, 0, len(progresses))
+	for i := range progresses {
+		progress := &progresses[i]
+		course, ok := coursesById[progress.CourseId]
+		if !ok {
+			continue
+		}
+
+		// Courses the learner left don't need continuing
+		enrollment, err := s.enrollmentRepo.FindOne(ctx, userObjId, course.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !enrollment.Counted() {
+			continue
+		}
+
+		curriculum, err := s.curriculum(ctx, course.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		next := resumePoint(course, curriculum, progress)
+		if next != nil {
+			resumePoints = append(resumePoints, *next)
+		}
+	}
+
+	return resumePoints, nil
+}
+
+// GetCourseReport lists the progress of a course's learners, most recently
+// enrolled first, with totals for the whole course. Only the course's
+// instructor and admins can see it
+func (s *courseProgressService) GetCourseReport(
+	ctx context.Context, courseId string, callerId string, callerRole string,
+	status string, page int64, pageSize int64,
+) (*dto.CourseProgressReport, error) {
+	if status != "" && !enrollmentStatuses[status] {
+		return nil, ErrInvalidEnrollmentStatus
+	}
+
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	enrollments, totalCount, err := s.enrollmentRepo.FindByCourse(
+		ctx, course.ID, status, page, pageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := make([]primitive.ObjectID, len(enrollments))
+	for i, enrollment := range enrollments {
+		userIds[i] = enrollment.UserId
+	}
+
+	progresses, err := s.repo.FindByCourseUsers(ctx, course.ID, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	progressByUser := make(
+		map[primitive.ObjectID]*models.CourseProgress, len(progresses),
+	)
+	for i := range progresses {
+		progressByUser[progresses[i].UserId] = &progresses[i]
+	}
+
+	curriculum, err := s.curriculum(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	learners := make([]dto.LearnerProgress, len(enrollments))
+	for i, enrollment := range enrollments {
+		learners[i] = dto.LearnerProgress{
+			UserId:           enrollment.UserId.Hex(),
+			EnrollmentStatus: enrollment.Status,
+			EnrolledAt:       enrollment.EnrolledAt,
+			LessonCount:      curriculum.LessonCount,
+		}
+
+		progress, ok := progressByUser[enrollment.UserId]
+		if !ok {
+			continue
+		}
+		learners[i].CompletedLessons, learners[i].CompletionPercent =
+			progress.Completion(curriculum)
+		learners[i].TimeSpentSeconds = progress.TimeSpentSeconds
+		learners[i].LastActivityAt = &progress.UpdatedAt
+	}
+
+	summary, err := s.repo.Summarize(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.enrollmentRepo.CountByCourse(
+		ctx, course.ID, models.EnrollmentStatusCompleted,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CourseProgressReport{
+		Summary: dto.ProgressReportSummary{
+			Learners:                 course.EnrollmentCount,
+			Completed:                completed,
+			Started:                  summary.Started,
+			AverageCompletionPercent: summary.AverageCompletion,
+			TotalTimeSpentSeconds:    summary.TotalTimeSpentSeconds,
+		},
+		Learners:   learners,
+		Page:       int(page),
+		TotalCount: totalCount,
+		HasMore:    totalCount > page*pageSize,
+	}, nil
+}
+
+// enrollment finds the user's enrollment in the course, whatever its
+// status
+func (s *courseProgressService) enrollment(
+	ctx context.Context, courseId primitive.ObjectID, userId string,
+) (*models.Enrollment, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	enrollment, err := s.enrollmentRepo.FindOne(ctx, userObjId, courseId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+func (s *courseProgressService) curriculum(
+	ctx context.Context, courseId primitive.ObjectID,
+) (*models.Curriculum, error) {
+	curriculum, err := s.curriculumRepo.FindByCourse(ctx, courseId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.Curriculum{CourseId: courseId}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return curriculum, nil
+}
+
+// resumePoint picks the lesson to continue with: the last one the learner
+// was in unless they completed it, otherwise the next lesson they haven't
+// completed, wrapping around to the start of the course
+func resumePoint(
+	course *models.Course, curriculum *models.Curriculum,
+	progress *models.CourseProgress,
+) *dto.ResumePoint {
+	completed := make(map[primitive.ObjectID]bool, len(progress.CompletedLessons))
+	for _, lessonId := range progress.CompletedLessons {
+		completed[lessonId] = true
+	}
+
+	type place struct {
+		section *models.Section
+		lesson  *models.Lesson
+	}
+	var lessons []place
+	start := 0
+	for i := range curriculum.Sections {
+		section := &curriculum.Sections[i]
+		for j := range section.Lessons {
+			lesson := &section.Lessons[j]
+			if progress.LastLessonId != nil && lesson.ID == *progress.LastLessonId {
+				start = len(lessons)
+			}
+			lessons = append(lessons, place{section: section, lesson: lesson})
+		}
+	}
+
+	_, percent := progress.Completion(curriculum)
+
+	for offset := range lessons {
+		at := lessons[(start+offset)%len(lessons)]
+		if completed[at.lesson.ID] {
+			continue
+		}
+
+		position := 0
+		if progress.LastLessonId != nil && at.lesson.ID == *progress.LastLessonId {
+			position = progress.LastPositionSeconds
+		}
+
+		return &dto.ResumePoint{
+			CourseId:          course.ID.Hex(),
+			CourseName:        course.CourseName,
+			SectionId:         at.section.ID.Hex(),
+			SectionTitle:      at.section.Title,
+			LessonId:          at.lesson.ID.Hex(),
+			LessonTitle:       at.lesson.Title,
+			PositionSeconds:   position,
+			CompletionPercent: percent,
+			LastActivityAt:    progress.UpdatedAt,
+		}
+	}
+
+	return nil
+}
+
+// findLesson finds a lesson anywhere in the curriculum
+func findLesson(
+	curriculum *models.Curriculum, lessonId primitive.ObjectID,
+) (*models.Section, *models.Lesson) {
+	for i := range curriculum.Sections {
+		lesson := curriculum.Sections[i].Lesson(lessonId)
+		if lesson != nil {
+			return &curriculum.Sections[i], lesson
+		}
+	}
+	return nil, nil
+}
+
+// withCompletedLessons makes sure progress lists its completed lessons as
+// an empty list rather than null
+func withCompletedLessons(progress *models.CourseProgress) *models.CourseProgress {
+	if progress.CompletedLessons == nil {
+		progress.CompletedLessons = []primitive.ObjectID{}
+	}
+	return progress
+}
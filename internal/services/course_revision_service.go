@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrInvalidRevision  = errors.New("revision must be a positive number")
+	ErrRevisionConflict = errors.New("course was changed concurrently, try again")
+	ErrCourseNameTaken  = errors.New("another course already has this name")
+)
+
+// revisionAttempts bounds how often recording a revision is retried when
+// another change takes its number
+const revisionAttempts = 3
+
+type CourseRevisionService interface {
+	GetRevisions(
+		ctx context.Context, courseId string, callerId string, callerRole string,
+		page int64, pageSize int64,
+	) ([]models.CourseRevision, int64, error)
+	GetRevision(
+		ctx context.Context, courseId string, revision int64, callerId string,
+		callerRole string,
+	) (*models.CourseRevision, error)
+	DiffRevisions(
+		ctx context.Context, courseId string, from int64, to int64,
+		callerId string, callerRole string,
+	) (*dto.RevisionDiff, error)
+	// RestoreRevision puts the course's fields back the way a revision has
+	// them, which is recorded as a new revision
+	RestoreRevision(
+		ctx context.Context, courseId string, revision int64, callerId string,
+		callerRole string,
+	) (*models.Course, error)
+}
+
+type courseRevisionService struct {
+	repo       repository.CourseRevisionRepository
+	courseRepo repository.CourseRepository
+}
+
+func NewCourseRevisionService(
+	repo repository.CourseRevisionRepository,
+	courseRepo repository.CourseRepository,
+) CourseRevisionService {
+	return &courseRevisionService{repo: repo, courseRepo: courseRepo}
+}
+
+func (s *courseRevisionService) GetRevisions(
+	ctx context.Context, courseId string, callerId string, callerRole string,
+	page int64, pageSize int64,
+) ([]models.CourseRevision, int64, error) {
+	course, err := s.findManagedCourse(ctx, courseId, callerId, callerRole)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.repo.FindByCourse(ctx, course.ID, page, pageSize)
+}
+
+func (s *courseRevisionService) GetRevision(
+	ctx context.Context, courseId string, revision int64, callerId string,
+	callerRole string,
+) (*models.CourseRevision, error) {
+	course, err := s.findManagedCourse(ctx, courseId, callerId, callerRole)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findRevision(ctx, course.ID, revision)
+}
+
+func (s *courseRevisionService) DiffRevisions(
+	ctx context.Context, courseId string, from int64, to int64, callerId string,
+	callerRole string,
+) (*dto.RevisionDiff, error) {
+	course, err := s.findManagedCourse(ctx, courseId, callerId, callerRole)
+	if err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := s.findRevision(ctx, course.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := s.findRevision(ctx, course.ID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RevisionDiff{
+		From:    from,
+		To:      to,
+		Changes: snapshotChanges(fromRevision.Snapshot, toRevision.Snapshot),
+	}, nil
+}
+
+func (s *courseRevisionService) RestoreRevision(
+	ctx context.Context, courseId string, revision int64, callerId string,
+	callerRole string,
+) (*models.Course, error) {
+	course, err := s.findManagedCourse(ctx, courseId, callerId, callerRole)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := s.findRevision(ctx, course.ID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	authorId, err := primitive.ObjectIDFromHex(callerId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	snapshot := restored.Snapshot
+	updated, err := s.courseRepo.UpdateOne(ctx, course.ID, bson.M{"$set": bson.M{
+		"course_name":  snapshot.CourseName,
+		"description":  snapshot.Description,
+		"tags":         snapshot.Tags,
+		"category":     snapshot.Category,
+		"level":        snapshot.Level,
+		"language":     snapshot.Language,
+		"course_price": snapshot.Price,
+		"price_list":   snapshot.PriceList,
+		"updated_at":   time.Now(),
+	}})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCourseNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrCourseNameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = recordRevision(
+		ctx, s.repo, course, updated, authorId, models.RevisionActionRestore,
+		&restored.Revision,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *courseRevisionService) findManagedCourse(
+	ctx context.Context, courseId string, callerId string, callerRole string,
+) (*models.Course, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	return course, nil
+}
+
+func (s *courseRevisionService) findRevision(
+	ctx context.Context, courseId primitive.ObjectID, revision int64,
+) (*models.CourseRevision, error) {
+	if revision < 1 {
+		return nil, ErrInvalidRevision
+	}
+
+	courseRevision, err := s.repo.FindOne(ctx, courseId, revision)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return courseRevision, nil
+}
+
+// recordRevision stores the course as it is after a change, numbered after
+// the latest revision. The first change of a course also keeps what it
+// looked like before. Changes that leave the snapshot as it was aren't
+// recorded, and revisions beyond COURSE_REVISION_LIMIT are pruned, oldest
+// first
+func recordRevision(
+	ctx context.Context, repo repository.CourseRevisionRepository,
+	before *models.Course, after *models.Course, authorId primitive.ObjectID,
+	action string, restoredFrom *int64,
+) (*models.CourseRevision, error) {
+	snapshot := after.Snapshot()
+	if reflect.DeepEqual(before.Snapshot(), snapshot) {
+		return nil, nil
+	}
+
+	for attempt := 0; attempt < revisionAttempts; attempt++ {
+		var number int64
+		latest, err := repo.FindLatest(ctx, after.ID)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			_, err = repo.Create(ctx, &models.CourseRevision{
+				CourseId:  before.ID,
+				TenantId:  before.TenantId,
+				Revision:  1,
+				Action:    models.RevisionActionInitial,
+				AuthorId:  before.InstructorId,
+				Snapshot:  before.Snapshot(),
+				CreatedAt: before.UpdatedAt,
+			})
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			number = 2
+		case err != nil:
+			return nil, err
+		default:
+			number = latest.Revision + 1
+		}
+
+		revision, err := repo.Create(ctx, &models.CourseRevision{
+			CourseId:     after.ID,
+			TenantId:     after.TenantId,
+			Revision:     number,
+			Action:       action,
+			RestoredFrom: restoredFrom,
+			AuthorId:     authorId,
+			Snapshot:     snapshot,
+			CreatedAt:    time.Now(),
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		limit := int64(helpers.GetEnvInt("COURSE_REVISION_LIMIT", 50))
+		if limit > 0 && number > limit {
+			_, err = repo.Prune(ctx, after.ID, number-limit+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return revision, nil
+	}
+
+	return nil, ErrRevisionConflict
+}
+
+// snapshotChanges lists the fields that differ between two snapshots
+func snapshotChanges(
+	from models.CourseSnapshot, to models.CourseSnapshot,
+) []dto.FieldChange {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"course_name", from.CourseName, to.CourseName},
+		{"description", from.Description, to.Description},
+		{"tags", from.Tags, to.Tags},
+		{"category", from.Category, to.Category},
+		{"level", from.Level, to.Level},
+		{"language", from.Language, to.Language},
+		{"price", from.Price, to.Price},
+		{"price_list", from.PriceList, to.PriceList},
+	}
+
+	changes := []dto.FieldChange{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(field.from, field.to) {
+			changes = append(changes, dto.FieldChange{
+				Field: field.name, From: field.from, To: field.to,
+			})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+)
+
+func TestSnapshotChangesUnchanged(t *testing.T) {
+	snapshot := models.CourseSnapshot{
+		CourseName: "Go for the web",
+		Tags:       []string{"go", "web"},
+		Price:      models.Money{Amount: 1250, Currency: "USD"},
+	}
+	copied := snapshot
+	copied.Tags = []string{"go", "web"}
+
+	changes := snapshotChanges(snapshot, copied)
+	if changes == nil || len(changes) != 0 {
+		t.Errorf("changes = %#v, want an empty list", changes)
+	}
+}
+
+func TestSnapshotChanges(t *testing.T) {
+	from := models.CourseSnapshot{
+		CourseName:  "Go for the web",
+		Description: "Build HTTP services",
+		Tags:        []string{"go", "web"},
+		Level:       "beginner",
+		Price:       models.Money{Amount: 1250, Currency: "USD"},
+		PriceList:   []models.Money{{Amount: 1100, Currency: "EUR"}},
+	}
+	to := models.CourseSnapshot{
+		CourseName:  "Go for the web",
+		Description: "Build HTTP services",
+		Tags:        []string{"web", "go"},
+		Level:       "advanced",
+		Price:       models.Money{Amount: 0, Currency: "USD"},
+	}
+
+	want := []dto.FieldChange{
+		{Field: "tags", From: from.Tags, To: to.Tags},
+		{Field: "level", From: "beginner", To: "advanced"},
+		{Field: "price", From: from.Price, To: to.Price},
+		{Field: "price_list", From: from.PriceList, To: to.PriceList},
+	}
+	if changes := snapshotChanges(from, to); !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+}
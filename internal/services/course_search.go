@@ -0,0 +1,156 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetRadius is how many characters of context surround a match
+	snippetRadius = 60
+	maxSnippets   = 2
+	// minStemLength keeps stems long enough to stay meaningful
+	minStemLength = 4
+)
+
+// searchTerms splits a text search into lowercase terms. Quoted phrases stay
+// whole and negated terms are dropped, mirroring MongoDB's $text syntax
+func searchTerms(query string) []string {
+	var terms []string
+
+	for i, part := range strings.Split(query, `"`) {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+
+		// Odd parts sit between quotes
+		if i%2 == 1 {
+			terms = append(terms, part)
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			if strings.HasPrefix(word, "-") {
+				continue
+			}
+			word = strings.TrimFunc(word, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if len([]rune(word)) > 1 {
+				terms = append(terms, word)
+			}
+		}
+	}
+
+	return terms
+}
+
+// stem cuts common suffixes off a term so "programming" also finds
+// "programmer". The database does proper per-language stemming when ranking,
+// this only has to find roughly where a match sits for a snippet
+func stem(term string) []rune {
+	runes := []rune(term)
+	if strings.Contains(term, " ") || len(runes) <= minStemLength {
+		return runes
+	}
+
+	cut := len(runes) - 3
+	if cut < minStemLength {
+		cut = minStemLength
+	}
+	return runes[:cut]
+}
+
+// matchSnippets returns up to maxSnippets excerpts of text around the
+// earliest matches of terms
+func matchSnippets(text string, terms []string) []string {
+	if text == "" || len(terms) == 0 {
+		return nil
+	}
+
+	original := []rune(text)
+	// unicode.ToLower maps rune to rune, keeping offsets aligned with the
+	// original text
+	lower := make([]rune, len(original))
+	for i, r := range original {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var matches []int
+	for _, term := range terms {
+		position := indexRunes(lower, stem(term))
+		if position >= 0 {
+			matches = append(matches, position)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Ints(matches)
+
+	var snippets []string
+	coveredUntil := -1
+	for _, position := range matches {
+		if position <= coveredUntil {
+			continue
+		}
+
+		start := position - snippetRadius
+		if start < 0 {
+			start = 0
+		}
+		end := position + snippetRadius
+		if end > len(original) {
+			end = len(original)
+		}
+
+		// Don't cut words in half at either end
+		for start > 0 && !unicode.IsSpace(original[start-1]) && position-start < snippetRadius+15 {
+			start--
+		}
+		for end < len(original) && !unicode.IsSpace(original[end]) && end-position < snippetRadius+15 {
+			end++
+		}
+
+		snippet := strings.TrimSpace(string(original[start:end]))
+		if start > 0 {
+			snippet = "…" + snippet
+		}
+		if end < len(original) {
+			snippet += "…"
+		}
+
+		snippets = append(snippets, snippet)
+		coveredUntil = end
+		if len(snippets) == maxSnippets {
+			break
+		}
+	}
+
+	return snippets
+}
+
+// indexRunes is strings.Index over runes, so the offset can index the
+// original text
+func indexRunes(haystack []rune, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		found := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := map[string][]string{
+		"Golang":                 {"golang"},
+		"go  web APIs":           {"go", "web", "apis"},
+		`"Web APIs" in go`:       {"web apis", "in", "go"},
+		"python -django":         {"python"},
+		"(rust), c++!":           {"rust"},
+		`learn "design patterns`: {"learn", "design patterns"},
+		"   ":                    nil,
+	}
+
+	for query, want := range tests {
+		if got := searchTerms(query); !reflect.DeepEqual(got, want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestMatchSnippetsShortText(t *testing.T) {
+	text := "Every PROGRAMMER should know this"
+
+	// Matching ignores case and finds other forms of the term
+	snippets := matchSnippets(text, []string{"programming"})
+	if !reflect.DeepEqual(snippets, []string{text}) {
+		t.Errorf("snippets = %q, want the whole text", snippets)
+	}
+
+	if snippets := matchSnippets(text, []string{"python"}); snippets != nil {
+		t.Errorf("snippets without a match = %q, want none", snippets)
+	}
+	if snippets := matchSnippets(text, nil); snippets != nil {
+		t.Errorf("snippets without terms = %q, want none", snippets)
+	}
+}
+
+func TestMatchSnippetsLongText(t *testing.T) {
+	filler := strings.Repeat("filler words here ", 10)
+	text := "indexes " + filler + "mongodb aggregation " + filler + filler + "sharding"
+
+	snippets := matchSnippets(text, []string{"sharding", "aggregation", "indexes"})
+	if len(snippets) != maxSnippets {
+		t.Fatalf("got %d snippets, want %d: %q", len(snippets), maxSnippets, snippets)
+	}
+
+	// The earliest matches win and each snippet keeps whole words
+	if !strings.HasPrefix(snippets[0], "indexes ") {
+		t.Errorf("first snippet %q doesn't start at the first match", snippets[0])
+	}
+	if !strings.Contains(snippets[1], "mongodb aggregation") {
+		t.Errorf("second snippet %q misses the second match", snippets[1])
+	}
+	for _, snippet := range snippets {
+		for _, word := range strings.Fields(strings.Trim(snippet, "…")) {
+			if !strings.Contains(text, " "+word+" ") && !strings.HasPrefix(text, word+" ") {
+				t.Errorf("snippet %q cuts the word %q", snippet, word)
+			}
+		}
+	}
+	if !strings.HasPrefix(snippets[1], "…") || !strings.HasSuffix(snippets[1], "…") {
+		t.Errorf("snippet %q from the middle isn't marked as cut", snippets[1])
+	}
+}
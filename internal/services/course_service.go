@@ -3,8 +3,13 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
 	"github.com/AhmedHossam777/go-mongo/internal/models"
 	"github.com/AhmedHossam777/go-mongo/internal/repository"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,87 +20,342 @@ import (
 var (
 	ErrCourseNotFound  = errors.New("course not found")
 	ErrInvalidCourseID = errors.New("invalid course ID")
+
+	ErrInvalidCourseTransition = errors.New("course can't move to this status")
+	ErrCourseIncomplete        = errors.New("course is missing required fields")
+
+	ErrDuplicatePriceCurrency = errors.New(
+		"price list may hold each currency once, and not the price's own currency",
+	)
 )
 
+// CourseIncompleteError lists what has to be filled in before a course can
+// be published
+type CourseIncompleteError struct {
+	Fields []helpers.ValidationError
+}
+
+func (e *CourseIncompleteError) Error() string {
+	names := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		names[i] = field.Field
+	}
+	return fmt.Sprintf("%s: %s", ErrCourseIncomplete, strings.Join(names, ", "))
+}
+
+func (e *CourseIncompleteError) Is(target error) bool {
+	return target == ErrCourseIncomplete
+}
+
 type CourseService interface {
 	CreateCourse(ctx context.Context, courseDto *dto.CreateCourseDto) (
 		*models.Course, error,
 	)
-	GetAllCourses(ctx context.Context, page, pageSize int64) (
-		[]models.Course, int, error,
+	GetAllCourses(
+		ctx context.Context, viewerId string, viewerRole string,
+		query dto.CourseListQuery, page, pageSize int64,
+	) (
+		[]models.Course, int, *dto.CourseFacets, dto.PageCursors, error,
 	)
-	GetCourseByID(ctx context.Context, id string) (*models.Course, error)
+	GetCourseByID(
+		ctx context.Context, id string, viewerId string, viewerRole string,
+	) (*models.Course, error)
+	SearchCourses(
+		ctx context.Context, query string, language string,
+		page, pageSize int64,
+	) ([]dto.CourseSearchResult, int64, error)
+	// UpdateCourse applies the changes and records them as a new revision
+	// authored by the editor, who has to be the course's instructor or an
+	// admin
 	UpdateCourse(
-		ctx context.Context, id string, updateCourseDto *dto.UpdateCourseDto,
+		ctx context.Context, id string, editorId string, editorRole string,
+		updateCourseDto *dto.UpdateCourseDto,
+	) (*models.Course, error)
+	ChangeStatus(
+		ctx context.Context, id string, status string, callerId string,
+		callerRole string,
 	) (*models.Course, error)
-	DeleteCourse(ctx context.Context, id string) error
+	// DeleteCourse deletes a course with everything that belongs to it, only
+	// its instructor and admins can do it
+	DeleteCourse(
+		ctx context.Context, id string, callerId string, callerRole string,
+	) error
+	CountInstructorCourses(
+		ctx context.Context, instructorId primitive.ObjectID,
+	) (int64, error)
+	DeleteInstructorCourses(
+		ctx context.Context, instructorId primitive.ObjectID,
+	) (int64, error)
+	ReassignInstructorCourses(
+		ctx context.Context, from primitive.ObjectID, to primitive.ObjectID,
+	) (int64, error)
+	// DeleteLearnerData removes a learner's enrollments, taking them off the
+	// courses' enrollment counts, their progress, certificates, reviews and
+	// helpful votes
+	DeleteLearnerData(ctx context.Context, userId primitive.ObjectID) error
 	Drop(ctx context.Context) error
 }
 
 type courseService struct {
-	repo repository.CourseRepository
+	repo             repository.CourseRepository
+	curriculumRepo   repository.CurriculumRepository
+	materialRepo     repository.CourseMaterialRepository
+	materialFileRepo repository.FileRepository
+	enrollmentRepo   repository.EnrollmentRepository
+	progressRepo     repository.CourseProgressRepository
+	certificateRepo  repository.CertificateRepository
+	reviewRepo       repository.ReviewRepository
+	reviewVoteRepo   repository.ReviewVoteRepository
+	revisionRepo     repository.CourseRevisionRepository
 }
 
-func NewCourseService(repo repository.CourseRepository) CourseService {
-	return &courseService{repo: repo}
+func NewCourseService(
+	repo repository.CourseRepository,
+	curriculumRepo repository.CurriculumRepository,
+	materialRepo repository.CourseMaterialRepository,
+	materialFileRepo repository.FileRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	progressRepo repository.CourseProgressRepository,
+	certificateRepo repository.CertificateRepository,
+	reviewRepo repository.ReviewRepository,
+	reviewVoteRepo repository.ReviewVoteRepository,
+	revisionRepo repository.CourseRevisionRepository,
+) CourseService {
+	return &courseService{
+		repo:             repo,
+		curriculumRepo:   curriculumRepo,
+		materialRepo:     materialRepo,
+		materialFileRepo: materialFileRepo,
+		enrollmentRepo:   enrollmentRepo,
+		progressRepo:     progressRepo,
+		certificateRepo:  certificateRepo,
+		reviewRepo:       reviewRepo,
+		reviewVoteRepo:   reviewVoteRepo,
+		revisionRepo:     revisionRepo,
+	}
 }
 
+// CreateCourse stores a new course as a draft, only its instructor sees it
+// until it is published
 func (s *courseService) CreateCourse(
 	ctx context.Context, courseDto *dto.CreateCourseDto,
 ) (*models.Course, error) {
+	price := toMoney(courseDto.Price)
+	priceList := toMoneyList(courseDto.PriceList)
+	err := checkPriceList(price, priceList)
+	if err != nil {
+		return nil, err
+	}
 
 	course := &models.Course{
 		CourseName:   courseDto.CourseName,
-		Price:        courseDto.Price,
+		Description:  strings.TrimSpace(courseDto.Description),
+		Tags:         normalizeTags(courseDto.Tags),
+		Category:     courseDto.Category,
+		Level:        courseDto.Level,
+		Language:     courseDto.Language,
+		Price:        price,
+		PriceList:    priceList,
 		InstructorId: courseDto.InstructorId,
+		Status:       models.CourseStatusDraft,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
 	}
 	return s.repo.Create(ctx, course)
 }
 
+// GetAllCourses lists published courses matching query, with the facet
+// counts over all of them. Admins see every course and instructors also see
+// their own unpublished ones. The page starts at query's cursor when it has
+// one and at page otherwise
 func (s *courseService) GetAllCourses(
-	ctx context.Context, page, pageSize int64,
+	ctx context.Context, viewerId string, viewerRole string,
+	query dto.CourseListQuery, page, pageSize int64,
 ) (
-	[]models.Course, int, error,
+	[]models.Course, int, *dto.CourseFacets, dto.PageCursors, error,
 ) {
-	return s.repo.FindAll(ctx, page, pageSize)
-}
+	filter := repository.CourseFilter{
+		AllStatuses: viewerRole == "admin",
+		MinPrice:    query.MinPrice,
+		MaxPrice:    query.MaxPrice,
+		Currency:    query.Currency,
+		Category:    query.Category,
+		Level:       query.Level,
+		Tag:         strings.ToLower(strings.TrimSpace(query.Tag)),
+	}
 
-func (s *courseService) GetCourseByID(ctx context.Context, id string) (
-	*models.Course, error,
-) {
-	objectId, err := primitive.ObjectIDFromHex(id)
+	viewerObjId, err := primitive.ObjectIDFromHex(viewerId)
+	if err == nil && viewerRole == "instructor" {
+		filter.OwnedBy = &viewerObjId
+	}
+
+	if query.InstructorId != "" {
+		instructorId, err := primitive.ObjectIDFromHex(query.InstructorId)
+		if err != nil {
+			return nil, 0, nil, dto.PageCursors{}, ErrInvalidUserID
+		}
+		filter.InstructorId = &instructorId
+	}
+	if filter.Currency == "" {
+		filter.Currency = helpers.DefaultCurrency()
+	}
+
+	filter.Keyset, err = decodeKeyset(query.Cursor, courseListSort, true)
 	if err != nil {
-		return nil, ErrInvalidCourseID
+		return nil, 0, nil, dto.PageCursors{}, err
 	}
 
-	course, err := s.repo.FindOne(ctx, objectId)
-	if errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, ErrCourseNotFound
+	courses, totalCount, facets, err := s.repo.FindAll(
+		ctx, filter, page, pageSize,
+	)
+	if err != nil {
+		return nil, 0, nil, dto.PageCursors{}, err
 	}
+
+	courses, cursors, err := keysetPage(
+		courses, pageSize, page, filter.Keyset, courseListSort,
+		func(course models.Course) (string, primitive.ObjectID) {
+			return cursorTime(course.CreatedAt), course.ID
+		},
+	)
+	if err != nil {
+		return nil, 0, nil, dto.PageCursors{}, err
+	}
+
+	return courses, totalCount, courseFacetsDto(facets, filter.Currency),
+		cursors, nil
+}
+
+// courseListSort is the only order courses are listed in, newest first
+const courseListSort = "created_at:desc"
+
+func courseFacetsDto(
+	facets *repository.CourseFacets, currency string,
+) *dto.CourseFacets {
+	priceRanges := make([]dto.PriceRangeCount, len(facets.PriceRanges))
+	for i, priceRange := range facets.PriceRanges {
+		priceRanges[i] = dto.PriceRangeCount{
+			Currency: currency,
+			Min:      priceRange.Min,
+			Max:      priceRange.Max,
+			Count:    priceRange.Count,
+		}
+	}
+
+	return &dto.CourseFacets{
+		PriceRanges: priceRanges,
+		Instructors: facetCountsDto(facets.Instructors),
+		Categories:  facetCountsDto(facets.Categories),
+		Levels:      facetCountsDto(facets.Levels),
+		Tags:        facetCountsDto(facets.Tags),
+	}
+}
+
+func facetCountsDto(counts []repository.FacetCount) []dto.FacetCount {
+	result := make([]dto.FacetCount, len(counts))
+	for i, count := range counts {
+		result[i] = dto.FacetCount{Value: count.Value, Count: count.Count}
+	}
+	return result
+}
+
+// GetCourseByID returns a course, hiding unpublished courses from everyone
+// but their instructor and admins
+func (s *courseService) GetCourseByID(
+	ctx context.Context, id string, viewerId string, viewerRole string,
+) (*models.Course, error) {
+	course, err := s.findCourse(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if course.Status != models.CourseStatusPublished &&
+		!canManageCourse(course, viewerId, viewerRole) {
+		return nil, ErrCourseNotFound
+	}
+
 	return course, nil
 }
 
+// SearchCourses ranks published courses by how well they match query. A
+// match in the name weighs more than one in the tags or description
+func (s *courseService) SearchCourses(
+	ctx context.Context, query string, language string, page, pageSize int64,
+) ([]dto.CourseSearchResult, int64, error) {
+	hits, totalCount, err := s.repo.Search(ctx, query, language, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	terms := searchTerms(query)
+	results := make([]dto.CourseSearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = dto.CourseSearchResult{
+			Course:   hit.Course,
+			Score:    hit.Score,
+			Snippets: matchSnippets(hit.Description, terms),
+		}
+	}
+
+	return results, totalCount, nil
+}
+
 func (s *courseService) UpdateCourse(
-	ctx context.Context, id string, updateCourseDto *dto.UpdateCourseDto,
+	ctx context.Context, id string, editorId string, editorRole string,
+	updateCourseDto *dto.UpdateCourseDto,
 ) (*models.Course, error) {
-	objectId, err := primitive.ObjectIDFromHex(id)
+	editorObjId, err := primitive.ObjectIDFromHex(editorId)
 	if err != nil {
-		return nil, ErrInvalidCourseID
+		return nil, ErrInvalidUserID
 	}
 
-	var update = bson.M{}
+	before, err := s.findCourse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(before, editorId, editorRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	var update = bson.M{"updated_at": time.Now()}
 	if updateCourseDto.CourseName != nil {
-		update["CourseName"] = *updateCourseDto.CourseName
+		update["course_name"] = *updateCourseDto.CourseName
+	}
+	if updateCourseDto.Description != nil {
+		update["description"] = strings.TrimSpace(*updateCourseDto.Description)
+	}
+	if updateCourseDto.Tags != nil {
+		update["tags"] = normalizeTags(*updateCourseDto.Tags)
+	}
+	if updateCourseDto.Category != nil {
+		update["category"] = *updateCourseDto.Category
+	}
+	if updateCourseDto.Level != nil {
+		update["level"] = *updateCourseDto.Level
 	}
+	if updateCourseDto.Language != nil {
+		update["language"] = *updateCourseDto.Language
+	}
+	// The price and the price list are checked together, whichever of them
+	// isn't being changed is kept as it is
+	price, priceList := before.Price, before.PriceList
 	if updateCourseDto.Price != nil {
-		update["Price"] = *updateCourseDto.Price
+		price = toMoney(*updateCourseDto.Price)
+		update["course_price"] = price
+	}
+	if updateCourseDto.PriceList != nil {
+		priceList = toMoneyList(*updateCourseDto.PriceList)
+		update["price_list"] = priceList
+	}
+	if updateCourseDto.Price != nil || updateCourseDto.PriceList != nil {
+		err = checkPriceList(price, priceList)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	updateCourse, err := s.repo.UpdateOne(ctx, objectId, bson.M{"$set": update})
+	updateCourse, err := s.repo.UpdateOne(ctx, before.ID, bson.M{"$set": update})
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, ErrCourseNotFound
 	}
@@ -103,20 +363,251 @@ func (s *courseService) UpdateCourse(
 		return nil, err
 	}
 
+	// The update already went through, a revision that fails to record
+	// shouldn't turn it into an error
+	_, err = recordRevision(
+		ctx, s.revisionRepo, before, updateCourse, editorObjId,
+		models.RevisionActionUpdate, nil,
+	)
+	if err != nil {
+		log.Printf(
+			"failed to record revision of course %s: %v", before.ID.Hex(), err,
+		)
+	}
+
 	return updateCourse, nil
 }
 
-func (s *courseService) DeleteCourse(ctx context.Context, id string) error {
-	objectId, err := primitive.ObjectIDFromHex(id)
+// ChangeStatus moves a course through its lifecycle: draft -> published ->
+// archived, and back from published to draft. Only the course's instructor
+// and admins can do it
+func (s *courseService) ChangeStatus(
+	ctx context.Context, id string, status string, callerId string,
+	callerRole string,
+) (*models.Course, error) {
+	course, err := s.findCourse(ctx, id)
 	if err != nil {
-		return ErrInvalidCourseID
+		return nil, err
 	}
 
-	err = s.repo.DeleteOne(ctx, objectId)
+	if !canManageCourse(course, callerId, callerRole) {
+		if course.Status != models.CourseStatusPublished {
+			return nil, ErrCourseNotFound
+		}
+		return nil, ErrForbidden
+	}
+
+	if !course.CanTransitionTo(status) {
+		return nil, fmt.Errorf(
+			"%w: %s to %s", ErrInvalidCourseTransition, course.Status, status,
+		)
+	}
+
+	now := time.Now()
+	set := bson.M{"status": status, "updated_at": now}
+	switch status {
+	case models.CourseStatusPublished:
+		missing := missingCourseFields(course)
+		if len(missing) > 0 {
+			return nil, &CourseIncompleteError{Fields: missing}
+		}
+		set["published_at"] = now
+	case models.CourseStatusArchived:
+		set["archived_at"] = now
+	}
+
+	updatedCourse, err := s.repo.Transition(
+		ctx, course.ID, course.Status, bson.M{"$set": set},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Someone else changed the status since the course was read
+		return nil, fmt.Errorf(
+			"%w: the course status changed, reload and try again",
+			ErrInvalidCourseTransition,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedCourse, nil
+}
+
+func (s *courseService) DeleteCourse(
+	ctx context.Context, id string, callerId string, callerRole string,
+) error {
+	course, err := s.findCourse(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return ErrNotCourseOwner
+	}
+
+	err = s.repo.DeleteOne(ctx, course.ID)
 
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return ErrCourseNotFound
 	}
+	if err != nil {
+		return err
+	}
+
+	return s.deleteCourseContent(ctx, []primitive.ObjectID{course.ID})
+}
+
+func (s *courseService) CountInstructorCourses(
+	ctx context.Context, instructorId primitive.ObjectID,
+) (int64, error) {
+	return s.repo.CountByInstructor(ctx, instructorId)
+}
+
+// DeleteInstructorCourses deletes every course of the instructor along with
+// everything that belongs to them
+func (s *courseService) DeleteInstructorCourses(
+	ctx context.Context, instructorId primitive.ObjectID,
+) (int64, error) {
+	courses, err := s.repo.FindByInstructor(ctx, instructorId)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.repo.DeleteByInstructor(ctx, instructorId)
+	if err != nil {
+		return 0, err
+	}
+
+	courseIds := make([]primitive.ObjectID, len(courses))
+	for i, course := range courses {
+		courseIds[i] = course.ID
+	}
+
+	return deleted, s.deleteCourseContent(ctx, courseIds)
+}
+
+func (s *courseService) ReassignInstructorCourses(
+	ctx context.Context, from primitive.ObjectID, to primitive.ObjectID,
+) (int64, error) {
+	return s.repo.ReassignInstructor(ctx, from, to)
+}
+
+// deleteCourseContent removes what hangs off deleted courses
+func (s *courseService) deleteCourseContent(
+	ctx context.Context, courseIds []primitive.ObjectID,
+) error {
+	if len(courseIds) == 0 {
+		return nil
+	}
+
+	_, err := s.curriculumRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.enrollmentRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.progressRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reviewRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reviewVoteRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.PullPrerequisites(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.revisionRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	materials, err := s.materialRepo.FindByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.materialRepo.DeleteByCourses(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+
+	return deleteMaterialFiles(ctx, s.materialFileRepo, materials)
+}
+
+func (s *courseService) DeleteLearnerData(
+	ctx context.Context, userId primitive.ObjectID,
+) error {
+	enrollments, err := s.enrollmentRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.enrollmentRepo.DeleteByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.progressRepo.DeleteByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.certificateRepo.DeleteByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	reviews, err := s.reviewRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = deleteReviews(ctx, s.reviewRepo, s.reviewVoteRepo, s.repo, reviews)
+	if err != nil {
+		return err
+	}
+
+	votes, err := s.reviewVoteRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reviewVoteRepo.DeleteByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, vote := range votes {
+		_, err = s.reviewRepo.AdjustHelpfulCount(ctx, vote.ReviewId, -1)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+
+	for _, enrollment := range enrollments {
+		if !enrollment.Counted() {
+			continue
+		}
+		err = s.repo.AdjustEnrollmentCount(ctx, enrollment.CourseId, -1)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -126,5 +617,157 @@ func (s *courseService) Drop(ctx context.Context) error {
 		return err
 	}
 
+	err = s.curriculumRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.enrollmentRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.progressRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.certificateRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.reviewRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.reviewVoteRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.revisionRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	materials, err := s.materialRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.materialRepo.Drop(ctx)
+	if err != nil {
+		return err
+	}
+
+	return deleteMaterialFiles(ctx, s.materialFileRepo, materials)
+}
+
+func (s *courseService) findCourse(
+	ctx context.Context, id string,
+) (*models.Course, error) {
+	return findCourse(ctx, s.repo, id)
+}
+
+// findCourse looks a course up by its hex id, for every service that hangs
+// something off courses
+func findCourse(
+	ctx context.Context, courseRepo repository.CourseRepository, id string,
+) (*models.Course, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidCourseID
+	}
+
+	course, err := courseRepo.FindOne(ctx, objectId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// canManageCourse reports whether the caller is the course's instructor or
+// an admin
+func canManageCourse(course *models.Course, userId string, role string) bool {
+	return role == "admin" || course.InstructorId.Hex() == userId
+}
+
+// missingCourseFields lists the fields a course needs before it can be
+// published
+func missingCourseFields(course *models.Course) []helpers.ValidationError {
+	var missing []helpers.ValidationError
+
+	if len(strings.TrimSpace(course.CourseName)) < 2 {
+		missing = append(missing, helpers.ValidationError{
+			Field: "course_name", Message: "course_name is required",
+		})
+	}
+	if len(strings.TrimSpace(course.Description)) == 0 {
+		missing = append(missing, helpers.ValidationError{
+			Field: "description", Message: "description is required",
+		})
+	}
+	if course.InstructorId.IsZero() {
+		missing = append(missing, helpers.ValidationError{
+			Field: "instructor_id", Message: "instructor_id is required",
+		})
+	}
+
+	return missing
+}
+
+func toMoney(price dto.MoneyDto) models.Money {
+	return models.Money{Amount: price.Amount, Currency: price.Currency}
+}
+
+func toMoneyList(prices []dto.MoneyDto) []models.Money {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	list := make([]models.Money, len(prices))
+	for i, price := range prices {
+		list[i] = toMoney(price)
+	}
+	return list
+}
+
+// checkPriceList makes sure every currency is priced once, either by the
+// course's price or by its price list
+func checkPriceList(price models.Money, priceList []models.Money) error {
+	seen := map[string]bool{price.Currency: true}
+	for _, listed := range priceList {
+		if seen[listed.Currency] {
+			return ErrDuplicatePriceCurrency
+		}
+		seen[listed.Currency] = true
+	}
 	return nil
 }
+
+// normalizeTags lowercases and trims tags and drops duplicates, so a filter
+// on a tag doesn't depend on how it was typed
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
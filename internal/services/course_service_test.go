@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckPriceList(t *testing.T) {
+	usd := models.Money{Amount: 1250, Currency: "USD"}
+	eur := models.Money{Amount: 1100, Currency: "EUR"}
+	egp := models.Money{Amount: 60000, Currency: "EGP"}
+
+	err := checkPriceList(usd, []models.Money{eur, egp})
+	if err != nil {
+		t.Errorf("distinct currencies rejected: %v", err)
+	}
+
+	err = checkPriceList(usd, []models.Money{{Amount: 1300, Currency: "USD"}})
+	if !errors.Is(err, ErrDuplicatePriceCurrency) {
+		t.Errorf("price list repeating the price's currency: got %v", err)
+	}
+
+	err = checkPriceList(usd, []models.Money{eur, {Amount: 1200, Currency: "EUR"}})
+	if !errors.Is(err, ErrDuplicatePriceCurrency) {
+		t.Errorf("price list repeating a currency: got %v", err)
+	}
+}
+
+func TestMissingCourseFieldsAllowsFreeCourses(t *testing.T) {
+	course := &models.Course{
+		CourseName:   "Go for the web",
+		Description:  "Build HTTP services",
+		Price:        models.Money{Amount: 0, Currency: "USD"},
+		InstructorId: primitive.NewObjectID(),
+	}
+	if missing := missingCourseFields(course); len(missing) != 0 {
+		t.Errorf("free course can't be published: %v", missing)
+	}
+
+	course.Description = " "
+	missing := missingCourseFields(course)
+	if len(missing) != 1 || missing[0].Field != "description" {
+		t.Errorf("missing = %v, want only the description", missing)
+	}
+}
@@ -0,0 +1,468 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrSectionNotFound  = errors.New("section not found")
+	ErrInvalidSectionID = errors.New("invalid section ID")
+	ErrLessonNotFound   = errors.New("lesson not found")
+	ErrInvalidLessonID  = errors.New("invalid lesson ID")
+
+	ErrNotCourseOwner         = errors.New("only the course's instructor can change it")
+	ErrInvalidCurriculumOrder = errors.New("invalid curriculum order")
+	ErrCurriculumTooLarge     = errors.New("curriculum is full")
+	ErrCurriculumConflict     = errors.New("curriculum was changed concurrently, try again")
+)
+
+const (
+	maxSections          = 50
+	maxLessonsPerSection = 100
+
+	// curriculumSaveAttempts is how often an edit is replayed on a fresh
+	// copy when another edit got saved in between
+	curriculumSaveAttempts = 3
+)
+
+type CurriculumService interface {
+	GetCurriculum(
+		ctx context.Context, courseId string, viewerId string, viewerRole string,
+	) (*models.Curriculum, error)
+	AddSection(
+		ctx context.Context, courseId string, callerId string,
+		sectionDto *dto.CreateSectionDto,
+	) (*models.Section, error)
+	UpdateSection(
+		ctx context.Context, courseId string, sectionId string, callerId string,
+		sectionDto *dto.UpdateSectionDto,
+	) (*models.Section, error)
+	DeleteSection(
+		ctx context.Context, courseId string, sectionId string, callerId string,
+	) error
+	AddLesson(
+		ctx context.Context, courseId string, sectionId string, callerId string,
+		lessonDto *dto.CreateLessonDto,
+	) (*models.Lesson, error)
+	UpdateLesson(
+		ctx context.Context, courseId string, sectionId string, lessonId string,
+		callerId string, lessonDto *dto.UpdateLessonDto,
+	) (*models.Lesson, error)
+	DeleteLesson(
+		ctx context.Context, courseId string, sectionId string, lessonId string,
+		callerId string,
+	) error
+	Reorder(
+		ctx context.Context, courseId string, callerId string,
+		orderDto *dto.ReorderCurriculumDto,
+	) (*models.Curriculum, error)
+}
+
+type curriculumService struct {
+	repo       repository.CurriculumRepository
+	courseRepo repository.CourseRepository
+}
+
+func NewCurriculumService(
+	repo repository.CurriculumRepository, courseRepo repository.CourseRepository,
+) CurriculumService {
+	return &curriculumService{repo: repo, courseRepo: courseRepo}
+}
+
+// GetCurriculum returns the course's sections and lessons in order. It is
+// as visible as the course itself
+func (s *curriculumService) GetCurriculum(
+	ctx context.Context, courseId string, viewerId string, viewerRole string,
+) (*models.Curriculum, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.Status != models.CourseStatusPublished &&
+		!canManageCourse(course, viewerId, viewerRole) {
+		return nil, ErrCourseNotFound
+	}
+
+	return s.load(ctx, course)
+}
+
+func (s *curriculumService) AddSection(
+	ctx context.Context, courseId string, callerId string,
+	sectionDto *dto.CreateSectionDto,
+) (*models.Section, error) {
+	sectionId := primitive.NewObjectID()
+
+	curriculum, err := s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			if len(curriculum.Sections) >= maxSections {
+				return fmt.Errorf(
+					"%w: at most %d sections", ErrCurriculumTooLarge, maxSections,
+				)
+			}
+
+			section := models.Section{
+				ID:        sectionId,
+				Title:     sectionDto.Title,
+				Lessons:   []models.Lesson{},
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			curriculum.Sections = insertAt(
+				curriculum.Sections, section, sectionDto.Position,
+			)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return curriculum.Section(sectionId), nil
+}
+
+func (s *curriculumService) UpdateSection(
+	ctx context.Context, courseId string, sectionId string, callerId string,
+	sectionDto *dto.UpdateSectionDto,
+) (*models.Section, error) {
+	sectionObjId, err := primitive.ObjectIDFromHex(sectionId)
+	if err != nil {
+		return nil, ErrInvalidSectionID
+	}
+
+	curriculum, err := s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			section := curriculum.Section(sectionObjId)
+			if section == nil {
+				return ErrSectionNotFound
+			}
+
+			if sectionDto.Title != nil {
+				section.Title = *sectionDto.Title
+			}
+			section.UpdatedAt = time.Now()
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return curriculum.Section(sectionObjId), nil
+}
+
+// DeleteSection removes a section together with its lessons
+func (s *curriculumService) DeleteSection(
+	ctx context.Context, courseId string, sectionId string, callerId string,
+) error {
+	sectionObjId, err := primitive.ObjectIDFromHex(sectionId)
+	if err != nil {
+		return ErrInvalidSectionID
+	}
+
+	_, err = s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			for i, section := range curriculum.Sections {
+				if section.ID == sectionObjId {
+					curriculum.Sections = append(
+						curriculum.Sections[:i], curriculum.Sections[i+1:]...,
+					)
+					return nil
+				}
+			}
+			return ErrSectionNotFound
+		},
+	)
+	return err
+}
+
+func (s *curriculumService) AddLesson(
+	ctx context.Context, courseId string, sectionId string, callerId string,
+	lessonDto *dto.CreateLessonDto,
+) (*models.Lesson, error) {
+	sectionObjId, err := primitive.ObjectIDFromHex(sectionId)
+	if err != nil {
+		return nil, ErrInvalidSectionID
+	}
+	lessonId := primitive.NewObjectID()
+
+	curriculum, err := s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			section := curriculum.Section(sectionObjId)
+			if section == nil {
+				return ErrSectionNotFound
+			}
+			if len(section.Lessons) >= maxLessonsPerSection {
+				return fmt.Errorf(
+					"%w: at most %d lessons per section",
+					ErrCurriculumTooLarge, maxLessonsPerSection,
+				)
+			}
+
+			lesson := models.Lesson{
+				ID:              lessonId,
+				Title:           lessonDto.Title,
+				ContentType:     lessonDto.ContentType,
+				DurationSeconds: lessonDto.DurationSeconds,
+				CreatedAt:       time.Now(),
+				UpdatedAt:       time.Now(),
+			}
+			section.Lessons = insertAt(section.Lessons, lesson, lessonDto.Position)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return curriculum.Section(sectionObjId).Lesson(lessonId), nil
+}
+
+func (s *curriculumService) UpdateLesson(
+	ctx context.Context, courseId string, sectionId string, lessonId string,
+	callerId string, lessonDto *dto.UpdateLessonDto,
+) (*models.Lesson, error) {
+	sectionObjId, lessonObjId, err := lessonIds(sectionId, lessonId)
+	if err != nil {
+		return nil, err
+	}
+
+	curriculum, err := s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			section := curriculum.Section(sectionObjId)
+			if section == nil {
+				return ErrSectionNotFound
+			}
+			lesson := section.Lesson(lessonObjId)
+			if lesson == nil {
+				return ErrLessonNotFound
+			}
+
+			if lessonDto.Title != nil {
+				lesson.Title = *lessonDto.Title
+			}
+			if lessonDto.ContentType != nil {
+				lesson.ContentType = *lessonDto.ContentType
+			}
+			if lessonDto.DurationSeconds != nil {
+				lesson.DurationSeconds = *lessonDto.DurationSeconds
+			}
+			lesson.UpdatedAt = time.Now()
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return curriculum.Section(sectionObjId).Lesson(lessonObjId), nil
+}
+
+func (s *curriculumService) DeleteLesson(
+	ctx context.Context, courseId string, sectionId string, lessonId string,
+	callerId string,
+) error {
+	sectionObjId, lessonObjId, err := lessonIds(sectionId, lessonId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			section := curriculum.Section(sectionObjId)
+			if section == nil {
+				return ErrSectionNotFound
+			}
+
+			for i, lesson := range section.Lessons {
+				if lesson.ID == lessonObjId {
+					section.Lessons = append(
+						section.Lessons[:i], section.Lessons[i+1:]...,
+					)
+					return nil
+				}
+			}
+			return ErrLessonNotFound
+		},
+	)
+	return err
+}
+
+// Reorder rearranges the whole curriculum in one write. The order has to
+// list every current section and lesson exactly once, so nothing added or
+// removed meanwhile gets lost
+func (s *curriculumService) Reorder(
+	ctx context.Context, courseId string, callerId string,
+	orderDto *dto.ReorderCurriculumDto,
+) (*models.Curriculum, error) {
+	return s.edit(ctx, courseId, callerId,
+		func(curriculum *models.Curriculum) error {
+			return reorderCurriculum(curriculum, orderDto)
+		},
+	)
+}
+
+// reorderCurriculum puts the curriculum's sections and lessons in the given
+// order, which has to list each of them exactly once
+func reorderCurriculum(
+	curriculum *models.Curriculum, order *dto.ReorderCurriculumDto,
+) error {
+	sections := make(map[string]models.Section, len(curriculum.Sections))
+	lessons := make(map[string]models.Lesson)
+	for _, section := range curriculum.Sections {
+		sections[section.ID.Hex()] = section
+		for _, lesson := range section.Lessons {
+			lessons[lesson.ID.Hex()] = lesson
+		}
+	}
+
+	if len(order.Sections) != len(sections) {
+		return fmt.Errorf(
+			"%w: expected %d sections, got %d", ErrInvalidCurriculumOrder,
+			len(sections), len(order.Sections),
+		)
+	}
+
+	ordered := make([]models.Section, 0, len(order.Sections))
+	for _, sectionOrder := range order.Sections {
+		section, ok := sections[sectionOrder.Id]
+		if !ok {
+			return fmt.Errorf(
+				"%w: unknown or repeated section %s",
+				ErrInvalidCurriculumOrder, sectionOrder.Id,
+			)
+		}
+		delete(sections, sectionOrder.Id)
+
+		if len(sectionOrder.Lessons) > maxLessonsPerSection {
+			return fmt.Errorf(
+				"%w: at most %d lessons per section",
+				ErrCurriculumTooLarge, maxLessonsPerSection,
+			)
+		}
+
+		section.Lessons = make([]models.Lesson, 0, len(sectionOrder.Lessons))
+		for _, lessonId := range sectionOrder.Lessons {
+			lesson, ok := lessons[lessonId]
+			if !ok {
+				return fmt.Errorf(
+					"%w: unknown or repeated lesson %s",
+					ErrInvalidCurriculumOrder, lessonId,
+				)
+			}
+			delete(lessons, lessonId)
+			section.Lessons = append(section.Lessons, lesson)
+		}
+		ordered = append(ordered, section)
+	}
+
+	if len(lessons) > 0 {
+		return fmt.Errorf(
+			"%w: %d lessons are missing", ErrInvalidCurriculumOrder,
+			len(lessons),
+		)
+	}
+
+	curriculum.Sections = ordered
+	return nil
+}
+
+// edit applies change to the course's curriculum and saves it. When another
+// edit was saved in between, change is replayed on the fresh curriculum
+func (s *curriculumService) edit(
+	ctx context.Context, courseId string, callerId string,
+	change func(curriculum *models.Curriculum) error,
+) (*models.Curriculum, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.InstructorId.Hex() != callerId {
+		return nil, ErrNotCourseOwner
+	}
+
+	for attempt := 0; attempt < curriculumSaveAttempts; attempt++ {
+		curriculum, err := s.load(ctx, course)
+		if err != nil {
+			return nil, err
+		}
+
+		err = change(curriculum)
+		if err != nil {
+			return nil, err
+		}
+		curriculum.Renumber()
+
+		err = s.repo.Save(ctx, curriculum)
+		if errors.Is(err, mongo.ErrNoDocuments) || mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return curriculum, nil
+	}
+
+	return nil, ErrCurriculumConflict
+}
+
+// load returns the course's curriculum, a course nobody added anything to
+// yet has an empty one
+func (s *curriculumService) load(
+	ctx context.Context, course *models.Course,
+) (*models.Curriculum, error) {
+	curriculum, err := s.repo.FindByCourse(ctx, course.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.Curriculum{
+			CourseId: course.ID,
+			TenantId: course.TenantId,
+			Sections: []models.Section{},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return curriculum, nil
+}
+
+func lessonIds(sectionId string, lessonId string) (
+	primitive.ObjectID, primitive.ObjectID, error,
+) {
+	sectionObjId, err := primitive.ObjectIDFromHex(sectionId)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, ErrInvalidSectionID
+	}
+
+	lessonObjId, err := primitive.ObjectIDFromHex(lessonId)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, ErrInvalidLessonID
+	}
+
+	return sectionObjId, lessonObjId, nil
+}
+
+// insertAt puts item at position, counting from 1, or last when position
+// is nil or past the end
+func insertAt[T any](items []T, item T, position *int) []T {
+	if position == nil || *position > len(items) {
+		return append(items, item)
+	}
+
+	index := *position - 1
+	items = append(items, item)
+	copy(items[index+1:], items[index:])
+	items[index] = item
+	return items
+}
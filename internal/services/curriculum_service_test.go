@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInsertAt(t *testing.T) {
+	at := func(position int) *int { return &position }
+
+	items := insertAt([]string{}, "b", nil)
+	items = insertAt(items, "a", at(1))
+	items = insertAt(items, "d", at(10))
+	items = insertAt(items, "c", at(3))
+	items = insertAt(items, "e", at(6))
+
+	want := []string{"a", "b", "c", "d", "e"}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %v, want %v", items, want)
+	}
+}
+
+// sampleCurriculum is "intro: welcome setup | basics: variables", lessons
+// and sections are looked up by title in the orders below
+func sampleCurriculum() *models.Curriculum {
+	section := func(title string, lessons ...string) models.Section {
+		s := models.Section{ID: primitive.NewObjectID(), Title: title}
+		for _, lesson := range lessons {
+			s.Lessons = append(s.Lessons, models.Lesson{
+				ID: primitive.NewObjectID(), Title: lesson,
+			})
+		}
+		return s
+	}
+
+	return &models.Curriculum{Sections: []models.Section{
+		section("intro", "welcome", "setup"),
+		section("basics", "variables"),
+	}}
+}
+
+func outline(curriculum *models.Curriculum) string {
+	sections := make([]string, len(curriculum.Sections))
+	for i, section := range curriculum.Sections {
+		titles := []string{section.Title + ":"}
+		for _, lesson := range section.Lessons {
+			titles = append(titles, lesson.Title)
+		}
+		sections[i] = strings.Join(titles, " ")
+	}
+	return strings.Join(sections, " | ")
+}
+
+// orderOf turns an outline like "basics: setup | intro: welcome" into the
+// order request for curriculum, unknown titles get fresh ids
+func orderOf(curriculum *models.Curriculum, layout string) *dto.ReorderCurriculumDto {
+	ids := map[string]string{}
+	for _, section := range curriculum.Sections {
+		ids[section.Title] = section.ID.Hex()
+		for _, lesson := range section.Lessons {
+			ids[lesson.Title] = lesson.ID.Hex()
+		}
+	}
+	idOf := func(title string) string {
+		if id, ok := ids[title]; ok {
+			return id
+		}
+		return primitive.NewObjectID().Hex()
+	}
+
+	order := &dto.ReorderCurriculumDto{}
+	for _, part := range strings.Split(layout, "|") {
+		titles := strings.Fields(part)
+		sectionOrder := dto.SectionOrderDto{
+			Id: idOf(strings.TrimSuffix(titles[0], ":")),
+		}
+		for _, lesson := range titles[1:] {
+			sectionOrder.Lessons = append(sectionOrder.Lessons, idOf(lesson))
+		}
+		order.Sections = append(order.Sections, sectionOrder)
+	}
+	return order
+}
+
+func TestReorderCurriculum(t *testing.T) {
+	layouts := []string{
+		"intro: welcome setup | basics: variables",
+		"basics: variables | intro: setup welcome",
+		"intro: welcome | basics: setup variables",
+		"intro: | basics: variables welcome setup",
+	}
+
+	for _, layout := range layouts {
+		curriculum := sampleCurriculum()
+
+		err := reorderCurriculum(curriculum, orderOf(curriculum, layout))
+		if err != nil {
+			t.Errorf("reordering to %q failed: %v", layout, err)
+			continue
+		}
+		if got := outline(curriculum); got != layout {
+			t.Errorf("curriculum = %q, want %q", got, layout)
+		}
+	}
+}
+
+func TestReorderCurriculumRejectsIncompleteOrders(t *testing.T) {
+	layouts := map[string]string{
+		"section left out": "intro: welcome setup variables",
+		"section repeated": "intro: welcome setup | intro: variables",
+		"lesson left out":  "intro: welcome | basics: variables",
+		"lesson repeated":  "intro: welcome setup | basics: variables setup",
+		"unknown lesson":   "intro: welcome setup | basics: variables loops",
+		"unknown section":  "intro: welcome setup | advanced: variables",
+	}
+
+	for name, layout := range layouts {
+		curriculum := sampleCurriculum()
+		before := outline(curriculum)
+
+		err := reorderCurriculum(curriculum, orderOf(curriculum, layout))
+		if !errors.Is(err, ErrInvalidCurriculumOrder) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalidCurriculumOrder)
+		}
+		if outline(curriculum) != before {
+			t.Errorf("%s: rejected order changed the curriculum", name)
+		}
+	}
+}
+
+func TestReorderCurriculumLimitsSectionSize(t *testing.T) {
+	section := models.Section{ID: primitive.NewObjectID()}
+	sectionOrder := dto.SectionOrderDto{Id: section.ID.Hex()}
+	for i := 0; i <= maxLessonsPerSection; i++ {
+		lesson := models.Lesson{ID: primitive.NewObjectID()}
+		section.Lessons = append(section.Lessons, lesson)
+		sectionOrder.Lessons = append(sectionOrder.Lessons, lesson.ID.Hex())
+	}
+	curriculum := &models.Curriculum{Sections: []models.Section{section}}
+
+	err := reorderCurriculum(curriculum, &dto.ReorderCurriculumDto{
+		Sections: []dto.SectionOrderDto{sectionOrder},
+	})
+	if !errors.Is(err, ErrCurriculumTooLarge) {
+		t.Errorf("got %v, want %v", err, ErrCurriculumTooLarge)
+	}
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrNotEnrolled             = errors.New("user is not enrolled in this course")
+	ErrCourseNotEnrollable     = errors.New("only published courses can be enrolled in")
+	ErrInvalidEnrollmentStatus = errors.New("enrollment status must be active, completed or cancelled")
+	ErrEnrollmentConflict      = errors.New("enrollment was changed concurrently, try again")
+)
+
+// enrollmentAttempts bounds how often a status change is retried when it
+// races another one
+const enrollmentAttempts = 3
+
+var enrollmentStatuses = map[string]bool{
+	models.EnrollmentStatusActive:    true,
+	models.EnrollmentStatusCompleted: true,
+	models.EnrollmentStatusCancelled: true,
+}
+
+type EnrollmentService interface {
+	// Enroll enrolls the user in the course, or reactivates a cancelled
+	// enrollment. Enrolling twice is harmless, changed tells whether
+	// anything happened. Learners who haven't completed the course's
+	// prerequisites get a *PrerequisitesNotMetError
+	Enroll(ctx context.Context, courseId string, userId string) (
+		enrollment *models.Enrollment, changed bool, err error,
+	)
+	// EnrollLearner enrolls a learner like Enroll but skips the
+	// prerequisite check, it is how admins override it
+	EnrollLearner(ctx context.Context, courseId string, learnerId string) (
+		enrollment *models.Enrollment, changed bool, err error,
+	)
+	Unenroll(ctx context.Context, courseId string, userId string) (
+		*models.Enrollment, error,
+	)
+	UpdateEnrollmentStatus(
+		ctx context.Context, courseId string, learnerId string, callerId string,
+		callerRole string, status string,
+	) (*models.Enrollment, error)
+	GetUserEnrollments(
+		ctx context.Context, userId string, callerId string, callerRole string,
+		status string, page int64, pageSize int64,
+	) ([]dto.EnrolledCourse, int64, error)
+	GetCourseEnrollments(
+		ctx context.Context, courseId string, callerId string, callerRole string,
+		status string, page int64, pageSize int64,
+	) ([]models.Enrollment, int64, error)
+}
+
+type enrollmentService struct {
+	repo         repository.EnrollmentRepository
+	courseRepo   repository.CourseRepository
+	certificates CertificateService
+}
+
+func NewEnrollmentService(
+	repo repository.EnrollmentRepository, courseRepo repository.CourseRepository,
+	certificates CertificateService,
+) EnrollmentService {
+	return &enrollmentService{
+		repo: repo, courseRepo: courseRepo, certificates: certificates,
+	}
+}
+
+func (s *enrollmentService) Enroll(
+	ctx context.Context, courseId string, userId string,
+) (*models.Enrollment, bool, error) {
+	return s.enroll(ctx, courseId, userId, true)
+}
+
+func (s *enrollmentService) EnrollLearner(
+	ctx context.Context, courseId string, learnerId string,
+) (*models.Enrollment, bool, error) {
+	return s.enroll(ctx, courseId, learnerId, false)
+}
+
+func (s *enrollmentService) enroll(
+	ctx context.Context, courseId string, userId string,
+	checkPrerequisites bool,
+) (*models.Enrollment, bool, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if course.Status != models.CourseStatusPublished {
+		return nil, false, ErrCourseNotEnrollable
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, false, ErrInvalidUserID
+	}
+
+	for attempt := 0; attempt < enrollmentAttempts; attempt++ {
+		enrollment, err := s.repo.FindOne(ctx, userObjId, course.ID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, false, err
+		}
+		if err == nil && enrollment.Counted() {
+			return enrollment, false, nil
+		}
+
+		if checkPrerequisites {
+			missing, err := missingPrerequisites(
+				ctx, s.courseRepo, s.repo, course, userObjId,
+			)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(missing) > 0 {
+				return nil, false, &PrerequisitesNotMetError{Missing: missing}
+			}
+			checkPrerequisites = false
+		}
+
+		if enrollment == nil {
+			now := time.Now()
+			enrollment, err = s.repo.Create(ctx, &models.Enrollment{
+				UserId:     userObjId,
+				CourseId:   course.ID,
+				TenantId:   course.TenantId,
+				Status:     models.EnrollmentStatusActive,
+				EnrolledAt: now,
+				UpdatedAt:  now,
+			})
+			if mongo.IsDuplicateKeyError(err) {
+				// Another request enrolled the user first
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+
+			err = s.courseRepo.AdjustEnrollmentCount(ctx, course.ID, 1)
+			if err != nil {
+				return nil, false, err
+			}
+			return enrollment, true, nil
+		}
+
+		enrollment, err = setEnrollmentStatus(
+			ctx, s.repo, s.courseRepo, enrollment, models.EnrollmentStatusActive,
+		)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return enrollment, true, nil
+	}
+
+	return nil, false, ErrEnrollmentConflict
+}
+
+// Unenroll cancels the user's enrollment. Unenrolling from a course left
+// earlier is harmless
+func (s *enrollmentService) Unenroll(
+	ctx context.Context, courseId string, userId string,
+) (*models.Enrollment, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.changeStatus(ctx, course, userId, models.EnrollmentStatusCancelled)
+}
+
+// UpdateEnrollmentStatus lets the course's instructor or an admin complete,
+// cancel or reactivate a learner's enrollment
+func (s *enrollmentService) UpdateEnrollmentStatus(
+	ctx context.Context, courseId string, learnerId string, callerId string,
+	callerRole string, status string,
+) (*models.Enrollment, error) {
+	if !enrollmentStatuses[status] {
+		return nil, ErrInvalidEnrollmentStatus
+	}
+
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	return s.changeStatus(ctx, course, learnerId, status)
+}
+
+func (s *enrollmentService) changeStatus(
+	ctx context.Context, course *models.Course, userId string, status string,
+) (*models.Enrollment, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	for attempt := 0; attempt < enrollmentAttempts; attempt++ {
+		enrollment, err := s.repo.FindOne(ctx, userObjId, course.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotEnrolled
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if enrollment.Status == status {
+			return enrollment, nil
+		}
+
+		enrollment, err = setEnrollmentStatus(
+			ctx, s.repo, s.courseRepo, enrollment, status,
+		)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if status == models.EnrollmentStatusCompleted {
+			issueCertificate(ctx, s.certificates, enrollment)
+		}
+		return enrollment, nil
+	}
+
+	return nil, ErrEnrollmentConflict
+}
+
+// GetUserEnrollments lists a learner's courses, most recently enrolled
+// first. Learners see their own, admins anybody's
+func (s *enrollmentService) GetUserEnrollments(
+	ctx context.Context, userId string, callerId string, callerRole string,
+	status string, page int64, pageSize int64,
+) ([]dto.EnrolledCourse, int64, error) {
+	if status != "" && !enrollmentStatuses[status] {
+		return nil, 0, ErrInvalidEnrollmentStatus
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, 0, ErrInvalidUserID
+	}
+
+	if userId != callerId && callerRole != "admin" {
+		return nil, 0, ErrForbidden
+	}
+
+	enrollments, totalCount, err := s.repo.FindByUser(
+		ctx, userObjId, status, page, pageSize,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	courseIds := make([]primitive.ObjectID, len(enrollments))
+	for i, enrollment := range enrollments {
+		courseIds[i] = enrollment.CourseId
+	}
+
+	courses, err := s.courseRepo.FindByIds(ctx, courseIds)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	coursesById := make(map[primitive.ObjectID]models.Course, len(courses))
+	for _, course := range courses {
+		coursesById[course.ID] = course
+	}
+
+	enrolled := make([]dto.EnrolledCourse, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		course, ok := coursesById[enrollment.CourseId]
+		if !ok {
+			continue
+		}
+		enrolled = append(enrolled, dto.EnrolledCourse{
+			Enrollment: enrollment, Course: course,
+		})
+	}
+
+	return enrolled, totalCount, nil
+}
+
+// GetCourseEnrollments lists a course's learners, most recently enrolled
+// first. Only the course's instructor and admins can see them
+func (s *enrollmentService) GetCourseEnrollments(
+	ctx context.Context, courseId string, callerId string, callerRole string,
+	status string, page int64, pageSize int64,
+) ([]models.Enrollment, int64, error) {
+	if status != "" && !enrollmentStatuses[status] {
+		return nil, 0, ErrInvalidEnrollmentStatus
+	}
+
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, 0, ErrNotCourseOwner
+	}
+
+	return s.repo.FindByCourse(ctx, course.ID, status, page, pageSize)
+}
+
+// setEnrollmentStatus moves enrollment to status unless it changed since it
+// was read, which returns mongo.ErrNoDocuments, and keeps the course's
+// enrollment count in step
+func setEnrollmentStatus(
+	ctx context.Context, repo repository.EnrollmentRepository,
+	courseRepo repository.CourseRepository, enrollment *models.Enrollment,
+	status string,
+) (*models.Enrollment, error) {
+	now := time.Now()
+	set := bson.M{"status": status, "updated_at": now}
+	unset := bson.M{}
+
+	switch status {
+	case models.EnrollmentStatusActive:
+		unset["completed_at"] = ""
+		unset["cancelled_at"] = ""
+		if enrollment.Status == models.EnrollmentStatusCancelled {
+			// Coming back starts a new enrollment period
+			set["enrolled_at"] = now
+		}
+	case models.EnrollmentStatusCompleted:
+		set["completed_at"] = now
+		unset["cancelled_at"] = ""
+	case models.EnrollmentStatusCancelled:
+		set["cancelled_at"] = now
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	wasCounted := enrollment.Counted()
+	updated, err := repo.Transition(
+		ctx, enrollment.ID, []string{enrollment.Status}, update,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var delta int64
+	switch {
+	case wasCounted && !updated.Counted():
+		delta = -1
+	case !wasCounted && updated.Counted():
+		delta = 1
+	}
+	if delta != 0 {
+		err = courseRepo.AdjustEnrollmentCount(ctx, updated.CourseId, delta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"strconv"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrExportNotFound      = errors.New("export not found")
+	ErrInvalidExportID     = errors.New("invalid export ID")
+	ErrExportNotReady      = errors.New("export is not ready yet")
+	ErrInvalidDownloadLink = errors.New("download link is invalid or has expired")
+	ErrForbidden           = errors.New("you don't have permission to access this resource")
+)
+
+// exportTimeout bounds how long building a single batch of archives may take
+const exportTimeout = 5 * time.Minute
+
+type ExportService interface {
+	RequestExport(ctx context.Context, userId string, requestedBy string) (
+		*dto.DataExportResponse, error,
+	)
+	GetExport(
+		ctx context.Context, exportId string, callerId string, callerRole string,
+	) (*dto.DataExportResponse, error)
+	OpenDownload(
+		ctx context.Context, exportId string, expires string, signature string,
+	) (*models.File, io.ReadCloser, error)
+	ProcessPendingExports(ctx context.Context) error
+	DeleteExpiredExports(ctx context.Context) (int64, error)
+}
+
+type exportService struct {
+	exportRepo       repository.DataExportRepository
+	exportFileRepo   repository.FileRepository
+	userRepo         repository.UserRepository
+	avatarRepo       repository.FileRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	courseRepo       repository.CourseRepository
+	enrollmentRepo   repository.EnrollmentRepository
+	progressRepo     repository.CourseProgressRepository
+	certificateRepo  repository.CertificateRepository
+	reviewRepo       repository.ReviewRepository
+	reviewVoteRepo   repository.ReviewVoteRepository
+	applicationRepo  repository.InstructorApplicationRepository
+	invitationRepo   repository.InvitationRepository
+	membershipRepo   repository.MembershipRepository
+}
+
+func NewExportService(
+	exportRepo repository.DataExportRepository,
+	exportFileRepo repository.FileRepository,
+	userRepo repository.UserRepository,
+	avatarRepo repository.FileRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	courseRepo repository.CourseRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	progressRepo repository.CourseProgressRepository,
+	certificateRepo repository.CertificateRepository,
+	reviewRepo repository.ReviewRepository,
+	reviewVoteRepo repository.ReviewVoteRepository,
+	applicationRepo repository.InstructorApplicationRepository,
+	invitationRepo repository.InvitationRepository,
+	membershipRepo repository.MembershipRepository,
+) ExportService {
+	return &exportService{
+		exportRepo:       exportRepo,
+		exportFileRepo:   exportFileRepo,
+		userRepo:         userRepo,
+		avatarRepo:       avatarRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		courseRepo:       courseRepo,
+		enrollmentRepo:   enrollmentRepo,
+		progressRepo:     progressRepo,
+		certificateRepo:  certificateRepo,
+		reviewRepo:       reviewRepo,
+		reviewVoteRepo:   reviewVoteRepo,
+		applicationRepo:  applicationRepo,
+		invitationRepo:   invitationRepo,
+		membershipRepo:   membershipRepo,
+	}
+}
+
+// RequestExport queues an export of userId's data. A user only ever has one
+// export in flight, asking again returns the existing job
+func (s *exportService) RequestExport(
+	ctx context.Context, userId string, requestedBy string,
+) (*dto.DataExportResponse, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	requesterObjId, err := primitive.ObjectIDFromHex(requestedBy)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	_, err = s.userRepo.GetOneUser(ctx, userObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	activeExport, err := s.exportRepo.FindActiveByUser(ctx, userObjId)
+	if err == nil {
+		return &dto.DataExportResponse{DataExport: *activeExport}, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	export, err := s.exportRepo.Create(
+		ctx, &models.DataExport{
+			UserId:      userObjId,
+			RequestedBy: requesterObjId,
+			Status:      models.ExportStatusPending,
+			Active:      true,
+			CreatedAt:   time.Now(),
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// A request running alongside this one queued the export first
+		activeExport, err = s.exportRepo.FindActiveByUser(ctx, userObjId)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.DataExportResponse{DataExport: *activeExport}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the archive in the background, the scheduled job picks up
+	// anything this misses (e.g. after a restart)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		err := s.ProcessPendingExports(ctx)
+		if err != nil {
+			log.Printf("failed to process data exports: %v", err)
+		}
+	}()
+
+	return &dto.DataExportResponse{DataExport: *export}, nil
+}
+
+func (s *exportService) GetExport(
+	ctx context.Context, exportId string, callerId string, callerRole string,
+) (*dto.DataExportResponse, error) {
+	export, err := s.findExport(ctx, exportId)
+	if err != nil {
+		return nil, err
+	}
+
+	isParticipant := export.UserId.Hex() == callerId ||
+		export.RequestedBy.Hex() == callerId
+	if callerRole != "admin" && !isParticipant {
+		return nil, ErrForbidden
+	}
+
+	// Organization admins only see exports of their own tenant's users
+	_, scoped := tenant.FromContext(ctx)
+	if scoped && !isParticipant {
+		_, err = s.userRepo.GetOneUser(ctx, export.UserId)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrExportNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := &dto.DataExportResponse{DataExport: *export}
+	if export.Status != models.ExportStatusCompleted || export.ExpiresAt == nil {
+		return response, nil
+	}
+
+	linkMinutes := helpers.GetEnvInt("DATA_EXPORT_LINK_EXPIRY_MINUTES", 60)
+	linkExpiry := time.Now().Add(time.Duration(linkMinutes) * time.Minute)
+	if linkExpiry.After(*export.ExpiresAt) {
+		linkExpiry = *export.ExpiresAt
+	}
+
+	expires := strconv.FormatInt(linkExpiry.Unix(), 10)
+	signature, err := helpers.Sign(export.ID.Hex() + ":" + expires)
+	if err != nil {
+		return nil, err
+	}
+
+	response.DownloadURL = fmt.Sprintf(
+		"/api/v1/exports/%s/download?expires=%s&signature=%s",
+		export.ID.Hex(), expires, signature,
+	)
+
+	return response, nil
+}
+
+func (s *exportService) OpenDownload(
+	ctx context.Context, exportId string, expires string, signature string,
+) (*models.File, io.ReadCloser, error) {
+	if !helpers.VerifySignature(exportId+":"+expires, signature) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	export, err := s.findExport(ctx, exportId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if export.Status != models.ExportStatusCompleted || export.FileId == nil {
+		return nil, nil, ErrExportNotReady
+	}
+
+	file, stream, err := s.exportFileRepo.Open(ctx, *export.FileId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, stream, nil
+}
+
+// ProcessPendingExports builds archives for queued exports until the queue
+// is empty. Every run is bounded by exportTimeout, so an export still
+// processing after that was left behind by a worker that stopped and is
+// built again
+func (s *exportService) ProcessPendingExports(ctx context.Context) error {
+	for {
+		export, err := s.exportRepo.ClaimPending(
+			ctx, time.Now().Add(-exportTimeout),
+		)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fileId, err := s.buildArchive(ctx, export)
+		if err != nil {
+			log.Printf("data export %s failed: %v", export.ID.Hex(), err)
+			err = s.exportRepo.MarkFailed(ctx, export, err.Error())
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return err
+			}
+			continue
+		}
+
+		retentionHours := helpers.GetEnvInt("DATA_EXPORT_RETENTION_HOURS", 72)
+		expiresAt := time.Now().Add(time.Duration(retentionHours) * time.Hour)
+
+		err = s.exportRepo.MarkCompleted(ctx, export, fileId, expiresAt)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Another worker took the export over, its archive is the one
+			// that counts
+			_ = s.exportFileRepo.Delete(ctx, fileId)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// DeleteExpiredExports removes archives past their retention period along
+// with their job documents
+func (s *exportService) DeleteExpiredExports(ctx context.Context) (
+	int64, error,
+) {
+	exports, err := s.exportRepo.FindExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, export := range exports {
+		if export.FileId != nil {
+			err = s.exportFileRepo.Delete(ctx, *export.FileId)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return deleted, err
+			}
+		}
+
+		err = s.exportRepo.DeleteOne(ctx, export.ID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func (s *exportService) findExport(
+	ctx context.Context, exportId string,
+) (*models.DataExport, error) {
+	objId, err := primitive.ObjectIDFromHex(exportId)
+	if err != nil {
+		return nil, ErrInvalidExportID
+	}
+
+	export, err := s.exportRepo.FindOne(ctx, objId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// buildArchive collects everything linked to the export's user into a zip
+// of JSON files and stores it in GridFS
+func (s *exportService) buildArchive(
+	ctx context.Context, export *models.DataExport,
+) (primitive.ObjectID, error) {
+	user, err := s.userRepo.GetOneUser(ctx, export.UserId)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	sessions, err := s.refreshTokenRepo.FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to load sessions: %w", err)
+	}
+
+	courses, err := s.courseRepo.FindByInstructor(ctx, user.ID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to load courses: %w", err)
+	}
+
+	exports, err := s.exportRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to load exports: %w", err)
+	}
+
+	learning, err := s.loadLearning(ctx, user.ID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	applications, err := s.applicationRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf(
+			"failed to load instructor applications: %w", err,
+		)
+	}
+
+	invitations, err := s.invitationRepo.FindByUser(ctx, user.ID, user.Email)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to load invitations: %w", err)
+	}
+
+	memberships, err := s.membershipRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to load memberships: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"manifest.json", map[string]interface{}{
+			"exportId":    export.ID.Hex(),
+			"userId":      user.ID.Hex(),
+			"generatedAt": time.Now(),
+		}},
+		{"profile.json", user},
+		{"sessions.json", sessions},
+		{"courses.json", courses},
+		{"enrollments.json", learning.enrollments},
+		{"progress.json", learning.progress},
+		{"certificates.json", learning.certificates},
+		{"reviews.json", learning.reviews},
+		{"review_votes.json", learning.reviewVotes},
+		{"instructor_applications.json", applications},
+		{"invitations.json", invitations},
+		{"memberships.json", memberships},
+		{"data_exports.json", exports},
+	}
+
+	for _, file := range files {
+		err = writeJSONFile(archive, file.name, file.data)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+
+	if user.AvatarId != nil {
+		err = s.writeAvatar(ctx, archive, *user.AvatarId)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	file, err := s.exportFileRepo.Upload(
+		ctx, "data-export-"+export.ID.Hex()+".zip", &buffer,
+		models.FileMetadata{ContentType: "application/zip", OwnerId: user.ID},
+	)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return file.ID, nil
+}
+
+// learningData is what a user did as a learner
+type learningData struct {
+	enrollments  []models.Enrollment
+	progress     []models.CourseProgress
+	certificates []models.Certificate
+	reviews      []models.Review
+	reviewVotes  []models.ReviewVote
+}
+
+func (s *exportService) loadLearning(
+	ctx context.Context, userId primitive.ObjectID,
+) (*learningData, error) {
+	var learning learningData
+	var err error
+
+	learning.enrollments, err = s.enrollmentRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load enrollments: %w", err)
+	}
+
+	learning.progress, err = s.progressRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load progress: %w", err)
+	}
+
+	learning.certificates, err = s.certificateRepo.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificates: %w", err)
+	}
+
+	learning.reviews, err = s.reviewRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reviews: %w", err)
+	}
+
+	learning.reviewVotes, err = s.reviewVoteRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load review votes: %w", err)
+	}
+
+	return &learning, nil
+}
+
+func (s *exportService) writeAvatar(
+	ctx context.Context, archive *zip.Writer, avatarId primitive.ObjectID,
+) error {
+	file, stream, err := s.avatarRepo.Open(ctx, avatarId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	name := "avatar"
+	extensions, _ := mime.ExtensionsByType(file.Metadata.ContentType)
+	if len(extensions) > 0 {
+		name += extensions[0]
+	}
+
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, stream)
+	return err
+}
+
+func writeJSONFile(archive *zip.Writer, name string, data interface{}) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(data)
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/mailer"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrApplicationNotFound  = errors.New("instructor application not found")
+	ErrInvalidApplicationID = errors.New("invalid instructor application ID")
+	ErrApplicationPending   = errors.New("you already have a pending instructor application")
+	ErrAlreadyInstructor    = errors.New("you can already create courses")
+)
+
+type InstructorApplicationService interface {
+	Apply(
+		ctx context.Context, userId string,
+		applicationDto *dto.CreateInstructorApplicationDto,
+	) (*models.InstructorApplication, error)
+	GetMyApplications(ctx context.Context, userId string) (
+		[]models.InstructorApplication, error,
+	)
+	GetAllApplications(
+		ctx context.Context, status string, page int64, pageSize int64,
+	) ([]models.InstructorApplication, int64, error)
+	Approve(ctx context.Context, id string, reviewerId string) (
+		*models.InstructorApplication, error,
+	)
+	Reject(ctx context.Context, id string, reviewerId string, reason string) (
+		*models.InstructorApplication, error,
+	)
+}
+
+type instructorApplicationService struct {
+	repo             repository.InstructorApplicationRepository
+	userRepo         repository.UserRepository
+	membershipRepo   repository.MembershipRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	mailer           mailer.Mailer
+}
+
+func NewInstructorApplicationService(
+	repo repository.InstructorApplicationRepository,
+	userRepo repository.UserRepository,
+	membershipRepo repository.MembershipRepository,
+	refreshTokenRepo repository.RefreshTokenRepository, mailer mailer.Mailer,
+) InstructorApplicationService {
+	return &instructorApplicationService{
+		repo:             repo,
+		userRepo:         userRepo,
+		membershipRepo:   membershipRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		mailer:           mailer,
+	}
+}
+
+func (s *instructorApplicationService) Apply(
+	ctx context.Context, userId string,
+	applicationDto *dto.CreateInstructorApplicationDto,
+) (*models.InstructorApplication, error) {
+	user, err := s.findUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == "instructor" || user.Role == "admin" {
+		return nil, ErrAlreadyInstructor
+	}
+
+	// The partial unique index allows a single pending application per user
+	application, err := s.repo.Create(
+		ctx, &models.InstructorApplication{
+			UserId:         user.ID,
+			Bio:            applicationDto.Bio,
+			SampleMaterial: applicationDto.SampleMaterial,
+			Status:         models.ApplicationStatusPending,
+			CreatedAt:      time.Now(),
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrApplicationPending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+func (s *instructorApplicationService) GetMyApplications(
+	ctx context.Context, userId string,
+) ([]models.InstructorApplication, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	return s.repo.FindByUser(ctx, userObjId)
+}
+
+func (s *instructorApplicationService) GetAllApplications(
+	ctx context.Context, status string, page int64, pageSize int64,
+) ([]models.InstructorApplication, int64, error) {
+	return s.repo.FindAll(ctx, status, page, pageSize)
+}
+
+// Approve grants the applicant the instructor role and ends their sessions,
+// so their next token carries it
+func (s *instructorApplicationService) Approve(
+	ctx context.Context, id string, reviewerId string,
+) (*models.InstructorApplication, error) {
+	application, err := s.review(
+		ctx, id, reviewerId, models.ApplicationStatusApproved, "",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.grantInstructorRole(ctx, application)
+	if err != nil {
+		// Leave the application in the queue rather than approved without
+		// the role
+		reopenErr := s.repo.Reopen(ctx, application.ID)
+		if reopenErr != nil {
+			log.Printf(
+				"failed to reopen instructor application %s: %v",
+				application.ID.Hex(), reopenErr,
+			)
+		}
+		return nil, err
+	}
+
+	s.notify(
+		ctx, application, "Your instructor application was approved",
+		"Your application to become an instructor was approved. Log in "+
+			"again to start creating courses.",
+	)
+
+	return application, nil
+}
+
+func (s *instructorApplicationService) Reject(
+	ctx context.Context, id string, reviewerId string, reason string,
+) (*models.InstructorApplication, error) {
+	application, err := s.review(
+		ctx, id, reviewerId, models.ApplicationStatusRejected, reason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(
+		ctx, application, "Your instructor application was not approved",
+		fmt.Sprintf(
+			"Your application to become an instructor was not approved:\n\n"+
+				"%s\n\nYou're welcome to apply again.", reason,
+		),
+	)
+
+	return application, nil
+}
+
+func (s *instructorApplicationService) review(
+	ctx context.Context, id string, reviewerId string, status string,
+	reason string,
+) (*models.InstructorApplication, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidApplicationID
+	}
+
+	reviewerObjId, err := primitive.ObjectIDFromHex(reviewerId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	// Only pending applications match, decided ones are reported as missing
+	application, err := s.repo.Review(ctx, objId, reviewerObjId, status, reason)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrApplicationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+func (s *instructorApplicationService) grantInstructorRole(
+	ctx context.Context, application *models.InstructorApplication,
+) error {
+	user, err := s.userRepo.UpdateOneUser(
+		ctx, application.UserId, bson.M{
+			"$set": bson.M{"role": "instructor", "updated_at": time.Now()},
+		},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.TenantId != nil {
+		_, err = s.membershipRepo.UpdateRole(
+			ctx, *user.TenantId, user.ID, "instructor",
+		)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+
+	_, err = s.refreshTokenRepo.RevokeAllUserTokens(ctx, user.ID)
+	return err
+}
+
+// notify emails the applicant about a decision. The decision stands even
+// if the email can't be sent
+func (s *instructorApplicationService) notify(
+	ctx context.Context, application *models.InstructorApplication,
+	subject string, body string,
+) {
+	user, err := s.userRepo.GetOneUser(ctx, application.UserId)
+	if err != nil {
+		log.Printf(
+			"failed to load applicant %s: %v", application.UserId.Hex(), err,
+		)
+		return
+	}
+
+	err = s.mailer.Send(
+		ctx, user.Email, subject, fmt.Sprintf("Hi %s,\n\n%s", user.Name, body),
+	)
+	if err != nil {
+		log.Printf("failed to email applicant %s: %v", user.ID.Hex(), err)
+	}
+}
+
+func (s *instructorApplicationService) findUser(
+	ctx context.Context, userId string,
+) (*models.User, error) {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	user, err := s.userRepo.GetOneUser(ctx, userObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/mailer"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvalidInvitationID     = errors.New("invalid invitation ID")
+	ErrInvitationExists        = errors.New("a pending invitation for this email already exists")
+	ErrInvalidInvitation       = errors.New("invitation is invalid or has expired")
+	ErrInvitationRequired      = errors.New("registration requires an invitation")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email")
+)
+
+// RegistrationModeInviteOnly makes an invitation mandatory to register
+const RegistrationModeInviteOnly = "invite_only"
+
+// instructorInvitableRoles are the roles an instructor may hand out, admins
+// can invite with any role
+var instructorInvitableRoles = map[string]bool{"user": true}
+
+type InvitationService interface {
+	CreateInvitation(
+		ctx context.Context, inviterId string, inviterRole string,
+		invitationDto *dto.CreateInvitationDto,
+	) (*models.Invitation, error)
+	GetAllInvitations(
+		ctx context.Context, status string, page int64, pageSize int64,
+	) ([]models.Invitation, int64, error)
+	RevokeInvitation(ctx context.Context, id string) (*models.Invitation, error)
+}
+
+type invitationService struct {
+	repo     repository.InvitationRepository
+	userRepo repository.UserRepository
+	mailer   mailer.Mailer
+}
+
+func NewInvitationService(
+	repo repository.InvitationRepository, userRepo repository.UserRepository,
+	mailer mailer.Mailer,
+) InvitationService {
+	return &invitationService{repo: repo, userRepo: userRepo, mailer: mailer}
+}
+
+// CreateInvitation stores an invitation and mails its registration link.
+// The token itself is only ever part of that link
+func (s *invitationService) CreateInvitation(
+	ctx context.Context, inviterId string, inviterRole string,
+	invitationDto *dto.CreateInvitationDto,
+) (*models.Invitation, error) {
+	inviterObjId, err := primitive.ObjectIDFromHex(inviterId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if inviterRole != "admin" && !instructorInvitableRoles[invitationDto.Role] {
+		return nil, ErrForbidden
+	}
+
+	email := strings.TrimSpace(invitationDto.Email)
+
+	// Emails are unique across tenants, so check every tenant's users
+	existing, err := s.userRepo.FindExistingEmails(ctx, []string{email})
+	if err != nil {
+		return nil, err
+	}
+	if existing[email] {
+		return nil, ErrEmailAlreadyExists
+	}
+
+	pending, err := s.repo.FindPendingByEmail(ctx, email)
+	if pending != nil {
+		return nil, ErrInvitationExists
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	token, err := helpers.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiryDays := helpers.GetEnvInt("INVITATION_EXPIRY_DAYS", 7)
+	invitation, err := s.repo.Create(
+		ctx, &models.Invitation{
+			Email:     email,
+			Role:      invitationDto.Role,
+			TokenHash: helpers.HashToken(token),
+			Status:    models.InvitationStatusPending,
+			InvitedBy: inviterObjId,
+			ExpiresAt: time.Now().AddDate(0, 0, expiryDays),
+			CreatedAt: time.Now(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(
+		ctx, email, "You're invited",
+		fmt.Sprintf(
+			"Hi,\n\nYou've been invited to join as %s. Create your account by "+
+				"opening the link below within %d days:\n\n%s\n\n"+
+				"If you weren't expecting this, you can ignore this email.",
+			invitation.Role, expiryDays,
+			helpers.AppURL("/register?invite="+token),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *invitationService) GetAllInvitations(
+	ctx context.Context, status string, page int64, pageSize int64,
+) ([]models.Invitation, int64, error) {
+	return s.repo.FindAll(ctx, status, page, pageSize)
+}
+
+func (s *invitationService) RevokeInvitation(
+	ctx context.Context, id string,
+) (*models.Invitation, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidInvitationID
+	}
+
+	// Only pending invitations can be revoked
+	invitation, err := s.repo.Revoke(ctx, objId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrInvalidOrganizationID = errors.New("invalid organization ID")
+	ErrOrganizationExists    = errors.New("an organization with this slug already exists")
+	ErrMembershipNotFound    = errors.New("user is not a member of this organization")
+	ErrAlreadyMember         = errors.New("user already belongs to an organization")
+)
+
+type OrganizationService interface {
+	CreateOrganization(
+		ctx context.Context, creatorId string,
+		organizationDto *dto.CreateOrganizationDto,
+	) (*models.Organization, error)
+	GetAllOrganizations(ctx context.Context, page int64, pageSize int64) (
+		[]models.Organization, int64, error,
+	)
+	GetOrganization(ctx context.Context, id string) (*models.Organization, error)
+	GetMembers(
+		ctx context.Context, organizationId string, page int64, pageSize int64,
+	) ([]models.Membership, int64, error)
+	AddMember(
+		ctx context.Context, organizationId string, memberDto *dto.AddMemberDto,
+	) (*models.Membership, error)
+	UpdateMemberRole(
+		ctx context.Context, organizationId string, userId string, role string,
+	) (*models.Membership, error)
+}
+
+type organizationService struct {
+	repo             repository.OrganizationRepository
+	membershipRepo   repository.MembershipRepository
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+}
+
+func NewOrganizationService(
+	repo repository.OrganizationRepository,
+	membershipRepo repository.MembershipRepository,
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+) OrganizationService {
+	return &organizationService{
+		repo:             repo,
+		membershipRepo:   membershipRepo,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+	}
+}
+
+// isPlatformRequest reports whether ctx is not scoped to a tenant, only
+// platform admins manage organizations themselves
+func isPlatformRequest(ctx context.Context) bool {
+	_, scoped := tenant.FromContext(ctx)
+	return !scoped
+}
+
+func (s *organizationService) CreateOrganization(
+	ctx context.Context, creatorId string,
+	organizationDto *dto.CreateOrganizationDto,
+) (*models.Organization, error) {
+	if !isPlatformRequest(ctx) {
+		return nil, ErrForbidden
+	}
+
+	creatorObjId, err := primitive.ObjectIDFromHex(creatorId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	organization, err := s.repo.Create(
+		ctx, &models.Organization{
+			Name:      organizationDto.Name,
+			Slug:      organizationDto.Slug,
+			CreatedBy: creatorObjId,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrOrganizationExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (s *organizationService) GetAllOrganizations(
+	ctx context.Context, page int64, pageSize int64,
+) ([]models.Organization, int64, error) {
+	return s.repo.FindAll(ctx, page, pageSize)
+}
+
+func (s *organizationService) GetOrganization(
+	ctx context.Context, id string,
+) (*models.Organization, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidOrganizationID
+	}
+
+	// Tenant scoped callers can only find their own organization
+	organization, err := s.repo.FindOne(ctx, objId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (s *organizationService) GetMembers(
+	ctx context.Context, organizationId string, page int64, pageSize int64,
+) ([]models.Membership, int64, error) {
+	organization, err := s.GetOrganization(ctx, organizationId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.membershipRepo.FindByOrganization(
+		ctx, organization.ID, page, pageSize,
+	)
+}
+
+// AddMember moves a user who doesn't belong to any organization into this
+// one. Their sessions are ended so the next token carries the tenant
+func (s *organizationService) AddMember(
+	ctx context.Context, organizationId string, memberDto *dto.AddMemberDto,
+) (*models.Membership, error) {
+	if !isPlatformRequest(ctx) {
+		return nil, ErrForbidden
+	}
+
+	organization, err := s.GetOrganization(ctx, organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(memberDto.UserId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	user, err := s.userRepo.GetOneUser(ctx, userObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.TenantId != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	// The unique index on user_id settles concurrent additions
+	membership, err := s.membershipRepo.Create(
+		ctx, &models.Membership{
+			OrganizationId: organization.ID,
+			UserId:         user.ID,
+			Role:           memberDto.Role,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrAlreadyMember
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.userRepo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{
+				"tenant_id":  organization.ID,
+				"role":       memberDto.Role,
+				"updated_at": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.refreshTokenRepo.RevokeAllUserTokens(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// UpdateMemberRole changes a member's role inside the organization and on
+// the user, ending their sessions so the new role takes effect
+func (s *organizationService) UpdateMemberRole(
+	ctx context.Context, organizationId string, userId string, role string,
+) (*models.Membership, error) {
+	organization, err := s.GetOrganization(ctx, organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	membership, err := s.membershipRepo.UpdateRole(
+		ctx, organization.ID, userObjId, role,
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMembershipNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.userRepo.UpdateOneUser(
+		ctx, userObjId, bson.M{
+			"$set": bson.M{"role": role, "updated_at": time.Now()},
+		},
+	)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	_, err = s.refreshTokenRepo.RevokeAllUserTokens(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
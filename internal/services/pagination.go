@@ -0,0 +1,103 @@
+package services
+
+import (
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// decodeKeyset turns a client's cursor back into the position it marks in
+// a list sorted by sort. Sort values of timestamp fields are times, the
+// rest are strings
+func decodeKeyset(token string, sort string, timeField bool) (
+	*repository.Keyset, error,
+) {
+	if token == "" {
+		return nil, nil
+	}
+
+	cursor, err := helpers.DecodeCursor(token, sort)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return nil, helpers.ErrInvalidCursor
+	}
+
+	keyset := &repository.Keyset{Id: id, Backward: cursor.Backward}
+	if timeField {
+		keyset.SortValue, err = time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, helpers.ErrInvalidCursor
+		}
+	} else {
+		keyset.SortValue = cursor.Value
+	}
+
+	return keyset, nil
+}
+
+// cursorTime is how a timestamp sort value is kept in a cursor
+func cursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// keysetPage drops the extra document the repositories fetch past a page
+// and builds the cursors leading away from the page. position returns a
+// document's sort value as kept in a cursor, and its id
+func keysetPage[T any](
+	documents []T, pageSize int64, page int64, keyset *repository.Keyset,
+	sort string, position func(T) (string, primitive.ObjectID),
+) ([]T, dto.PageCursors, error) {
+	var cursors dto.PageCursors
+
+	backward := keyset != nil && keyset.Backward
+	overflow := int64(len(documents)) > pageSize
+	if overflow {
+		if backward {
+			documents = documents[1:]
+		} else {
+			documents = documents[:pageSize]
+		}
+	}
+
+	if len(documents) == 0 {
+		return documents, cursors, nil
+	}
+
+	// Walking forward there is more ahead only when the extra document
+	// came back, and something behind once past the first page. Walking
+	// backward it is the other way round
+	hasNext := overflow
+	hasPrev := keyset != nil || page > 1
+	if backward {
+		hasNext, hasPrev = true, overflow
+	}
+
+	var err error
+	if hasNext {
+		value, id := position(documents[len(documents)-1])
+		cursors.Next, err = helpers.EncodeCursor(helpers.Cursor{
+			Sort: sort, Value: value, ID: id.Hex(),
+		})
+		if err != nil {
+			return nil, cursors, err
+		}
+	}
+	if hasPrev {
+		value, id := position(documents[0])
+		cursors.Prev, err = helpers.EncodeCursor(helpers.Cursor{
+			Sort: sort, Value: value, ID: id.Hex(), Backward: true,
+		})
+		if err != nil {
+			return nil, cursors, err
+		}
+	}
+
+	return documents, cursors, nil
+}
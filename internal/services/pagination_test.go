@@ -0,0 +1,149 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type listedName struct {
+	name string
+	id   primitive.ObjectID
+}
+
+// fetchNames plays the repository: it returns up to pageSize+1 names past
+// keyset in list order, the ones closest to the keyset when going backward
+func fetchNames(
+	names []listedName, keyset *repository.Keyset, pageSize int,
+) []listedName {
+	var page []listedName
+	for _, listed := range names {
+		switch {
+		case keyset == nil,
+			keyset.Backward && listed.name < keyset.SortValue.(string),
+			!keyset.Backward && listed.name > keyset.SortValue.(string):
+			page = append(page, listed)
+		}
+	}
+
+	if len(page) <= pageSize+1 {
+		return page
+	}
+	if keyset != nil && keyset.Backward {
+		return page[len(page)-pageSize-1:]
+	}
+	return page[:pageSize+1]
+}
+
+func namesOf(page []listedName) string {
+	names := make([]string, len(page))
+	for i, listed := range page {
+		names[i] = listed.name
+	}
+	return strings.Join(names, " ")
+}
+
+func TestKeysetPageWalk(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	var names []listedName
+	for _, name := range strings.Fields("a b c d e") {
+		names = append(names, listedName{name: name, id: primitive.NewObjectID()})
+	}
+	position := func(listed listedName) (string, primitive.ObjectID) {
+		return listed.name, listed.id
+	}
+
+	var keyset *repository.Keyset
+	load := func(wantNames string) dto.PageCursors {
+		t.Helper()
+		page, cursors, err := keysetPage(
+			fetchNames(names, keyset, 2), 2, 1, keyset, "name", position,
+		)
+		if err != nil {
+			t.Fatalf("keysetPage failed: %v", err)
+		}
+		if got := namesOf(page); got != wantNames {
+			t.Fatalf("page = %q, want %q", got, wantNames)
+		}
+		return cursors
+	}
+	follow := func(token string) {
+		t.Helper()
+		if token == "" {
+			t.Fatalf("expected a cursor to follow")
+		}
+		var err error
+		keyset, err = decodeKeyset(token, "name", false)
+		if err != nil {
+			t.Fatalf("cursor doesn't decode: %v", err)
+		}
+	}
+
+	cursors := load("a b")
+	if cursors.Prev != "" {
+		t.Errorf("first page has a previous cursor")
+	}
+
+	follow(cursors.Next)
+	cursors = load("c d")
+
+	follow(cursors.Next)
+	cursors = load("e")
+	if cursors.Next != "" {
+		t.Errorf("last page has a next cursor")
+	}
+
+	follow(cursors.Prev)
+	cursors = load("c d")
+	if cursors.Next == "" || cursors.Prev == "" {
+		t.Errorf("middle page misses a cursor: %+v", cursors)
+	}
+
+	follow(cursors.Prev)
+	cursors = load("a b")
+	if cursors.Prev != "" {
+		t.Errorf("walking back to the start still offers a previous cursor")
+	}
+}
+
+func TestKeysetPageOffsetPages(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	page := []listedName{{name: "e", id: primitive.NewObjectID()}}
+	_, cursors, err := keysetPage(
+		page, 2, 3, nil, "name",
+		func(listed listedName) (string, primitive.ObjectID) {
+			return listed.name, listed.id
+		},
+	)
+	if err != nil {
+		t.Fatalf("keysetPage failed: %v", err)
+	}
+
+	// A page reached by number still leads back, but not forward past the end
+	if cursors.Prev == "" || cursors.Next != "" {
+		t.Errorf("cursors = %+v, want only a previous cursor", cursors)
+	}
+}
+
+func TestDecodeKeysetRejectsOtherSort(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	_, cursors, err := keysetPage(
+		[]listedName{{name: "a"}, {name: "b"}}, 1, 1, nil, "name",
+		func(listed listedName) (string, primitive.ObjectID) {
+			return listed.name, listed.id
+		},
+	)
+	if err != nil {
+		t.Fatalf("keysetPage failed: %v", err)
+	}
+
+	if _, err := decodeKeyset(cursors.Next, "email", false); err == nil {
+		t.Errorf("a name cursor was accepted for an email sorted list")
+	}
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxPrerequisites bounds how many courses a course may require directly
+const maxPrerequisites = 20
+
+var (
+	ErrPrerequisiteNotFound = errors.New("prerequisite course not found")
+	ErrPrerequisiteCycle    = errors.New("prerequisite would make the course require itself")
+	ErrTooManyPrerequisites = fmt.Errorf(
+		"a course can have at most %d prerequisites", maxPrerequisites,
+	)
+	ErrPrerequisitesNotMet = errors.New("complete the course's prerequisites first")
+)
+
+// PrerequisitesNotMetError lists the prerequisites a learner still has to
+// complete before enrolling
+type PrerequisitesNotMetError struct {
+	Missing []dto.MissingPrerequisite
+}
+
+func (e *PrerequisitesNotMetError) Error() string {
+	names := make([]string, len(e.Missing))
+	for i, missing := range e.Missing {
+		names[i] = missing.CourseName
+	}
+	return fmt.Sprintf("%s: %s", ErrPrerequisitesNotMet, strings.Join(names, ", "))
+}
+
+func (e *PrerequisitesNotMetError) Is(target error) bool {
+	return target == ErrPrerequisitesNotMet
+}
+
+type PrerequisiteService interface {
+	// AddPrerequisite makes the course require another course, rejecting
+	// prerequisites that would close a cycle
+	AddPrerequisite(
+		ctx context.Context, courseId string, prerequisiteId string,
+		callerId string, callerRole string,
+	) (*models.Course, error)
+	RemovePrerequisite(
+		ctx context.Context, courseId string, prerequisiteId string,
+		callerId string, callerRole string,
+	) (*models.Course, error)
+	// GetPrerequisites returns the course with everything it requires,
+	// marking what a signed in viewer completed
+	GetPrerequisites(
+		ctx context.Context, courseId string, viewerId string, viewerRole string,
+	) (*dto.PrerequisiteNode, error)
+}
+
+type prerequisiteService struct {
+	courseRepo     repository.CourseRepository
+	enrollmentRepo repository.EnrollmentRepository
+}
+
+func NewPrerequisiteService(
+	courseRepo repository.CourseRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+) PrerequisiteService {
+	return &prerequisiteService{
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+	}
+}
+
+func (s *prerequisiteService) AddPrerequisite(
+	ctx context.Context, courseId string, prerequisiteId string, callerId string,
+	callerRole string,
+) (*models.Course, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	prerequisite, err := findCourse(ctx, s.courseRepo, prerequisiteId)
+	if errors.Is(err, ErrCourseNotFound) {
+		return nil, ErrPrerequisiteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Learners can only complete published courses, requiring a draft
+	// would leave the course impossible to enroll in
+	if prerequisite.Status != models.CourseStatusPublished {
+		return nil, ErrPrerequisiteNotFound
+	}
+
+	if prerequisite.ID == course.ID {
+		return nil, ErrPrerequisiteCycle
+	}
+
+	for _, id := range course.Prerequisites {
+		if id == prerequisite.ID {
+			return course, nil
+		}
+	}
+
+	cycle, err := requires(ctx, s.courseRepo, prerequisite.ID, course.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, ErrPrerequisiteCycle
+	}
+
+	updated, err := s.courseRepo.AddPrerequisite(
+		ctx, course.ID, prerequisite.ID, maxPrerequisites,
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTooManyPrerequisites
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Two courses made to require each other at the same time both pass
+	// the check above, looking again after writing catches that. Both
+	// then back out, which leaves no cycle behind
+	cycle, err = requires(ctx, s.courseRepo, prerequisite.ID, course.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		_, err = s.courseRepo.RemovePrerequisite(ctx, course.ID, prerequisite.ID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrPrerequisiteCycle
+	}
+
+	return updated, nil
+}
+
+func (s *prerequisiteService) RemovePrerequisite(
+	ctx context.Context, courseId string, prerequisiteId string, callerId string,
+	callerRole string,
+) (*models.Course, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, ErrNotCourseOwner
+	}
+
+	prerequisiteObjId, err := primitive.ObjectIDFromHex(prerequisiteId)
+	if err != nil {
+		return nil, ErrInvalidCourseID
+	}
+
+	updated, err := s.courseRepo.RemovePrerequisite(
+		ctx, course.ID, prerequisiteObjId,
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *prerequisiteService) GetPrerequisites(
+	ctx context.Context, courseId string, viewerId string, viewerRole string,
+) (*dto.PrerequisiteNode, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.Status != models.CourseStatusPublished &&
+		!canManageCourse(course, viewerId, viewerRole) {
+		return nil, ErrCourseNotFound
+	}
+
+	courses, err := prerequisiteClosure(ctx, s.courseRepo, course)
+	if err != nil {
+		return nil, err
+	}
+
+	var completed map[primitive.ObjectID]bool
+	viewerObjId, err := primitive.ObjectIDFromHex(viewerId)
+	if err == nil {
+		completed, err = completedCourses(ctx, s.enrollmentRepo, viewerObjId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tree := prerequisiteTree(course, courses, completed, map[primitive.ObjectID]bool{})
+	return &tree, nil
+}
+
+// requires reports whether course from requires course target, directly or
+// through its prerequisites
+func requires(
+	ctx context.Context, courseRepo repository.CourseRepository,
+	from primitive.ObjectID, target primitive.ObjectID,
+) (bool, error) {
+	visited := map[primitive.ObjectID]bool{from: true}
+	frontier := []primitive.ObjectID{from}
+
+	for len(frontier) > 0 {
+		courses, err := courseRepo.FindByIds(ctx, frontier)
+		if err != nil {
+			return false, err
+		}
+
+		frontier = nil
+		for _, course := range courses {
+			for _, id := range course.Prerequisites {
+				if id == target {
+					return true, nil
+				}
+				if !visited[id] {
+					visited[id] = true
+					frontier = append(frontier, id)
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// prerequisiteClosure loads every course the course requires, directly or
+// through its prerequisites, one level per query
+func prerequisiteClosure(
+	ctx context.Context, courseRepo repository.CourseRepository,
+	course *models.Course,
+) (map[primitive.ObjectID]*models.Course, error) {
+	courses := map[primitive.ObjectID]*models.Course{course.ID: course}
+	frontier := course.Prerequisites
+
+	for len(frontier) > 0 {
+		loaded, err := courseRepo.FindByIds(ctx, frontier)
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for i := range loaded {
+			courses[loaded[i].ID] = &loaded[i]
+		}
+		for i := range loaded {
+			for _, id := range loaded[i].Prerequisites {
+				if _, seen := courses[id]; !seen && !containsId(frontier, id) {
+					frontier = append(frontier, id)
+				}
+			}
+		}
+	}
+
+	return courses, nil
+}
+
+// prerequisiteTree nests the course's prerequisites under it. A course
+// required along several paths shows up under each of them
+func prerequisiteTree(
+	course *models.Course, courses map[primitive.ObjectID]*models.Course,
+	completed map[primitive.ObjectID]bool, path map[primitive.ObjectID]bool,
+) dto.PrerequisiteNode {
+	node := dto.PrerequisiteNode{
+		CourseId:      course.ID,
+		CourseName:    course.CourseName,
+		Status:        course.Status,
+		Prerequisites: []dto.PrerequisiteNode{},
+	}
+	if completed != nil {
+		done := completed[course.ID]
+		node.Completed = &done
+	}
+
+	path[course.ID] = true
+	defer delete(path, course.ID)
+
+	for _, id := range course.Prerequisites {
+		prerequisite, ok := courses[id]
+		// Cycles are rejected when prerequisites are added, the path
+		// check only keeps a corrupted graph from recursing forever
+		if !ok || path[id] {
+			continue
+		}
+		node.Prerequisites = append(
+			node.Prerequisites,
+			prerequisiteTree(prerequisite, courses, completed, path),
+		)
+	}
+
+	return node
+}
+
+// missingPrerequisites lists the course's prerequisites the learner hasn't
+// completed. Prerequisites that were archived or unpublished since can't be
+// taken anymore, so they don't block anyone
+func missingPrerequisites(
+	ctx context.Context, courseRepo repository.CourseRepository,
+	enrollmentRepo repository.EnrollmentRepository, course *models.Course,
+	userId primitive.ObjectID,
+) ([]dto.MissingPrerequisite, error) {
+	if len(course.Prerequisites) == 0 {
+		return nil, nil
+	}
+
+	completed, err := completedCourses(ctx, enrollmentRepo, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var missingIds []primitive.ObjectID
+	for _, id := range course.Prerequisites {
+		if !completed[id] {
+			missingIds = append(missingIds, id)
+		}
+	}
+	if len(missingIds) == 0 {
+		return nil, nil
+	}
+
+	courses, err := courseRepo.FindByIds(ctx, missingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []dto.MissingPrerequisite
+	for _, prerequisite := range courses {
+		if prerequisite.Status != models.CourseStatusPublished {
+			continue
+		}
+		missing = append(missing, dto.MissingPrerequisite{
+			CourseId:   prerequisite.ID,
+			CourseName: prerequisite.CourseName,
+		})
+	}
+
+	return missing, nil
+}
+
+func completedCourses(
+	ctx context.Context, enrollmentRepo repository.EnrollmentRepository,
+	userId primitive.ObjectID,
+) (map[primitive.ObjectID]bool, error) {
+	enrollments, err := enrollmentRepo.FindAllByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[primitive.ObjectID]bool, len(enrollments))
+	for _, enrollment := range enrollments {
+		if enrollment.Status == models.EnrollmentStatusCompleted {
+			completed[enrollment.CourseId] = true
+		}
+	}
+
+	return completed, nil
+}
+
+func containsId(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// courseGraph serves FindByIds from memory, the other CourseRepository
+// methods aren't used by the prerequisite helpers
+type courseGraph struct {
+	repository.CourseRepository
+	courses map[primitive.ObjectID]models.Course
+}
+
+func (g *courseGraph) FindByIds(
+	ctx context.Context, ids []primitive.ObjectID,
+) ([]models.Course, error) {
+	courses := []models.Course{}
+	for _, id := range ids {
+		if course, ok := g.courses[id]; ok {
+			courses = append(courses, course)
+		}
+	}
+	return courses, nil
+}
+
+// newCourseGraph builds courses named after the keys of edges, each
+// requiring the courses listed for it
+func newCourseGraph(edges map[string][]string) (
+	*courseGraph, map[string]primitive.ObjectID,
+) {
+	ids := map[string]primitive.ObjectID{}
+	idOf := func(name string) primitive.ObjectID {
+		if _, ok := ids[name]; !ok {
+			ids[name] = primitive.NewObjectID()
+		}
+		return ids[name]
+	}
+
+	graph := &courseGraph{courses: map[primitive.ObjectID]models.Course{}}
+	for name, required := range edges {
+		course := models.Course{
+			ID:         idOf(name),
+			CourseName: name,
+			Status:     models.CourseStatusPublished,
+		}
+		for _, prerequisite := range required {
+			course.Prerequisites = append(course.Prerequisites, idOf(prerequisite))
+		}
+		graph.courses[course.ID] = course
+	}
+
+	return graph, ids
+}
+
+func TestRequires(t *testing.T) {
+	graph, ids := newCourseGraph(map[string][]string{
+		"go":       {"basics"},
+		"web":      {"go", "html"},
+		"cloud":    {"web"},
+		"basics":   {},
+		"html":     {},
+		"loop-a":   {"loop-b"},
+		"loop-b":   {"loop-a"},
+		"isolated": {},
+	})
+
+	tests := []struct {
+		name   string
+		from   string
+		target string
+		want   bool
+	}{
+		{"direct prerequisite", "go", "basics", true},
+		{"prerequisite of a prerequisite", "cloud", "basics", true},
+		{"second branch", "cloud", "html", true},
+		{"dependent is not required", "basics", "go", false},
+		{"unrelated course", "isolated", "go", false},
+		{"course itself is not required", "go", "go", false},
+		{"existing cycle still terminates", "loop-a", "go", false},
+		{"cycle member", "loop-a", "loop-b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := requires(
+				context.Background(), graph, ids[tt.from], ids[tt.target],
+			)
+			if err != nil {
+				t.Fatalf("requires returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("requires(%s, %s) = %v, want %v", tt.from, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// treeNames flattens a tree into "name(child,child)" form for comparison
+func treeNames(node dto.PrerequisiteNode) string {
+	names := node.CourseName
+	if len(node.Prerequisites) == 0 {
+		return names
+	}
+
+	names += "("
+	for i, child := range node.Prerequisites {
+		if i > 0 {
+			names += ","
+		}
+		names += treeNames(child)
+	}
+	return names + ")"
+}
+
+func TestPrerequisiteTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		root  string
+		edges map[string][]string
+		want  string
+	}{
+		{
+			name:  "no prerequisites",
+			root:  "basics",
+			edges: map[string][]string{"basics": {}},
+			want:  "basics",
+		},
+		{
+			name: "nested prerequisites",
+			root: "cloud",
+			edges: map[string][]string{
+				"cloud": {"web"}, "web": {"go", "html"}, "go": {}, "html": {},
+			},
+			want: "cloud(web(go,html))",
+		},
+		{
+			name: "shared prerequisite shows up on every path",
+			root: "web",
+			edges: map[string][]string{
+				"web": {"go", "html"}, "go": {"basics"}, "html": {"basics"},
+				"basics": {},
+			},
+			want: "web(go(basics),html(basics))",
+		},
+		{
+			name:  "cycle is cut instead of recursing",
+			root:  "loop-a",
+			edges: map[string][]string{"loop-a": {"loop-b"}, "loop-b": {"loop-a"}},
+			want:  "loop-a(loop-b)",
+		},
+		{
+			name:  "unloaded prerequisite is skipped",
+			root:  "go",
+			edges: map[string][]string{"go": {"deleted"}},
+			want:  "go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, ids := newCourseGraph(tt.edges)
+			courses := map[primitive.ObjectID]*models.Course{}
+			for id, course := range graph.courses {
+				course := course
+				courses[id] = &course
+			}
+
+			tree := prerequisiteTree(
+				courses[ids[tt.root]], courses, nil, map[primitive.ObjectID]bool{},
+			)
+			if got := treeNames(tree); got != tt.want {
+				t.Errorf("tree = %s, want %s", got, tt.want)
+			}
+			if tree.Completed != nil {
+				t.Errorf("anonymous viewer got completion flags")
+			}
+		})
+	}
+}
+
+func TestPrerequisiteTreeMarksCompleted(t *testing.T) {
+	graph, ids := newCourseGraph(map[string][]string{
+		"web": {"go", "html"}, "go": {}, "html": {},
+	})
+	courses := map[primitive.ObjectID]*models.Course{}
+	for id, course := range graph.courses {
+		course := course
+		courses[id] = &course
+	}
+	completed := map[primitive.ObjectID]bool{ids["go"]: true}
+
+	tree := prerequisiteTree(
+		courses[ids["web"]], courses, completed, map[primitive.ObjectID]bool{},
+	)
+
+	want := map[string]bool{"web": false, "go": true, "html": false}
+	var check func(node dto.PrerequisiteNode)
+	check = func(node dto.PrerequisiteNode) {
+		if node.Completed == nil {
+			t.Fatalf("%s has no completion flag", node.CourseName)
+		}
+		if *node.Completed != want[node.CourseName] {
+			t.Errorf(
+				"%s completed = %v, want %v",
+				node.CourseName, *node.Completed, want[node.CourseName],
+			)
+		}
+		for _, child := range node.Prerequisites {
+			check(child)
+		}
+	}
+	check(tree)
+}
+
+// learnerEnrollments serves FindAllByUser from memory
+type learnerEnrollments struct {
+	repository.EnrollmentRepository
+	enrollments []models.Enrollment
+}
+
+func (e *learnerEnrollments) FindAllByUser(
+	ctx context.Context, userId primitive.ObjectID,
+) ([]models.Enrollment, error) {
+	return e.enrollments, nil
+}
+
+func TestMissingPrerequisites(t *testing.T) {
+	graph, ids := newCourseGraph(map[string][]string{
+		"web": {"go", "html", "css"}, "go": {}, "html": {}, "css": {},
+	})
+
+	tests := []struct {
+		name      string
+		completed []string
+		archived  []string
+		want      []string
+	}{
+		{"nothing completed", nil, nil, []string{"go", "html", "css"}},
+		{"some completed", []string{"go"}, nil, []string{"html", "css"}},
+		{"all completed", []string{"go", "html", "css"}, nil, nil},
+		{"archived prerequisite doesn't block", []string{"go"}, []string{"html"}, []string{"css"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses := &courseGraph{courses: map[primitive.ObjectID]models.Course{}}
+			for id, course := range graph.courses {
+				courses.courses[id] = course
+			}
+			for _, name := range tt.archived {
+				course := courses.courses[ids[name]]
+				course.Status = models.CourseStatusArchived
+				courses.courses[ids[name]] = course
+			}
+
+			enrollments := &learnerEnrollments{}
+			for _, name := range tt.completed {
+				enrollments.enrollments = append(enrollments.enrollments, models.Enrollment{
+					CourseId: ids[name],
+					Status:   models.EnrollmentStatusCompleted,
+				})
+			}
+
+			web := courses.courses[ids["web"]]
+			missing, err := missingPrerequisites(
+				context.Background(), courses, enrollments, &web,
+				primitive.NewObjectID(),
+			)
+			if err != nil {
+				t.Fatalf("missingPrerequisites returned error: %v", err)
+			}
+
+			got := map[string]bool{}
+			for _, prerequisite := range missing {
+				got[prerequisite.CourseName] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("missing = %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("%s is not listed as missing", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrReviewNotFound     = errors.New("review not found")
+	ErrInvalidReviewID    = errors.New("invalid review id")
+	ErrInvalidReviewSort  = errors.New("sort must be newest or helpful")
+	ErrAlreadyReviewed    = errors.New("you already reviewed this course")
+	ErrNotReviewAuthor    = errors.New("only the review's author can do this")
+	ErrOwnCourseReview    = errors.New("instructors can't review their own course")
+	ErrOwnReviewVote      = errors.New("you can't vote for your own review")
+	ErrReviewConflict     = errors.New("review was changed concurrently, try again")
+	ErrReviewNotEnrolled  = errors.New("only learners enrolled in the course can review it")
+	ErrReviewReplyMissing = errors.New("review has no reply")
+)
+
+// reviewAttempts bounds how often an edit is retried when the rating
+// changed between reading and updating the review
+const reviewAttempts = 3
+
+type ReviewService interface {
+	CreateReview(
+		ctx context.Context, courseId string, userId string,
+		reviewDto *dto.CreateReviewDto,
+	) (*models.Review, error)
+	GetReviews(
+		ctx context.Context, courseId string, viewerId string, viewerRole string,
+		sort string, page int64, pageSize int64,
+	) ([]models.Review, int64, error)
+	UpdateReview(
+		ctx context.Context, courseId string, reviewId string, userId string,
+		reviewDto *dto.UpdateReviewDto,
+	) (*models.Review, error)
+	// DeleteReview deletes a review, its author or an admin may
+	DeleteReview(
+		ctx context.Context, courseId string, reviewId string, callerId string,
+		callerRole string,
+	) error
+	ReplyToReview(
+		ctx context.Context, courseId string, reviewId string, callerId string,
+		callerRole string, replyDto *dto.ReviewReplyDto,
+	) (*models.Review, error)
+	DeleteReply(
+		ctx context.Context, courseId string, reviewId string, callerId string,
+		callerRole string,
+	) (*models.Review, error)
+	// MarkHelpful records that the user found a review helpful, voting
+	// twice counts once
+	MarkHelpful(
+		ctx context.Context, courseId string, reviewId string, userId string,
+	) (*models.Review, error)
+	UnmarkHelpful(
+		ctx context.Context, courseId string, reviewId string, userId string,
+	) (*models.Review, error)
+}
+
+type reviewService struct {
+	repo           repository.ReviewRepository
+	voteRepo       repository.ReviewVoteRepository
+	courseRepo     repository.CourseRepository
+	enrollmentRepo repository.EnrollmentRepository
+}
+
+func NewReviewService(
+	repo repository.ReviewRepository, voteRepo repository.ReviewVoteRepository,
+	courseRepo repository.CourseRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+) ReviewService {
+	return &reviewService{
+		repo:           repo,
+		voteRepo:       voteRepo,
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+	}
+}
+
+func (s *reviewService) CreateReview(
+	ctx context.Context, courseId string, userId string,
+	reviewDto *dto.CreateReviewDto,
+) (*models.Review, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if course.InstructorId.Hex() == userId {
+		return nil, ErrOwnCourseReview
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	enrollment, err := s.enrollmentRepo.FindOne(ctx, userObjId, course.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReviewNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !enrollment.Counted() {
+		return nil, ErrReviewNotEnrolled
+	}
+
+	now := time.Now()
+	review, err := s.repo.Create(ctx, &models.Review{
+		CourseId:  course.ID,
+		TenantId:  course.TenantId,
+		UserId:    userObjId,
+		Rating:    reviewDto.Rating,
+		Text:      strings.TrimSpace(reviewDto.Text),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrAlreadyReviewed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.courseRepo.AdjustRating(ctx, course.ID, int64(review.Rating), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetReviews lists a course's reviews, newest or most helpful first. Only
+// the instructor and admins see the reviews of unpublished courses
+func (s *reviewService) GetReviews(
+	ctx context.Context, courseId string, viewerId string, viewerRole string,
+	sort string, page int64, pageSize int64,
+) ([]models.Review, int64, error) {
+	if sort == "" {
+		sort = models.ReviewSortNewest
+	}
+	if sort != models.ReviewSortNewest && sort != models.ReviewSortHelpful {
+		return nil, 0, ErrInvalidReviewSort
+	}
+
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if course.Status != models.CourseStatusPublished &&
+		!canManageCourse(course, viewerId, viewerRole) {
+		return nil, 0, ErrCourseNotFound
+	}
+
+	return s.repo.FindByCourse(ctx, course.ID, sort, page, pageSize)
+}
+
+func (s *reviewService) UpdateReview(
+	ctx context.Context, courseId string, reviewId string, userId string,
+	reviewDto *dto.UpdateReviewDto,
+) (*models.Review, error) {
+	for attempt := 0; attempt < reviewAttempts; attempt++ {
+		review, err := s.findReview(ctx, courseId, reviewId)
+		if err != nil {
+			return nil, err
+		}
+
+		if review.UserId.Hex() != userId {
+			return nil, ErrNotReviewAuthor
+		}
+
+		set := bson.M{"updated_at": time.Now()}
+		rating := review.Rating
+		if reviewDto.Rating != nil {
+			rating = *reviewDto.Rating
+			set["rating"] = rating
+		}
+		if reviewDto.Text != nil {
+			set["text"] = strings.TrimSpace(*reviewDto.Text)
+		}
+
+		updated, err := s.repo.Update(
+			ctx, review.ID, review.Rating, bson.M{"$set": set},
+		)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if rating != review.Rating {
+			err = s.courseRepo.AdjustRating(
+				ctx, review.CourseId, int64(rating-review.Rating), 0,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return updated, nil
+	}
+
+	return nil, ErrReviewConflict
+}
+
+func (s *reviewService) DeleteReview(
+	ctx context.Context, courseId string, reviewId string, callerId string,
+	callerRole string,
+) error {
+	review, err := s.findReview(ctx, courseId, reviewId)
+	if err != nil {
+		return err
+	}
+
+	if review.UserId.Hex() != callerId && callerRole != "admin" {
+		return ErrNotReviewAuthor
+	}
+
+	return deleteReviews(
+		ctx, s.repo, s.voteRepo, s.courseRepo, []models.Review{*review},
+	)
+}
+
+func (s *reviewService) ReplyToReview(
+	ctx context.Context, courseId string, reviewId string, callerId string,
+	callerRole string, replyDto *dto.ReviewReplyDto,
+) (*models.Review, error) {
+	course, review, err := s.findManagedReview(
+		ctx, courseId, reviewId, callerId, callerRole,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	authorId, err := primitive.ObjectIDFromHex(callerId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	now := time.Now()
+	reply := &models.ReviewReply{
+		AuthorId:  authorId,
+		Text:      strings.TrimSpace(replyDto.Text),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if review.Reply != nil {
+		reply.CreatedAt = review.Reply.CreatedAt
+	}
+
+	return s.setReply(ctx, course.ID, review.ID, reply)
+}
+
+func (s *reviewService) DeleteReply(
+	ctx context.Context, courseId string, reviewId string, callerId string,
+	callerRole string,
+) (*models.Review, error) {
+	course, review, err := s.findManagedReview(
+		ctx, courseId, reviewId, callerId, callerRole,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.Reply == nil {
+		return nil, ErrReviewReplyMissing
+	}
+
+	return s.setReply(ctx, course.ID, review.ID, nil)
+}
+
+func (s *reviewService) MarkHelpful(
+	ctx context.Context, courseId string, reviewId string, userId string,
+) (*models.Review, error) {
+	review, err := s.findReview(ctx, courseId, reviewId)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.UserId.Hex() == userId {
+		return nil, ErrOwnReviewVote
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	_, err = s.voteRepo.Create(ctx, &models.ReviewVote{
+		ReviewId:  review.ID,
+		CourseId:  review.CourseId,
+		TenantId:  review.TenantId,
+		UserId:    userObjId,
+		CreatedAt: time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return review, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adjustHelpfulCount(ctx, review.ID, 1)
+}
+
+func (s *reviewService) UnmarkHelpful(
+	ctx context.Context, courseId string, reviewId string, userId string,
+) (*models.Review, error) {
+	review, err := s.findReview(ctx, courseId, reviewId)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	deleted, err := s.voteRepo.Delete(ctx, review.ID, userObjId)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return review, nil
+	}
+
+	return s.adjustHelpfulCount(ctx, review.ID, -1)
+}
+
+func (s *reviewService) findReview(
+	ctx context.Context, courseId string, reviewId string,
+) (*models.Review, error) {
+	courseObjId, err := primitive.ObjectIDFromHex(courseId)
+	if err != nil {
+		return nil, ErrInvalidCourseID
+	}
+
+	reviewObjId, err := primitive.ObjectIDFromHex(reviewId)
+	if err != nil {
+		return nil, ErrInvalidReviewID
+	}
+
+	review, err := s.repo.FindById(ctx, courseObjId, reviewObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// findManagedReview looks up a review of a course the caller instructs, or
+// any course for admins
+func (s *reviewService) findManagedReview(
+	ctx context.Context, courseId string, reviewId string, callerId string,
+	callerRole string,
+) (*models.Course, *models.Review, error) {
+	course, err := findCourse(ctx, s.courseRepo, courseId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !canManageCourse(course, callerId, callerRole) {
+		return nil, nil, ErrNotCourseOwner
+	}
+
+	review, err := s.findReview(ctx, courseId, reviewId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return course, review, nil
+}
+
+func (s *reviewService) setReply(
+	ctx context.Context, courseId primitive.ObjectID, reviewId primitive.ObjectID,
+	reply *models.ReviewReply,
+) (*models.Review, error) {
+	review, err := s.repo.SetReply(ctx, courseId, reviewId, reply)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (s *reviewService) adjustHelpfulCount(
+	ctx context.Context, reviewId primitive.ObjectID, delta int64,
+) (*models.Review, error) {
+	review, err := s.repo.AdjustHelpfulCount(ctx, reviewId, delta)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+// deleteReviews deletes reviews with their helpful votes and takes their
+// ratings off their courses. A review somebody else deleted first is
+// skipped, so its rating is only taken off once
+func deleteReviews(
+	ctx context.Context, reviewRepo repository.ReviewRepository,
+	voteRepo repository.ReviewVoteRepository,
+	courseRepo repository.CourseRepository, reviews []models.Review,
+) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	reviewIds := make([]primitive.ObjectID, 0, len(reviews))
+	for _, review := range reviews {
+		deleted, err := reviewRepo.Delete(ctx, review.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = courseRepo.AdjustRating(
+			ctx, deleted.CourseId, -int64(deleted.Rating), -1,
+		)
+		if err != nil {
+			return err
+		}
+		reviewIds = append(reviewIds, deleted.ID)
+	}
+
+	if len(reviewIds) == 0 {
+		return nil
+	}
+
+	_, err := voteRepo.DeleteByReviews(ctx, reviewIds)
+	return err
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/mailer"
+	"github.com/AhmedHossam777/go-mongo/internal/models"
+	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidImportFile  = errors.New("invalid CSV file")
+	ErrPasswordAlreadySet = errors.New("user has already set a password")
+)
+
+// importBatchSize is how many rows are checked and inserted per round trip
+const importBatchSize = 500
+
+type UserImportService interface {
+	ImportUsers(
+		ctx context.Context, source io.Reader, importOptions dto.UserImportOptions,
+	) (*dto.UserImportReport, error)
+	// ResendPasswordSetup emails an account that has no password yet a new
+	// setup link, the earlier link stops working
+	ResendPasswordSetup(ctx context.Context, userId string) error
+}
+
+type userImportService struct {
+	userRepo       repository.UserRepository
+	membershipRepo repository.MembershipRepository
+	mailer         mailer.Mailer
+}
+
+func NewUserImportService(
+	userRepo repository.UserRepository,
+	membershipRepo repository.MembershipRepository, mailer mailer.Mailer,
+) UserImportService {
+	return &userImportService{
+		userRepo: userRepo, membershipRepo: membershipRepo, mailer: mailer,
+	}
+}
+
+type pendingImportRow struct {
+	// index points into the report rows, which keep growing while a batch
+	// is collected so pointers into them wouldn't stay valid
+	index int
+	row   dto.ImportUserRow
+}
+
+// ImportUsers reads a CSV with a name,email[,role] header and creates a user
+// per row. The file is streamed and handled in batches, so its size is not
+// bound by memory
+func (s *userImportService) ImportUsers(
+	ctx context.Context, source io.Reader, importOptions dto.UserImportOptions,
+) (*dto.UserImportReport, error) {
+	reader := csv.NewReader(source)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header row", ErrInvalidImportFile)
+	}
+
+	columns, err := importColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.UserImportReport{
+		DryRun: importOptions.DryRun,
+		Rows:   []dto.UserImportRowResult{},
+	}
+	seen := make(map[string]bool)
+	batch := make([]pendingImportRow, 0, importBatchSize)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		report.Rows = append(report.Rows, dto.UserImportRowResult{Row: line})
+		index := len(report.Rows) - 1
+		result := &report.Rows[index]
+
+		if err != nil {
+			// Quoting errors are per line, keep going with the next one
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Status = dto.ImportRowFailed
+				result.Reason = parseErr.Err.Error()
+				continue
+			}
+			return nil, err
+		}
+
+		row := dto.ImportUserRow{
+			Name:  columnValue(record, columns, "name"),
+			Email: columnValue(record, columns, "email"),
+			Role:  strings.ToLower(columnValue(record, columns, "role")),
+		}
+		result.Email = row.Email
+
+		validationErr := helpers.ValidateStruct(row)
+		if validationErr != nil {
+			result.Status = dto.ImportRowFailed
+			result.Reason = joinValidationErrors(validationErr)
+			continue
+		}
+
+		emailKey := strings.ToLower(row.Email)
+		if seen[emailKey] {
+			result.Status = dto.ImportRowSkipped
+			result.Reason = "duplicate email in file"
+			continue
+		}
+		seen[emailKey] = true
+
+		batch = append(batch, pendingImportRow{index: index, row: row})
+		if len(batch) == importBatchSize {
+			err = s.importBatch(ctx, report, batch, importOptions)
+			if err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		err = s.importBatch(ctx, report, batch, importOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case dto.ImportRowCreated:
+			report.Created++
+		case dto.ImportRowSkipped:
+			report.Skipped++
+		case dto.ImportRowFailed:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *userImportService) importBatch(
+	ctx context.Context, report *dto.UserImportReport, batch []pendingImportRow,
+	importOptions dto.UserImportOptions,
+) error {
+	emails := make([]string, len(batch))
+	for i, pending := range batch {
+		emails[i] = pending.row.Email
+	}
+
+	existing, err := s.userRepo.FindExistingEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+
+	expiryHours := helpers.GetEnvInt("PASSWORD_SETUP_EXPIRY_HOURS", 72)
+	users := make([]*models.User, 0, len(batch))
+	toCreate := make([]pendingImportRow, 0, len(batch))
+	tokens := make([]string, 0, len(batch))
+
+	for _, pending := range batch {
+		result := &report.Rows[pending.index]
+		if existing[pending.row.Email] {
+			result.Status = dto.ImportRowSkipped
+			result.Reason = "email already exists"
+			continue
+		}
+
+		if importOptions.DryRun {
+			result.Status = dto.ImportRowCreated
+			continue
+		}
+
+		token, err := helpers.GenerateRandomToken()
+		if err != nil {
+			return err
+		}
+
+		role := pending.row.Role
+		if role == "" {
+			role = "user"
+		}
+
+		// No password until the user sets one through the setup link, an
+		// empty hash never matches so the account can't be logged into
+		users = append(users, &models.User{
+			Name:  pending.row.Name,
+			Email: pending.row.Email,
+			Role:  role,
+			PasswordSetup: &models.PasswordSetup{
+				TokenHash: helpers.HashToken(token),
+				ExpiresAt: time.Now().Add(time.Duration(expiryHours) * time.Hour),
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		toCreate = append(toCreate, pending)
+		tokens = append(tokens, token)
+	}
+
+	if len(users) == 0 {
+		return nil
+	}
+
+	failures, err := s.userRepo.CreateUsers(ctx, users)
+	if err != nil {
+		return err
+	}
+
+	memberships := make([]*models.Membership, 0, len(users))
+	for i, pending := range toCreate {
+		result := &report.Rows[pending.index]
+		if failure, ok := failures[i]; ok {
+			result.Status = dto.ImportRowFailed
+			result.Reason = failure.Error()
+			continue
+		}
+
+		result.Status = dto.ImportRowCreated
+		result.UserId = &users[i].ID
+
+		if users[i].TenantId != nil {
+			memberships = append(memberships, &models.Membership{
+				OrganizationId: *users[i].TenantId,
+				UserId:         users[i].ID,
+				Role:           users[i].Role,
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			})
+		}
+
+		if importOptions.SendInvites {
+			err = s.sendInvite(ctx, users[i], tokens[i], expiryHours)
+			if err != nil {
+				// The account exists either way, report the row but go on
+				result.Reason = "created, but the invitation email failed"
+			}
+		}
+	}
+
+	return s.membershipRepo.CreateMany(ctx, memberships)
+}
+
+func (s *userImportService) ResendPasswordSetup(
+	ctx context.Context, userId string,
+) error {
+	userObjId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	user, err := s.userRepo.GetOneUser(ctx, userObjId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordSetup == nil {
+		return ErrPasswordAlreadySet
+	}
+
+	token, err := helpers.GenerateRandomToken()
+	if err != nil {
+		return err
+	}
+
+	expiryHours := helpers.GetEnvInt("PASSWORD_SETUP_EXPIRY_HOURS", 72)
+	_, err = s.userRepo.UpdateOneUser(ctx, user.ID, bson.M{"$set": bson.M{
+		"password_setup": models.PasswordSetup{
+			TokenHash: helpers.HashToken(token),
+			ExpiresAt: time.Now().Add(time.Duration(expiryHours) * time.Hour),
+		},
+		"updated_at": time.Now(),
+	}})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.sendInvite(ctx, user, token, expiryHours)
+}
+
+func (s *userImportService) sendInvite(
+	ctx context.Context, user *models.User, token string, expiryHours int,
+) error {
+	return s.mailer.Send(
+		ctx, user.Email, "You have been invited",
+		fmt.Sprintf(
+			"Hi %s,\n\nAn account has been created for you. Choose a password "+
+				"within %d hours using the link below:\n\n%s",
+			user.Name, expiryHours,
+			helpers.AppURL("/set-password?token="+token),
+		),
+	)
+}
+
+// importColumns maps the known column names to their position in the
+// header, matching case-insensitively
+func importColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "name" || name == "email" || name == "role" {
+			columns[name] = i
+		}
+	}
+
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf(
+				"%w: header must contain a %q column", ErrInvalidImportFile, required,
+			)
+		}
+	}
+
+	return columns, nil
+}
+
+func columnValue(record []string, columns map[string]int, name string) string {
+	index, ok := columns[name]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func joinValidationErrors(validationErr []helpers.ValidationError) string {
+	messages := make([]string, len(validationErr))
+	for i, fieldErr := range validationErr {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
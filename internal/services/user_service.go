@@ -1,12 +1,23 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/AhmedHossam777/go-mongo/internal/dto"
+	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/mailer"
 	"github.com/AhmedHossam777/go-mongo/internal/models"
 	"github.com/AhmedHossam777/go-mongo/internal/repository"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -15,40 +26,207 @@ import (
 var (
 	ErrUserNotFound  = errors.New("user not found")
 	ErrInvalidUserID = errors.New("invalid user ID")
+
+	ErrAvatarNotFound    = errors.New("avatar not found")
+	ErrAvatarTooLarge    = errors.New("avatar exceeds the maximum allowed size")
+	ErrInvalidAvatarType = errors.New("avatar must be a JPEG, PNG, GIF or WebP image")
+
+	ErrIncorrectPassword = errors.New("incorrect password")
+
+	ErrEmailUnchanged          = errors.New("new email is the same as the current one")
+	ErrNoPendingEmailChange    = errors.New("no pending email change")
+	ErrInvalidEmailChangeToken = errors.New("email change link is invalid or has expired")
+	ErrInvalidPasswordSetup    = errors.New("password setup link is invalid or has expired")
+
+	ErrUserOwnsCourses    = errors.New("user still owns courses")
+	ErrInvalidPurgePolicy = errors.New("invalid user purge course policy")
+)
+
+// What happens to a purged user's courses, set with USER_PURGE_COURSE_POLICY
+const (
+	// CoursePolicyRestrict keeps the user soft deleted while they own courses
+	CoursePolicyRestrict = "restrict"
+	// CoursePolicyDelete deletes the user's courses along with them
+	CoursePolicyDelete = "delete"
+	// CoursePolicyReassign hands the courses over to USER_PURGE_REASSIGN_TO
+	CoursePolicyReassign = "reassign"
 )
 
+// MaxAvatarSize is the largest avatar image accepted, in bytes
+const MaxAvatarSize = 2 << 20
+
+var allowedAvatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
 type UserService interface {
-	GetAllUsers(ctx context.Context, page, pageSize int64) (
-		[]models.User, int64, error,
-	)
+	GetAllUsers(
+		ctx context.Context, query dto.UserListQuery, page, pageSize int64,
+	) ([]models.User, int64, dto.PageCursors, error)
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
 	GetOneUser(ctx context.Context, id string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
-	UpdateUser(ctx context.Context, id string, user *dto.UpdateUserDto) (
+	// UpdateUser changes a user's profile, only the user and admins may
+	UpdateUser(
+		ctx context.Context, id string, callerId string, callerRole string,
+		user *dto.UpdateUserDto,
+	) (*models.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	RestoreUser(ctx context.Context, id string) (*models.User, error)
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
+	ScheduleDeletion(ctx context.Context, id string, password string) (
 		*models.User, error,
 	)
-	DeleteUser(ctx context.Context, id string) error
+	CancelScheduledDeletion(ctx context.Context, id string) (
+		*models.User, error,
+	)
+	RunScheduledDeletions(ctx context.Context) (int64, error)
 	DropUserCollection(ctx context.Context) error
+	UpdateAvatar(
+		ctx context.Context, id string, source io.Reader, size int64,
+	) (*models.User, error)
+	GetAvatar(ctx context.Context, id string, viewerId string, viewerRole string) (
+		*models.File, io.ReadCloser, error,
+	)
+	DeleteAvatar(ctx context.Context, id string) (*models.User, error)
+	UpdatePrivacy(
+		ctx context.Context, id string, privacyDto *dto.UpdatePrivacyDto,
+	) (*models.User, error)
+	RequestEmailChange(
+		ctx context.Context, id string, emailDto *dto.RequestEmailChangeDto,
+	) (*models.User, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*models.User, error)
+	CancelEmailChange(ctx context.Context, id string) (*models.User, error)
+	CompletePasswordSetup(ctx context.Context, token string, password string) (
+		*models.User, error,
+	)
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo             repository.UserRepository
+	avatarRepo       repository.FileRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	courseService    CourseService
+	membershipRepo   repository.MembershipRepository
+	exportRepo       repository.DataExportRepository
+	exportFileRepo   repository.FileRepository
+	applicationRepo  repository.InstructorApplicationRepository
+	invitationRepo   repository.InvitationRepository
+	mailer           mailer.Mailer
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(
+	repo repository.UserRepository, avatarRepo repository.FileRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	courseService CourseService,
+	membershipRepo repository.MembershipRepository,
+	exportRepo repository.DataExportRepository,
+	exportFileRepo repository.FileRepository,
+	applicationRepo repository.InstructorApplicationRepository,
+	invitationRepo repository.InvitationRepository, mailer mailer.Mailer,
+) UserService {
+	return &userService{
+		repo:             repo,
+		avatarRepo:       avatarRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		courseService:    courseService,
+		membershipRepo:   membershipRepo,
+		exportRepo:       exportRepo,
+		exportFileRepo:   exportFileRepo,
+		applicationRepo:  applicationRepo,
+		invitationRepo:   invitationRepo,
+		mailer:           mailer,
+	}
 }
 
+// CreateUser stores a user, users created for a tenant also become members
+// of its organization with their role
 func (s *userService) CreateUser(
 	ctx context.Context, user *models.User,
 ) (*models.User, error) {
-	return s.repo.CreateUser(ctx, user)
+	createdUser, err := s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if createdUser.TenantId != nil {
+		_, err = s.membershipRepo.Create(
+			ctx, &models.Membership{
+				OrganizationId: *createdUser.TenantId,
+				UserId:         createdUser.ID,
+				Role:           createdUser.Role,
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return createdUser, nil
 }
 
+// GetAllUsers lists one page of users, starting at query's cursor when it
+// has one and at page otherwise
 func (s *userService) GetAllUsers(
-	ctx context.Context, page int64, pageSize int64,
-) ([]models.User, int64, error) {
-	return s.repo.GetAllUsers(ctx, page, pageSize)
+	ctx context.Context, query dto.UserListQuery, page int64, pageSize int64,
+) ([]models.User, int64, dto.PageCursors, error) {
+	filter := repository.UserFilter{
+		Role:        query.Role,
+		Search:      query.Search,
+		SearchEmail: query.AdminView,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+		SortBy:      query.Sort,
+		// Newest first unless asked otherwise, matching the old behaviour
+		SortDesc: query.Order != "asc",
+	}
+
+	sortBy := query.Sort
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	sort := sortBy + ":asc"
+	if filter.SortDesc {
+		sort = sortBy + ":desc"
+	}
+	timeField := sortBy == "created_at" || sortBy == "updated_at"
+
+	var err error
+	filter.Keyset, err = decodeKeyset(query.Cursor, sort, timeField)
+	if err != nil {
+		return nil, 0, dto.PageCursors{}, err
+	}
+
+	users, totalCount, err := s.repo.GetAllUsers(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, dto.PageCursors{}, err
+	}
+
+	users, cursors, err := keysetPage(
+		users, pageSize, page, filter.Keyset, sort,
+		func(user models.User) (string, primitive.ObjectID) {
+			switch sortBy {
+			case "updated_at":
+				return cursorTime(user.UpdatedAt), user.ID
+			case "name":
+				return user.Name, user.ID
+			case "email":
+				return user.Email, user.ID
+			default:
+				return cursorTime(user.CreatedAt), user.ID
+			}
+		},
+	)
+	if err != nil {
+		return nil, 0, dto.PageCursors{}, err
+	}
+
+	return users, totalCount, cursors, nil
 }
 
 func (s *userService) GetOneUser(
@@ -86,7 +264,8 @@ func (s *userService) GetUserByEmail(
 }
 
 func (s *userService) UpdateUser(
-	ctx context.Context, id string, updateUserDto *dto.UpdateUserDto,
+	ctx context.Context, id string, callerId string, callerRole string,
+	updateUserDto *dto.UpdateUserDto,
 ) (*models.User, error) {
 
 	objId, err := primitive.ObjectIDFromHex(id)
@@ -94,15 +273,32 @@ func (s *userService) UpdateUser(
 		return nil, ErrInvalidUserID
 	}
 
-	var update = bson.M{}
-	if updateUserDto.Name != nil {
-		update["Name"] = updateUserDto.Name
+	if callerRole != "admin" && callerId != id {
+		return nil, ErrForbidden
 	}
-	if updateUserDto.Email != nil {
-		update["Email"] = updateUserDto.Email
+
+	var update = bson.M{"updated_at": time.Now()}
+	if updateUserDto.Name != nil {
+		update["name"] = *updateUserDto.Name
 	}
 	if updateUserDto.Password != nil {
-		update["Password"] = updateUserDto.Password
+		hashedPassword, err := helpers.HashPassword(*updateUserDto.Password)
+		if err != nil {
+			return nil, err
+		}
+		update["password"] = hashedPassword
+	}
+	if updateUserDto.Bio != nil {
+		update["bio"] = *updateUserDto.Bio
+	}
+	if updateUserDto.Headline != nil {
+		update["headline"] = *updateUserDto.Headline
+	}
+	if updateUserDto.Timezone != nil {
+		update["timezone"] = *updateUserDto.Timezone
+	}
+	if updateUserDto.Language != nil {
+		update["language"] = *updateUserDto.Language
 	}
 
 	updatedUser, err := s.repo.UpdateOneUser(ctx, objId, bson.M{"$set": update})
@@ -114,6 +310,15 @@ func (s *userService) UpdateUser(
 	if err != nil {
 		return nil, err
 	}
+
+	// A new password signs out every session that knew the old one
+	if updateUserDto.Password != nil {
+		_, err = s.refreshTokenRepo.RevokeAllUserTokens(ctx, objId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return updatedUser, nil
 }
 
@@ -124,11 +329,331 @@ func (s *userService) DeleteUser(
 	if err != nil {
 		return ErrInvalidUserID
 	}
-	err = s.repo.DeleteOneUser(ctx, objId)
+	err = s.repo.SoftDeleteUser(ctx, objId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
 
+	// Sessions end right away, the document itself is purged later
+	_, err = s.refreshTokenRepo.RevokeAllUserTokens(ctx, objId)
+	return err
+}
+
+func (s *userService) RestoreUser(
+	ctx context.Context, id string,
+) (*models.User, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	user, err := s.repo.RestoreUser(ctx, objId)
 	if errors.Is(err, mongo.ErrNoDocuments) {
-		return ErrCourseNotFound
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// PurgeDeletedUsers permanently removes users that were soft deleted more
+// than USER_PURGE_RETENTION_DAYS ago and returns how many were purged
+func (s *userService) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	retentionDays := helpers.GetEnvInt("USER_PURGE_RETENTION_DAYS", 30)
+	cutoff := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+
+	users, err := s.repo.FindDeletedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	// One user failing doesn't hold back the others, the failures are
+	// reported together and retried on the next run
+	var purged int64
+	var failures []error
+	for i := range users {
+		err = s.purgeUser(ctx, &users[i])
+		switch {
+		case errors.Is(err, ErrUserOwnsCourses):
+			err = s.reportPurgeBlocked(ctx, &users[i])
+		case errors.Is(err, mongo.ErrNoDocuments):
+			// Restored or purged by someone else meanwhile
+			err = nil
+		case err == nil:
+			purged++
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf(
+				"failed to purge user %s: %w", users[i].ID.Hex(), err,
+			))
+		}
+	}
+
+	return purged, errors.Join(failures...)
+}
+
+// reportPurgeBlocked logs that a deleted user still owns courses the first
+// time the purge job runs into it
+func (s *userService) reportPurgeBlocked(
+	ctx context.Context, user *models.User,
+) error {
+	if user.PurgeBlockedAt != nil {
+		return nil
+	}
+
+	first, err := s.repo.MarkPurgeBlocked(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if first {
+		log.Printf(
+			"not purging user %s until their courses are deleted or reassigned",
+			user.ID.Hex(),
+		)
+	}
+
+	return nil
+}
+
+// ScheduleDeletion confirms the user's password, ends all their sessions and
+// schedules the account for deletion after ACCOUNT_DELETION_GRACE_DAYS
+func (s *userService) ScheduleDeletion(
+	ctx context.Context, id string, password string,
+) (*models.User, error) {
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !helpers.CheckPassword(user.Password, password) {
+		return nil, ErrIncorrectPassword
+	}
+
+	graceDays := helpers.GetEnvInt("ACCOUNT_DELETION_GRACE_DAYS", 14)
+	deleteAt := time.Now().Add(time.Duration(graceDays) * 24 * time.Hour)
+
+	updatedUser, err := s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{
+				"deletion_scheduled_at": deleteAt,
+				"updated_at":            time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.refreshTokenRepo.RevokeAllUserTokens(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+func (s *userService) CancelScheduledDeletion(
+	ctx context.Context, id string,
+) (*models.User, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	user, err := s.repo.UpdateOneUser(
+		ctx, objId, bson.M{
+			"$unset": bson.M{"deletion_scheduled_at": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RunScheduledDeletions deletes accounts whose grace period has ended and
+// returns how many were deleted
+func (s *userService) RunScheduledDeletions(ctx context.Context) (
+	int64, error,
+) {
+	users, err := s.repo.FindDueForDeletion(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	var failures []error
+	for i := range users {
+		err = s.runScheduledDeletion(ctx, users[i].ID)
+		// The user is already gone or cancelled the deletion meanwhile
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf(
+				"failed to delete user %s: %w", users[i].ID.Hex(), err,
+			))
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, errors.Join(failures...)
+}
+
+// runScheduledDeletion deletes the user unless they cancelled the deletion
+// since it was found due, mongo.ErrNoDocuments means nothing was deleted
+func (s *userService) runScheduledDeletion(
+	ctx context.Context, userId primitive.ObjectID,
+) error {
+	user, err := s.repo.GetOneUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil || user.DeletionScheduledAt.After(time.Now()) {
+		return mongo.ErrNoDocuments
+	}
+
+	err = s.purgeUser(ctx, user)
+	if errors.Is(err, ErrUserOwnsCourses) {
+		// Hide the account now and leave its courses to the purge job
+		return s.repo.SoftDeleteUser(ctx, user.ID)
+	}
+	return err
+}
+
+// CheckPurgePolicy reports whether USER_PURGE_COURSE_POLICY, and the
+// USER_PURGE_REASSIGN_TO user it may need, are set to something the purge
+// jobs can apply
+func CheckPurgePolicy() error {
+	_, _, err := purgePolicy()
+	return err
+}
+
+// purgePolicy reads USER_PURGE_COURSE_POLICY and, for the reassign policy,
+// the user taking over the courses
+func purgePolicy() (string, primitive.ObjectID, error) {
+	policy := helpers.GetEnvString("USER_PURGE_COURSE_POLICY", CoursePolicyRestrict)
+
+	switch policy {
+	case CoursePolicyRestrict, CoursePolicyDelete:
+		return policy, primitive.NilObjectID, nil
+	case CoursePolicyReassign:
+		newOwner, err := primitive.ObjectIDFromHex(os.Getenv("USER_PURGE_REASSIGN_TO"))
+		if err != nil {
+			return "", primitive.NilObjectID, fmt.Errorf(
+				"%w: USER_PURGE_REASSIGN_TO must be a user ID",
+				ErrInvalidPurgePolicy,
+			)
+		}
+		return policy, newOwner, nil
+	default:
+		return "", primitive.NilObjectID, fmt.Errorf(
+			"%w: %q", ErrInvalidPurgePolicy, policy,
+		)
+	}
+}
+
+func (s *userService) purgeUser(ctx context.Context, user *models.User) error {
+	policy, newOwner, err := purgePolicy()
+	if err != nil {
+		return err
+	}
+
+	switch policy {
+	case CoursePolicyRestrict:
+		owned, err := s.courseService.CountInstructorCourses(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if owned > 0 {
+			return ErrUserOwnsCourses
+		}
+	case CoursePolicyDelete:
+		_, err = s.courseService.DeleteInstructorCourses(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	case CoursePolicyReassign:
+		_, err = s.courseService.ReassignInstructorCourses(ctx, user.ID, newOwner)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.courseService.DeleteLearnerData(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteExports(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.applicationRepo.DeleteByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.invitationRepo.DeleteByUser(ctx, user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.refreshTokenRepo.DeleteAllUserTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if user.AvatarId != nil {
+		err = s.avatarRepo.Delete(ctx, *user.AvatarId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+
+	err = s.membershipRepo.DeleteByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteOneUser(ctx, user.ID)
+}
+
+// deleteExports deletes the user's data exports along with their archives
+func (s *userService) deleteExports(
+	ctx context.Context, userId primitive.ObjectID,
+) error {
+	exports, err := s.exportRepo.FindByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.FileId != nil {
+			err = s.exportFileRepo.Delete(ctx, *export.FileId)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return err
+			}
+		}
+
+		err = s.exportRepo.DeleteOne(ctx, export.ID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
 	}
+
 	return nil
 }
 
@@ -139,3 +664,337 @@ func (s *userService) DropUserCollection(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (s *userService) UpdateAvatar(
+	ctx context.Context, id string, source io.Reader, size int64,
+) (*models.User, error) {
+	if size > MaxAvatarSize {
+		return nil, ErrAvatarTooLarge
+	}
+
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sniff the real content type instead of trusting the client header
+	head := make([]byte, 512)
+	n, err := io.ReadFull(source, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !allowedAvatarTypes[contentType] {
+		return nil, ErrInvalidAvatarType
+	}
+
+	file, err := s.avatarRepo.Upload(
+		ctx, "avatar-"+user.ID.Hex(),
+		io.LimitReader(io.MultiReader(bytes.NewReader(head), source),
+			MaxAvatarSize+1),
+		models.FileMetadata{ContentType: contentType, OwnerId: user.ID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Length > MaxAvatarSize {
+		_ = s.avatarRepo.Delete(ctx, file.ID)
+		return nil, ErrAvatarTooLarge
+	}
+
+	updatedUser, err := s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{"avatar_id": file.ID, "updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		_ = s.avatarRepo.Delete(ctx, file.ID)
+		return nil, err
+	}
+
+	// The previous avatar is only removed once the new one is in place
+	if user.AvatarId != nil {
+		_ = s.avatarRepo.Delete(ctx, *user.AvatarId)
+	}
+
+	return updatedUser, nil
+}
+
+func (s *userService) GetAvatar(
+	ctx context.Context, id string, viewerId string, viewerRole string,
+) (*models.File, io.ReadCloser, error) {
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user.AvatarId == nil {
+		return nil, nil, ErrAvatarNotFound
+	}
+
+	// A hidden avatar looks exactly like a missing one to everybody else
+	canSeePrivate := viewerRole == "admin" || viewerId == user.ID.Hex()
+	if !canSeePrivate && !user.EffectivePrivacy().ShowAvatar {
+		return nil, nil, ErrAvatarNotFound
+	}
+
+	file, stream, err := s.avatarRepo.Open(ctx, *user.AvatarId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrAvatarNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, stream, nil
+}
+
+func (s *userService) DeleteAvatar(
+	ctx context.Context, id string,
+) (*models.User, error) {
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AvatarId == nil {
+		return nil, ErrAvatarNotFound
+	}
+
+	updatedUser, err := s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$unset": bson.M{"avatar_id": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.avatarRepo.Delete(ctx, *user.AvatarId)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+func (s *userService) UpdatePrivacy(
+	ctx context.Context, id string, privacyDto *dto.UpdatePrivacyDto,
+) (*models.User, error) {
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	privacy := user.EffectivePrivacy()
+	if privacyDto.ShowEmail != nil {
+		privacy.ShowEmail = *privacyDto.ShowEmail
+	}
+	if privacyDto.ShowAvatar != nil {
+		privacy.ShowAvatar = *privacyDto.ShowAvatar
+	}
+	if privacyDto.ShowBio != nil {
+		privacy.ShowBio = *privacyDto.ShowBio
+	}
+	if privacyDto.ShowHeadline != nil {
+		privacy.ShowHeadline = *privacyDto.ShowHeadline
+	}
+	if privacyDto.ShowJoinDate != nil {
+		privacy.ShowJoinDate = *privacyDto.ShowJoinDate
+	}
+
+	updatedUser, err := s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{"privacy": privacy, "updated_at": time.Now()},
+		},
+	)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+// RequestEmailChange stores newEmail as a pending change and mails a
+// confirmation link to it. The current email stays in place until the link
+// is used
+func (s *userService) RequestEmailChange(
+	ctx context.Context, id string, emailDto *dto.RequestEmailChangeDto,
+) (*models.User, error) {
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !helpers.CheckPassword(user.Password, emailDto.Password) {
+		return nil, ErrIncorrectPassword
+	}
+
+	newEmail := strings.TrimSpace(emailDto.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrEmailUnchanged
+	}
+
+	// Checked again on confirmation, this only gives early feedback
+	existing, err := s.repo.GetUserByEmail(ctx, newEmail)
+	if existing != nil {
+		return nil, ErrEmailAlreadyExists
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	token, err := helpers.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiryHours := helpers.GetEnvInt("EMAIL_CHANGE_EXPIRY_HOURS", 24)
+	pending := models.PendingEmailChange{
+		Email:       newEmail,
+		TokenHash:   helpers.HashToken(token),
+		RequestedAt: time.Now(),
+		ExpiresAt:   time.Now().Add(time.Duration(expiryHours) * time.Hour),
+	}
+
+	updatedUser, err := s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{"pending_email": pending, "updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(
+		ctx, newEmail, "Confirm your new email address",
+		fmt.Sprintf(
+			"Hi %s,\n\nConfirm %s as your new email address by opening the "+
+				"link below within %d hours:\n\n%s\n\n"+
+				"If you didn't ask for this, you can ignore this email.",
+			user.Name, newEmail, expiryHours,
+			helpers.AppURL("/confirm-email?token="+token),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(
+		ctx, user.Email, "Your email address is being changed",
+		fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to change the email address of your "+
+				"account to %s. Nothing changes until the new address is "+
+				"confirmed.\n\nIf this wasn't you, cancel the change from your "+
+				"account settings and change your password.",
+			user.Name, newEmail,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+func (s *userService) ConfirmEmailChange(
+	ctx context.Context, token string,
+) (*models.User, error) {
+	// The token alone identifies the account, whichever tenant it is in
+	ctx = tenant.Unscoped(ctx)
+
+	user, err := s.repo.GetUserByEmailChangeToken(ctx, helpers.HashToken(token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PendingEmail == nil || time.Now().After(user.PendingEmail.ExpiresAt) {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	// The email_unique index is the real uniqueness check, another account
+	// may have taken the address since the change was requested
+	updatedUser, err := s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{
+				"email":      user.PendingEmail.Email,
+				"updated_at": time.Now(),
+			},
+			"$unset": bson.M{"pending_email": ""},
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrEmailAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedUser, nil
+}
+
+func (s *userService) CancelEmailChange(
+	ctx context.Context, id string,
+) (*models.User, error) {
+	user, err := s.GetOneUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PendingEmail == nil {
+		return nil, ErrNoPendingEmailChange
+	}
+
+	return s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$unset": bson.M{"pending_email": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+}
+
+// CompletePasswordSetup sets the first password of an account created
+// without one, using the token from its invitation email
+func (s *userService) CompletePasswordSetup(
+	ctx context.Context, token string, password string,
+) (*models.User, error) {
+	// The token alone identifies the account, whichever tenant it is in
+	ctx = tenant.Unscoped(ctx)
+
+	user, err := s.repo.GetUserByPasswordSetupToken(ctx, helpers.HashToken(token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidPasswordSetup
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordSetup == nil || time.Now().After(user.PasswordSetup.ExpiresAt) {
+		return nil, ErrInvalidPasswordSetup
+	}
+
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateOneUser(
+		ctx, user.ID, bson.M{
+			"$set": bson.M{
+				"password":   hashedPassword,
+				"updated_at": time.Now(),
+			},
+			"$unset": bson.M{"password_setup": ""},
+		},
+	)
+}
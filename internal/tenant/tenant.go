@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Header lets platform admins pick the organization a request is made for.
+// Members may send their own organization, tokens issued to them carry
+// their tenant and take precedence
+const Header = "X-Tenant-ID"
+
+type contextKey struct{}
+
+type requestedKey struct{}
+
+// WithTenant returns a context whose repository queries are scoped to the
+// given organization
+func WithTenant(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// WithoutTenant returns a context whose repository queries only see
+// documents that belong to no organization, which is all anonymous callers
+// and users outside every organization get to see
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, primitive.NilObjectID)
+}
+
+// Unscoped returns a context that sees every tenant, for platform admins
+// and for looking up accounts before it is known which tenant they are in
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, nil)
+}
+
+// FromContext returns the organization ctx is scoped to, if any. A zero ID
+// means ctx sees no organization's documents. Contexts without one
+// (platform admins, background jobs) see every tenant
+func FromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	id, ok := ctx.Value(contextKey{}).(primitive.ObjectID)
+	return id, ok
+}
+
+// WithRequested remembers the organization a request asked for. It doesn't
+// scope anything until the caller is known to be allowed in
+func WithRequested(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, requestedKey{}, id)
+}
+
+// RequestedFromContext returns the organization the request asked for
+func RequestedFromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	id, ok := ctx.Value(requestedKey{}).(primitive.ObjectID)
+	return id, ok
+}
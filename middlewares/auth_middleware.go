@@ -7,6 +7,8 @@ import (
 
 	"github.com/AhmedHossam777/go-mongo/internal/handlers"
 	"github.com/AhmedHossam777/go-mongo/internal/helpers"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func AuthMiddleware(next http.Handler) http.Handler {
@@ -39,7 +41,49 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		ctx = context.WithValue(ctx, "userEmail", claim.Email)
 		ctx = context.WithValue(ctx, "userRole", claim.Role)
 
+		// Organization members are confined to their own tenant, platform
+		// admins may pick any and everyone else sees no organization's data
+		requested, isRequested := tenant.RequestedFromContext(ctx)
+		switch {
+		case claim.TenantId != "":
+			tenantId, err := primitive.ObjectIDFromHex(claim.TenantId)
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusUnauthorized,
+					"Invalid or expired token: invalid tenant")
+				return
+			}
+
+			if isRequested && requested != tenantId {
+				handlers.RespondWithError(w, http.StatusForbidden,
+					"You don't have access to this organization")
+				return
+			}
+
+			ctx = tenant.WithTenant(ctx, tenantId)
+		case claim.Role == "admin" && isRequested:
+			ctx = tenant.WithTenant(ctx, requested)
+		case claim.Role == "admin":
+			ctx = tenant.Unscoped(ctx)
+		case isRequested:
+			handlers.RespondWithError(w, http.StatusForbidden,
+				"You don't have access to this organization")
+			return
+		}
+
 		//Call the next handler with updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// OptionalAuthMiddleware authenticates the request like AuthMiddleware when
+// it carries an Authorization header and lets anonymous requests through
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		AuthMiddleware(next).ServeHTTP(w, r)
+	})
+}
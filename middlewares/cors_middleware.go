@@ -13,7 +13,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 			)
 			w.Header().Set(
 				"Access-Control-Allow-Headers",
-				"Accept, Authorization, Content-Type, X-Requested-With",
+				"Accept, Authorization, Content-Type, X-Requested-With, X-Tenant-ID",
 			)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Max-Age", "3600")
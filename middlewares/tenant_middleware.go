@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TenantMiddleware scopes every request to documents outside any
+// organization and remembers the organization named in the X-Tenant-ID
+// header. AuthMiddleware decides whether the caller may see it, anonymous
+// callers never do
+func TenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tenant.WithoutTenant(r.Context())
+
+		header := r.Header.Get(tenant.Header)
+		if header != "" {
+			tenantId, err := primitive.ObjectIDFromHex(header)
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusBadRequest,
+					"invalid "+tenant.Header+" header")
+				return
+			}
+			ctx = tenant.WithRequested(ctx, tenantId)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
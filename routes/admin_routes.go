@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterAdminRoutes(
+	router *http.ServeMux, userImportHandler *handlers.UserImportHandler,
+) {
+	const basePath = "/api/v1/admin"
+
+	router.Handle("POST "+basePath+"/users/import", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(userImportHandler.ImportUsers)),
+	))
+	router.Handle("POST "+basePath+"/users/{id}/password-setup", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(userImportHandler.ResendPasswordSetup)),
+	))
+}
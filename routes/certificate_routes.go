@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterCertificateRoutes(
+	router *http.ServeMux, certificateHandler *handlers.CertificateHandler,
+) {
+	const basePath = "/api/v1/certificates"
+
+	//? anybody holding a code can check it, no account needed
+	router.HandleFunc("GET "+basePath+"/{code}",
+		certificateHandler.VerifyCertificate)
+
+	//? learners get their own certificates
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "GET",
+			path:    "/api/v1/courses/{id}/certificate",
+			handler: certificateHandler.GetCertificate,
+		},
+		{
+			method:  "GET",
+			path:    "/api/v1/courses/{id}/certificate/pdf",
+			handler: certificateHandler.DownloadCertificate,
+		},
+		{
+			method:  "GET",
+			path:    "/api/v1/users/me/certificates",
+			handler: certificateHandler.GetMyCertificates,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+
+	//? admin only routes
+	router.Handle("POST "+basePath+"/{code}/revoke", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(
+			http.HandlerFunc(certificateHandler.RevokeCertificate),
+		),
+	))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterCourseCloneRoutes(
+	router *http.ServeMux, cloneHandler *handlers.CourseCloneHandler,
+) {
+	//? only the course's instructor copies it
+	router.Handle("POST /api/v1/courses/{id}/clone",
+		middlewares.AuthMiddleware(http.HandlerFunc(cloneHandler.CloneCourse)))
+}
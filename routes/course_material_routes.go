@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterCourseMaterialRoutes(
+	router *http.ServeMux, materialHandler *handlers.CourseMaterialHandler,
+) {
+	var basePath = "/api/v1/courses/{id}/materials"
+	// Signed in instructors and admins also see unpublished courses'
+	// materials, downloading needs an enrollment
+	router.Handle("GET "+basePath,
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(materialHandler.ListMaterials)))
+	router.Handle("GET "+basePath+"/{materialId}",
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(materialHandler.DownloadMaterial)))
+
+	//? only the course's instructor manages its materials
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "POST",
+			path:    basePath,
+			handler: materialHandler.UploadMaterial,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/{materialId}",
+			handler: materialHandler.DeleteMaterial,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterCourseProgressRoutes(
+	router *http.ServeMux, progressHandler *handlers.CourseProgressHandler,
+) {
+	var basePath = "/api/v1/courses/{id}"
+
+	//? learners track their own progress, the course's instructor and
+	//? admins see the report
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "POST",
+			path:    basePath + "/lessons/{lessonId}/progress",
+			handler: progressHandler.RecordProgress,
+		},
+		{
+			method:  "GET",
+			path:    basePath + "/progress",
+			handler: progressHandler.GetProgress,
+		},
+		{
+			method:  "GET",
+			path:    basePath + "/progress/report",
+			handler: progressHandler.GetCourseReport,
+		},
+		{
+			method:  "GET",
+			path:    "/api/v1/users/me/continue",
+			handler: progressHandler.ContinueLearning,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterCourseRevisionRoutes(
+	router *http.ServeMux, revisionHandler *handlers.CourseRevisionHandler,
+) {
+	var basePath = "/api/v1/courses/{id}/revisions"
+
+	//? only the course's instructor sees and restores its revisions
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "GET",
+			path:    basePath,
+			handler: revisionHandler.GetRevisions,
+		},
+		{
+			method:  "GET",
+			path:    basePath + "/diff",
+			handler: revisionHandler.DiffRevisions,
+		},
+		{
+			method:  "GET",
+			path:    basePath + "/{rev}",
+			handler: revisionHandler.GetRevision,
+		},
+		{
+			method:  "POST",
+			path:    basePath + "/{rev}/restore",
+			handler: revisionHandler.RestoreRevision,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+}
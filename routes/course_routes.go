@@ -11,19 +11,24 @@ func RegisterCourseRoutes(
 	router *http.ServeMux, courseHandler *handlers.CourseHandler,
 ) {
 	var basePath = "/api/v1/courses"
-	router.HandleFunc("GET "+basePath, courseHandler.GetAllCourses)
-	router.HandleFunc("GET "+basePath+"/{id}", courseHandler.GetOneCourse)
+	// Signed in instructors and admins also see unpublished courses
+	router.Handle("GET "+basePath,
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(courseHandler.GetAllCourses)))
+	router.Handle("GET "+basePath+"/search",
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(courseHandler.SearchCourses)))
+	router.Handle("GET "+basePath+"/{id}",
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(courseHandler.GetOneCourse)))
+
+	//? only instructors and admins create courses
+	router.Handle("POST "+basePath, middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("instructor", "admin")(http.HandlerFunc(courseHandler.CreateCourse)),
+	))
 
 	protected := []struct {
 		method  string
 		path    string
 		handler http.HandlerFunc
 	}{
-		{
-			method:  "POST",
-			path:    basePath,
-			handler: courseHandler.CreateCourse,
-		},
 		{
 			method:  "PATCH",
 			path:    basePath + "/{id}",
@@ -34,6 +39,21 @@ func RegisterCourseRoutes(
 			path:    basePath + "/{id}",
 			handler: courseHandler.DeleteOneCourse,
 		},
+		{
+			method:  "POST",
+			path:    basePath + "/{id}/publish",
+			handler: courseHandler.PublishCourse,
+		},
+		{
+			method:  "POST",
+			path:    basePath + "/{id}/unpublish",
+			handler: courseHandler.UnpublishCourse,
+		},
+		{
+			method:  "POST",
+			path:    basePath + "/{id}/archive",
+			handler: courseHandler.ArchiveCourse,
+		},
 	}
 
 	for _, route := range protected {
@@ -47,7 +67,4 @@ func RegisterCourseRoutes(
 	))
 	// As your application grows, you might add more course-related endpoints here:
 	// router.HandleFunc("GET /courses/{id}/students", handler.GetCourseStudents)
-	// router.HandleFunc("POST /courses/{id}/enroll", handler.EnrollInCourse)
-	// router.HandleFunc("GET /courses/{id}/materials", handler.GetCourseMaterials)
-	// router.HandleFunc("POST /courses/{id}/reviews", handler.CreateCourseReview)
 }
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterCurriculumRoutes(
+	router *http.ServeMux, curriculumHandler *handlers.CurriculumHandler,
+) {
+	var basePath = "/api/v1/courses/{id}"
+	// Signed in instructors and admins also see unpublished curricula
+	router.Handle("GET "+basePath+"/curriculum",
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(curriculumHandler.GetCurriculum)))
+
+	//? only the course's instructor edits its curriculum
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "PUT",
+			path:    basePath + "/curriculum/order",
+			handler: curriculumHandler.Reorder,
+		},
+		{
+			method:  "POST",
+			path:    basePath + "/sections",
+			handler: curriculumHandler.AddSection,
+		},
+		{
+			method:  "PATCH",
+			path:    basePath + "/sections/{sectionId}",
+			handler: curriculumHandler.UpdateSection,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/sections/{sectionId}",
+			handler: curriculumHandler.DeleteSection,
+		},
+		{
+			method:  "POST",
+			path:    basePath + "/sections/{sectionId}/lessons",
+			handler: curriculumHandler.AddLesson,
+		},
+		{
+			method:  "PATCH",
+			path:    basePath + "/sections/{sectionId}/lessons/{lessonId}",
+			handler: curriculumHandler.UpdateLesson,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/sections/{sectionId}/lessons/{lessonId}",
+			handler: curriculumHandler.DeleteLesson,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+}
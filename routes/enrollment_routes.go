@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterEnrollmentRoutes(
+	router *http.ServeMux, enrollmentHandler *handlers.EnrollmentHandler,
+) {
+	var basePath = "/api/v1/courses/{id}"
+
+	//? learners enroll themselves, the course's instructor and admins manage
+	//? its enrollments
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "POST",
+			path:    basePath + "/enroll",
+			handler: enrollmentHandler.Enroll,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/enroll",
+			handler: enrollmentHandler.Unenroll,
+		},
+		{
+			method:  "GET",
+			path:    basePath + "/enrollments",
+			handler: enrollmentHandler.GetCourseEnrollments,
+		},
+		{
+			method:  "PATCH",
+			path:    basePath + "/enrollments/{userId}",
+			handler: enrollmentHandler.UpdateEnrollment,
+		},
+		{
+			method:  "GET",
+			path:    "/api/v1/users/{id}/enrolled-courses",
+			handler: enrollmentHandler.GetUserEnrollments,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+
+	//? admin only routes, enrolling a learner skips the prerequisite check
+	router.Handle("POST "+basePath+"/enrollments/{userId}", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(
+			http.HandlerFunc(enrollmentHandler.EnrollLearner),
+		),
+	))
+}
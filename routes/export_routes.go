@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterExportRoutes(
+	router *http.ServeMux, exportHandler *handlers.ExportHandler,
+) {
+	const basePath = "/api/v1/exports"
+
+	// The download link is signed, so it works without an access token
+	router.HandleFunc(
+		"GET "+basePath+"/{id}/download", exportHandler.DownloadExport,
+	)
+
+	router.Handle(
+		"GET "+basePath+"/{id}",
+		middlewares.AuthMiddleware(http.HandlerFunc(exportHandler.GetExport)),
+	)
+	router.Handle(
+		"POST /api/v1/users/me/export",
+		middlewares.AuthMiddleware(http.HandlerFunc(exportHandler.RequestMyExport)),
+	)
+
+	//? admin only routes
+	router.Handle("POST /api/v1/users/{id}/export", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(exportHandler.RequestUserExport)),
+	))
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterInstructorApplicationRoutes(
+	router *http.ServeMux, applicationHandler *handlers.InstructorApplicationHandler,
+) {
+	const basePath = "/api/v1/instructor-applications"
+
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"POST", basePath, applicationHandler.Apply},
+		{"GET", basePath + "/me", applicationHandler.GetMyApplications},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+
+	//? admin only routes
+	adminRoutes := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"GET", basePath, applicationHandler.GetAllApplications},
+		{"POST", basePath + "/{id}/approve", applicationHandler.Approve},
+		{"POST", basePath + "/{id}/reject", applicationHandler.Reject},
+	}
+
+	for _, route := range adminRoutes {
+		router.Handle(route.method+" "+route.path, middlewares.AuthMiddleware(
+			middlewares.RoleMiddleware("admin")(route.handler),
+		))
+	}
+}
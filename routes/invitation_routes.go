@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterInvitationRoutes(
+	router *http.ServeMux, invitationHandler *handlers.InvitationHandler,
+) {
+	const basePath = "/api/v1/invitations"
+
+	router.Handle("POST "+basePath, middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin", "instructor")(http.HandlerFunc(invitationHandler.CreateInvitation)),
+	))
+
+	//? admin only routes
+	router.Handle("GET "+basePath, middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(invitationHandler.GetAllInvitations)),
+	))
+	router.Handle("DELETE "+basePath+"/{id}", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(invitationHandler.RevokeInvitation)),
+	))
+}
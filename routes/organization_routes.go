@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterOrganizationRoutes(
+	router *http.ServeMux, organizationHandler *handlers.OrganizationHandler,
+) {
+	const basePath = "/api/v1/organizations"
+
+	//? admin only routes, organization admins are confined to their tenant
+	adminRoutes := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"POST", basePath, organizationHandler.CreateOrganization},
+		{"GET", basePath, organizationHandler.GetAllOrganizations},
+		{"GET", basePath + "/{id}", organizationHandler.GetOrganization},
+		{"GET", basePath + "/{id}/members", organizationHandler.GetMembers},
+		{"POST", basePath + "/{id}/members", organizationHandler.AddMember},
+		{"PATCH", basePath + "/{id}/members/{userId}", organizationHandler.UpdateMemberRole},
+	}
+
+	for _, route := range adminRoutes {
+		router.Handle(route.method+" "+route.path, middlewares.AuthMiddleware(
+			middlewares.RoleMiddleware("admin")(route.handler),
+		))
+	}
+}
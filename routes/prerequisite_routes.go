@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterPrerequisiteRoutes(
+	router *http.ServeMux, prerequisiteHandler *handlers.PrerequisiteHandler,
+) {
+	var basePath = "/api/v1/courses/{id}/prerequisites"
+	// Signed in learners also see which prerequisites they completed
+	router.Handle("GET "+basePath,
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(prerequisiteHandler.GetPrerequisites)))
+
+	//? only the course's instructor changes its prerequisites
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "POST",
+			path:    basePath,
+			handler: prerequisiteHandler.AddPrerequisite,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/{prerequisiteId}",
+			handler: prerequisiteHandler.RemovePrerequisite,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/AhmedHossam777/go-mongo/internal/handlers"
+	"github.com/AhmedHossam777/go-mongo/middlewares"
+)
+
+func RegisterReviewRoutes(
+	router *http.ServeMux, reviewHandler *handlers.ReviewHandler,
+) {
+	var basePath = "/api/v1/courses/{id}/reviews"
+	// Signed in instructors and admins also see unpublished courses' reviews
+	router.Handle("GET "+basePath,
+		middlewares.OptionalAuthMiddleware(http.HandlerFunc(reviewHandler.GetReviews)))
+
+	//? enrolled learners review, the course's instructor replies
+	protected := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{
+			method:  "POST",
+			path:    basePath,
+			handler: reviewHandler.CreateReview,
+		},
+		{
+			method:  "PATCH",
+			path:    basePath + "/{reviewId}",
+			handler: reviewHandler.UpdateReview,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/{reviewId}",
+			handler: reviewHandler.DeleteReview,
+		},
+		{
+			method:  "PUT",
+			path:    basePath + "/{reviewId}/reply",
+			handler: reviewHandler.ReplyToReview,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/{reviewId}/reply",
+			handler: reviewHandler.DeleteReply,
+		},
+		{
+			method:  "POST",
+			path:    basePath + "/{reviewId}/helpful",
+			handler: reviewHandler.MarkHelpful,
+		},
+		{
+			method:  "DELETE",
+			path:    basePath + "/{reviewId}/helpful",
+			handler: reviewHandler.UnmarkHelpful,
+		},
+	}
+
+	for _, route := range protected {
+		router.Handle(route.method+" "+route.path,
+			middlewares.AuthMiddleware(route.handler))
+	}
+}
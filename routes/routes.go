@@ -11,7 +11,20 @@ import (
 
 func SetupRoutes(
 	userHandler *handlers.UserHandler, courseHandler *handlers.CourseHandler,
-	authHandler *handlers.AuthHandler,
+	authHandler *handlers.AuthHandler, exportHandler *handlers.ExportHandler,
+	userImportHandler *handlers.UserImportHandler,
+	invitationHandler *handlers.InvitationHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	applicationHandler *handlers.InstructorApplicationHandler,
+	curriculumHandler *handlers.CurriculumHandler,
+	materialHandler *handlers.CourseMaterialHandler,
+	enrollmentHandler *handlers.EnrollmentHandler,
+	progressHandler *handlers.CourseProgressHandler,
+	certificateHandler *handlers.CertificateHandler,
+	reviewHandler *handlers.ReviewHandler,
+	prerequisiteHandler *handlers.PrerequisiteHandler,
+	revisionHandler *handlers.CourseRevisionHandler,
+	cloneHandler *handlers.CourseCloneHandler,
 ) http.Handler {
 
 	router := http.NewServeMux()
@@ -23,11 +36,27 @@ func SetupRoutes(
 	router.HandleFunc("GET /swagger/", httpSwagger.WrapHandler)
 
 	RegisterCourseRoutes(router, courseHandler)
+	RegisterCurriculumRoutes(router, curriculumHandler)
+	RegisterCourseMaterialRoutes(router, materialHandler)
+	RegisterEnrollmentRoutes(router, enrollmentHandler)
+	RegisterCourseProgressRoutes(router, progressHandler)
+	RegisterCertificateRoutes(router, certificateHandler)
+	RegisterReviewRoutes(router, reviewHandler)
+	RegisterPrerequisiteRoutes(router, prerequisiteHandler)
+	RegisterCourseRevisionRoutes(router, revisionHandler)
+	RegisterCourseCloneRoutes(router, cloneHandler)
 	RegisterUserRoutes(router, userHandler)
 	RegisterAuthRouts(router, authHandler)
+	RegisterExportRoutes(router, exportHandler)
+	RegisterAdminRoutes(router, userImportHandler)
+	RegisterInvitationRoutes(router, invitationHandler)
+	RegisterOrganizationRoutes(router, organizationHandler)
+	RegisterInstructorApplicationRoutes(router, applicationHandler)
 
-	// Wrap router with CORS and Rate Limit middleware
-	return middlewares.RateLimitMiddleware(middlewares.CORSMiddleware(router))
+	// Wrap router with CORS, Rate Limit and tenant resolution middleware
+	return middlewares.RateLimitMiddleware(
+		middlewares.CORSMiddleware(middlewares.TenantMiddleware(router)),
+	)
 }
 
 // @Summary Server Home
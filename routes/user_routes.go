@@ -11,10 +11,28 @@ func RegisterUserRoutes(
 	router *http.ServeMux, userHandler *handlers.UserHandler,
 ) {
 	const basePath = "/api/v1/users"
-	router.HandleFunc("POST "+basePath, userHandler.CreateUser)
-	router.HandleFunc("GET "+basePath, userHandler.GetAllUsers)
-	router.HandleFunc("GET "+basePath+"/{id}", userHandler.GetOneUser)
-	router.HandleFunc("PATCH "+basePath+"/{id}", userHandler.UpdateUser)
+	router.HandleFunc(
+		"POST "+basePath+"/email/confirm", userHandler.ConfirmEmailChange,
+	)
+	router.HandleFunc(
+		"POST "+basePath+"/password/setup", userHandler.CompletePasswordSetup,
+	)
+
+	// Open to anonymous callers, a token only widens what is visible
+	public := []struct {
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"GET", basePath, userHandler.GetAllUsers},
+		{"GET", basePath + "/{id}", userHandler.GetOneUser},
+		{"GET", basePath + "/{id}/avatar", userHandler.GetAvatar},
+	}
+
+	for _, route := range public {
+		router.Handle(route.method+" "+route.path,
+			middlewares.OptionalAuthMiddleware(route.handler))
+	}
 	//router.HandleFunc("DELETE "+basePath+"/{id}", userHandler.DeleteUser)
 
 	protected := []struct {
@@ -23,6 +41,13 @@ func RegisterUserRoutes(
 		handler http.HandlerFunc
 	}{
 		{"GET", "/api/v1/users/me", userHandler.GetMe},
+		{"PATCH", "/api/v1/users/{id}", userHandler.UpdateUser},
+		{"DELETE", "/api/v1/users/me", userHandler.DeleteMe},
+		{"PATCH", "/api/v1/users/me/privacy", userHandler.UpdatePrivacy},
+		{"POST", "/api/v1/users/me/email", userHandler.RequestEmailChange},
+		{"DELETE", "/api/v1/users/me/email", userHandler.CancelEmailChange},
+		{"PUT", "/api/v1/users/me/avatar", userHandler.UpdateAvatar},
+		{"DELETE", "/api/v1/users/me/avatar", userHandler.DeleteAvatar},
 	}
 
 	for _, route := range protected {
@@ -31,9 +56,15 @@ func RegisterUserRoutes(
 	}
 
 	//? admin only routes
+	router.Handle("POST "+basePath, middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(userHandler.CreateUser)),
+	))
 	router.Handle("DELETE "+basePath+"/{id}", middlewares.AuthMiddleware(
 		middlewares.RoleMiddleware("admin")(http.HandlerFunc(userHandler.DeleteUser)),
 	))
+	router.Handle("POST "+basePath+"/{id}/restore", middlewares.AuthMiddleware(
+		middlewares.RoleMiddleware("admin")(http.HandlerFunc(userHandler.RestoreUser)),
+	))
 	router.Handle("DELETE "+basePath+"/drop", middlewares.AuthMiddleware(
 		middlewares.RoleMiddleware("admin")(http.HandlerFunc(userHandler.DropUserCollection)),
 	))
@@ -41,7 +72,6 @@ func RegisterUserRoutes(
 	// router.HandleFunc("POST /users/login", handler.Login)
 	// router.HandleFunc("POST /users/logout", handler.Logout)
 	// router.HandleFunc("POST /users/refresh-token", handler.RefreshToken)
-	// router.HandleFunc("GET /users/{id}/enrolled-courses", handler.GetUserCourses)
 	// router.HandleFunc("PATCH /users/{id}/password", handler.ChangePassword)
 	// router.HandleFunc("POST /users/forgot-password", handler.ForgotPassword)
 	// router.HandleFunc("POST /users/reset-password", handler.ResetPassword)